@@ -42,6 +42,31 @@ export const updateSettings = async (settings: Record<string, string>) => {
   return response.data;
 };
 
+// Live server events (Server-Sent Events). EventSource can't send headers, so
+// the token goes in the query. Returns a function closing the stream.
+export const subscribeEvents = (
+  types: string[],
+  onEvent: (type: string, data: any) => void,
+  onOpen?: () => void
+) => {
+  const params = new URLSearchParams({ types: types.join(',') });
+  const token = localStorage.getItem('token');
+  if (token) {
+    params.set('token', token);
+  }
+  const base = import.meta.env.VITE_API_BASE_URL || '/api';
+  const source = new EventSource(`${base}/events?${params}`);
+  if (onOpen) {
+    source.onopen = onOpen;
+  }
+  for (const type of types) {
+    source.addEventListener(type, (e) => {
+      onEvent(type, JSON.parse((e as MessageEvent).data).data);
+    });
+  }
+  return () => source.close();
+};
+
 export const getStatus = async () => {
   const response = await api.get('/status');
   return response.data;
@@ -65,6 +90,8 @@ export interface Device {
   show_weather?: boolean;
   weather_lat?: number;
   weather_lon?: number;
+  last_seen_at?: string | null;
+  source?: string;
   created_at: string;
   model?: any;
 }
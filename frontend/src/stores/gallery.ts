@@ -1,5 +1,5 @@
 import { defineStore } from 'pinia';
-import { api } from '../api';
+import { api, subscribeEvents } from '../api';
 import { useSettingsStore } from './settings';
 
 export const useGalleryStore = defineStore('gallery', {
@@ -147,7 +147,7 @@ export const useGalleryStore = defineStore('gallery', {
       try {
         const res = await api.post(`/google/picker/process/${sessionId}`);
         if (res.status === 202) {
-          this.pollProgress(sessionId);
+          this.watchImport(res.data.job_id);
         } else {
           const { count } = res.data;
           this.importMessage = `Successfully added ${count} photos!`;
@@ -162,27 +162,32 @@ export const useGalleryStore = defineStore('gallery', {
       }
     },
 
-    pollProgress(sessionId: string) {
-      const progressInterval = setInterval(async () => {
-        try {
-          const pRes = await api.get(`/google/picker/progress/${sessionId}`);
-          const pData = pRes.data;
-          this.fetchPhotos();
+    // Follows the import job through live events instead of polling
+    watchImport(jobId: number) {
+      let done = false;
+      const update = (job: any) => {
+        if (done || job.id !== jobId) return;
+        this.fetchPhotos();
 
-          if (pData.status === 'done') {
-            clearInterval(progressInterval);
-            this.importMessage = `Successfully added ${pData.processed} photos!`;
-            setTimeout(() => (this.importMessage = ''), 5000);
-            this.loading = false;
-          } else if (pData.status === 'error') {
-            clearInterval(progressInterval);
-            this.importMessage = `Error: ${pData.error}`;
-            this.loading = false;
-          }
-        } catch (e) {
-          console.error('Progress poll error', e);
+        if (job.status === 'succeeded') {
+          done = true;
+          close();
+          this.importMessage = `Successfully added ${job.progress.processed} photos!`;
+          setTimeout(() => (this.importMessage = ''), 5000);
+          this.loading = false;
+        } else if (job.status === 'failed' || job.status === 'canceled') {
+          done = true;
+          close();
+          this.importMessage = `Error: ${job.error || job.status}`;
+          this.loading = false;
         }
-      }, 2000);
+      };
+      // The job may have finished before the stream was connected
+      const close = subscribeEvents(
+        ['job.updated'],
+        (_, job) => update(job),
+        async () => update((await api.get(`/jobs/${jobId}`)).data)
+      );
     },
   },
 });
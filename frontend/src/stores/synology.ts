@@ -60,7 +60,17 @@ export const useSynologyStore = defineStore('synology', {
     async sync() {
       this.loading = true;
       try {
-        await api.post('/synology/sync');
+        // The sync runs as a background job: wait for it to finish
+        const res = await api.post('/synology/sync');
+        let job = { id: res.data.job_id, status: res.data.status, error: '' };
+        while (!['succeeded', 'failed', 'canceled'].includes(job.status)) {
+          await new Promise((resolve) => setTimeout(resolve, 2000));
+          job = (await api.get(`/jobs/${job.id}`)).data;
+          await this.fetchCount();
+        }
+        if (job.status !== 'succeeded') {
+          throw new Error(job.error || `Sync ${job.status}`);
+        }
         await this.fetchCount();
       } catch (e: any) {
         throw e;
@@ -0,0 +1,2 @@
+-- Remove updated_at column from images table
+ALTER TABLE images DROP COLUMN updated_at;
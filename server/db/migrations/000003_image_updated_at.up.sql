@@ -0,0 +1,2 @@
+-- Add updated_at column to images table to detect changed source files
+ALTER TABLE images ADD COLUMN updated_at DATETIME;
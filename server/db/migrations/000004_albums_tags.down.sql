@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS image_tags;
+DROP TABLE IF EXISTS image_albums;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS albums;
@@ -0,0 +1,28 @@
+-- User-defined albums and tags, many-to-many with images
+CREATE TABLE IF NOT EXISTS albums (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    created_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_albums_name ON albums(name);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    created_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags(name);
+
+CREATE TABLE IF NOT EXISTS image_albums (
+    image_id INTEGER,
+    album_id INTEGER,
+    PRIMARY KEY (image_id, album_id)
+);
+CREATE INDEX IF NOT EXISTS idx_image_albums_album_id ON image_albums(album_id);
+
+CREATE TABLE IF NOT EXISTS image_tags (
+    image_id INTEGER,
+    tag_id INTEGER,
+    PRIMARY KEY (image_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_image_tags_tag_id ON image_tags(tag_id);
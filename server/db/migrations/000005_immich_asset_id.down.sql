@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_images_immich_asset_id;
+ALTER TABLE images DROP COLUMN immich_asset_id;
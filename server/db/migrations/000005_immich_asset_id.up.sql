@@ -0,0 +1,3 @@
+-- Immich asset reference (photos are fetched live from the Immich server)
+ALTER TABLE images ADD COLUMN immich_asset_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_images_immich_asset_id ON images(immich_asset_id);
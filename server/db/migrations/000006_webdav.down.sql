@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_images_webdav_path;
+ALTER TABLE images DROP COLUMN webdav_etag;
+ALTER TABLE images DROP COLUMN webdav_path;
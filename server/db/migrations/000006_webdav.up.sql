@@ -0,0 +1,4 @@
+-- WebDAV remote path and ETag of the downloaded copy
+ALTER TABLE images ADD COLUMN webdav_path TEXT;
+ALTER TABLE images ADD COLUMN webdav_etag TEXT;
+CREATE INDEX IF NOT EXISTS idx_images_webdav_path ON images(webdav_path);
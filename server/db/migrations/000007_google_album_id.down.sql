@@ -0,0 +1 @@
+ALTER TABLE images DROP COLUMN google_album_id;
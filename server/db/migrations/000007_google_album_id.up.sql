@@ -0,0 +1,2 @@
+-- Google Photos album a photo was imported from by album sync
+ALTER TABLE images ADD COLUMN google_album_id TEXT;
@@ -0,0 +1 @@
+ALTER TABLE images DROP COLUMN synology_album_id;
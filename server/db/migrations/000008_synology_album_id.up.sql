@@ -0,0 +1,2 @@
+-- Synology album a photo was synced from (needed to fetch photos of shared albums)
+ALTER TABLE images ADD COLUMN synology_album_id INTEGER DEFAULT 0;
@@ -0,0 +1,14 @@
+-- Local mirror of remote (Synology, Immich) photos. Blobs live under DATA_DIR/cache,
+-- named by their SHA-256, so several keys can share one file.
+CREATE TABLE IF NOT EXISTS cache_entries (
+    key TEXT PRIMARY KEY,
+    hash TEXT NOT NULL,
+    size INTEGER DEFAULT 0,
+    image_id INTEGER DEFAULT 0,
+    variant TEXT,
+    last_access DATETIME,
+    created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_cache_entries_hash ON cache_entries(hash);
+CREATE INDEX IF NOT EXISTS idx_cache_entries_image_id ON cache_entries(image_id);
+CREATE INDEX IF NOT EXISTS idx_cache_entries_last_access ON cache_entries(last_access);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_images_email_message_id;
+ALTER TABLE images DROP COLUMN email_sender;
+ALTER TABLE images DROP COLUMN email_message_id;
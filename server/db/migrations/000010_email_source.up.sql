@@ -0,0 +1,4 @@
+-- Email inbox source: Message-ID for deduplication and the sender address
+ALTER TABLE images ADD COLUMN email_message_id TEXT DEFAULT '';
+ALTER TABLE images ADD COLUMN email_sender TEXT DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_images_email_message_id ON images(email_message_id);
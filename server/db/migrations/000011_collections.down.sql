@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS collections;
+DROP INDEX IF EXISTS idx_images_captured_at;
+ALTER TABLE images DROP COLUMN captured_at;
@@ -0,0 +1,14 @@
+-- When the photo was taken (EXIF or the remote library), used by smart collections
+ALTER TABLE images ADD COLUMN captured_at DATETIME;
+CREATE INDEX IF NOT EXISTS idx_images_captured_at ON images(captured_at);
+
+-- Smart collections: saved rules matching images across all sources
+CREATE TABLE IF NOT EXISTS collections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    match TEXT DEFAULT 'all',
+    rules TEXT DEFAULT '[]',
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collections_name ON collections(name);
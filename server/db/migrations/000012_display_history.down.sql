@@ -0,0 +1,3 @@
+ALTER TABLE images DROP COLUMN last_shown_at;
+ALTER TABLE images DROP COLUMN shown_count;
+DROP TABLE IF EXISTS display_history;
@@ -0,0 +1,13 @@
+-- Which photo was shown on which device, and when
+CREATE TABLE IF NOT EXISTS display_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    image_id INTEGER,
+    device_id INTEGER DEFAULT 0,
+    shown_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_display_history_image_id ON display_history(image_id);
+CREATE INDEX IF NOT EXISTS idx_display_history_device_id ON display_history(device_id);
+
+-- Denormalized for sorting and the "never shown" filter
+ALTER TABLE images ADD COLUMN shown_count INTEGER DEFAULT 0;
+ALTER TABLE images ADD COLUMN last_shown_at DATETIME;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_images_hidden;
+ALTER TABLE images DROP COLUMN weight;
+ALTER TABLE images DROP COLUMN hidden;
+ALTER TABLE images DROP COLUMN favorite;
@@ -0,0 +1,6 @@
+-- Favorites are shown more often, hidden photos are never shown, and weight
+-- scales how often a photo is picked (1 = normal)
+ALTER TABLE images ADD COLUMN favorite BOOLEAN DEFAULT 0;
+ALTER TABLE images ADD COLUMN hidden BOOLEAN DEFAULT 0;
+ALTER TABLE images ADD COLUMN weight REAL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_images_hidden ON images(hidden);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_images_phash;
+ALTER TABLE images DROP COLUMN phash;
@@ -0,0 +1,4 @@
+-- Perceptual hash (dHash, 16 hex digits) for near-duplicate detection.
+-- Empty until computed, "-" if the photo could not be hashed.
+ALTER TABLE images ADD COLUMN phash TEXT DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_images_phash ON images(phash);
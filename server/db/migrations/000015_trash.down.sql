@@ -0,0 +1,2 @@
+ALTER TABLE images DROP COLUMN purged;
+ALTER TABLE images DROP COLUMN trash_path;
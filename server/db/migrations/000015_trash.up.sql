@@ -0,0 +1,8 @@
+-- Deleted photos are soft-deleted (deleted_at) and kept in the trash until
+-- purged. trash_path is where their file was moved, purged marks rows of
+-- synced sources that are kept after purging so the photo is not imported again.
+ALTER TABLE images ADD COLUMN trash_path TEXT DEFAULT '';
+ALTER TABLE images ADD COLUMN purged BOOLEAN DEFAULT 0;
+
+-- Photos deleted before the trash existed have no file left
+UPDATE images SET purged = 1 WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,24 @@
+-- Background jobs (Google Photos imports, Synology syncs, batches), kept
+-- across restarts. payload and result are JSON.
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    key TEXT DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'queued',
+    payload TEXT DEFAULT '',
+    result TEXT DEFAULT '',
+    error TEXT DEFAULT '',
+    attempts INTEGER DEFAULT 0,
+    max_attempts INTEGER DEFAULT 1,
+    run_at DATETIME,
+    progress_total INTEGER DEFAULT 0,
+    progress_processed INTEGER DEFAULT 0,
+    progress_failed INTEGER DEFAULT 0,
+    progress_message TEXT DEFAULT '',
+    started_at DATETIME,
+    finished_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_type_key ON jobs(type, key);
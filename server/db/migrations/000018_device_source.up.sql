@@ -0,0 +1,2 @@
+-- Photo source of the "next photo" action (empty for all sources)
+ALTER TABLE devices ADD COLUMN source TEXT DEFAULT '';
@@ -0,0 +1,26 @@
+-- Outgoing webhooks. events is a comma-separated filter, empty for all events.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT DEFAULT '',
+    url TEXT NOT NULL,
+    secret TEXT DEFAULT '',
+    events TEXT DEFAULT '',
+    enabled BOOLEAN DEFAULT 1,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+-- Delivery log, payload is the JSON body sent
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    payload TEXT DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER DEFAULT 0,
+    response_code INTEGER DEFAULT 0,
+    error TEXT DEFAULT '',
+    created_at DATETIME,
+    delivered_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, id);
@@ -0,0 +1,2 @@
+-- Nothing to undo: the cleared hashes are computed again in the background
+SELECT 1;
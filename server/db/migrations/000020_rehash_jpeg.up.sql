@@ -0,0 +1,5 @@
+-- Hash JPEGs again: hashes are now computed after applying EXIF orientation,
+-- so camera originals stored sideways match rotated copies.
+UPDATE images SET phash = ''
+WHERE source NOT IN ('synology', 'immich')
+  AND (lower(file_path) LIKE '%.jpg' OR lower(file_path) LIKE '%.jpeg');
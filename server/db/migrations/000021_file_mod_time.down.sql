@@ -0,0 +1 @@
+ALTER TABLE images DROP COLUMN file_mod_time;
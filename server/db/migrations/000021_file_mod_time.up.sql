@@ -0,0 +1,3 @@
+-- Modification time of the file when it was last read, for local folder sync.
+-- Left empty for existing photos, so the next scan reads them once more.
+ALTER TABLE images ADD COLUMN file_mod_time DATETIME;
@@ -20,10 +20,11 @@ func Init(dbPath string) (*gorm.DB, error) {
 	log.Println("Database connection established")
 
 	// Auto Migrate Schema
-	// Note: We still keep AutoMigrate for other models for now, but `devices` is handled by migration
+	// Note: We still keep AutoMigrate for other models for now, but `devices` and `images`
+	// are handled by migration. AutoMigrate would add new `images` columns before the
+	// migration that adds them runs, making the migration fail.
 	err = db.AutoMigrate(
 		&model.Setting{},
-		&model.Image{},
 		&model.GoogleAuth{},
 		&model.User{},
 		&model.APIKey{},
@@ -36,6 +37,12 @@ func Init(dbPath string) (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB, dbPath string) error {
+	return MigrateFrom(db, "db/migrations")
+}
+
+// MigrateFrom applies the migrations in dir, e.g. from tests running in
+// another working directory
+func MigrateFrom(db *gorm.DB, dir string) error {
 	sqlDB, err := db.DB()
 	if err != nil {
 		return err
@@ -47,7 +54,7 @@ func Migrate(db *gorm.DB, dbPath string) error {
 	}
 
 	m, err := migrate.NewWithDatabaseInstance(
-		"file://db/migrations",
+		"file://"+dir,
 		"sqlite3", driver)
 	if err != nil {
 		return err
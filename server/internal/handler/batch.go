@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type BatchHandler struct {
+	batch *service.BatchService
+}
+
+func NewBatchHandler(batch *service.BatchService) *BatchHandler {
+	return &BatchHandler{batch: batch}
+}
+
+// Batch applies an action to a list of photos and reports the result per photo.
+// Pushes and rotations (or any action with "async": true) run in the background:
+// the response is 202 with the job to poll at GET /api/jobs/:id.
+// POST /api/gallery/batch {"photo_ids": [1, 2], "action": "tag", "tags": ["family"]}
+func (h *BatchHandler) Batch(c echo.Context) error {
+	var req service.BatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if err := h.batch.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if h.batch.IsLong(&req) {
+		job, err := h.batch.Start(req)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusAccepted, job)
+	}
+	return c.JSON(http.StatusOK, h.batch.Run(req))
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type CacheHandler struct {
+	cache *service.CacheService
+}
+
+func NewCacheHandler(s *service.CacheService) *CacheHandler {
+	return &CacheHandler{cache: s}
+}
+
+// GetUsage returns the size of the local cache of remote photos
+func (h *CacheHandler) GetUsage(c echo.Context) error {
+	usage, err := h.cache.Usage()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, usage)
+}
+
+func (h *CacheHandler) Clear(c echo.Context) error {
+	if err := h.cache.Clear(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "cleared"})
+}
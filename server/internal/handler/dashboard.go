@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"strconv"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type DashboardHandler struct {
+	dashboard *service.DashboardService
+}
+
+func NewDashboardHandler(dashboard *service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{dashboard: dashboard}
+}
+
+// GET /api/dashboard/template
+func (h *DashboardHandler) GetTemplate(c echo.Context) error {
+	tpl, err := h.dashboard.GetTemplate()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tpl)
+}
+
+// PUT /api/dashboard/template
+func (h *DashboardHandler) UpdateTemplate(c echo.Context) error {
+	var tpl service.DashboardTemplate
+	if err := c.Bind(&tpl); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid template"})
+	}
+
+	if err := h.dashboard.SaveTemplate(&tpl); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tpl)
+}
+
+// GET /api/dashboard/preview?width=800&height=480&lat=..&lon=..
+// Renders the dashboard without e-paper processing for the web UI.
+func (h *DashboardHandler) Preview(c echo.Context) error {
+	width, height := 800, 480
+	if w, err := strconv.Atoi(c.QueryParam("width")); err == nil && w > 0 {
+		width = w
+	}
+	if he, err := strconv.Atoi(c.QueryParam("height")); err == nil && he > 0 {
+		height = he
+	}
+
+	opts := service.DashboardOptions{}
+	opts.WeatherLat, _ = strconv.ParseFloat(c.QueryParam("lat"), 64)
+	opts.WeatherLon, _ = strconv.ParseFloat(c.QueryParam("lon"), 64)
+
+	img, err := h.dashboard.Render(width, height, opts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to encode preview"})
+	}
+	return c.Blob(http.StatusOK, "image/png", buf.Bytes())
+}
@@ -15,16 +15,20 @@ import (
 )
 
 type DeviceHandler struct {
-	deviceService   *service.DeviceService
-	synologyService *service.SynologyService
-	db              *gorm.DB // Needed to find image by ID
+	deviceService *service.DeviceService
+	remote        *service.RemotePhotoService
+	placeholder   *service.PlaceholderService
+	history       *service.HistoryService
+	db            *gorm.DB // Needed to find image by ID
 }
 
-func NewDeviceHandler(deviceService *service.DeviceService, synologyService *service.SynologyService, db *gorm.DB) *DeviceHandler {
+func NewDeviceHandler(deviceService *service.DeviceService, remote *service.RemotePhotoService, placeholder *service.PlaceholderService, history *service.HistoryService, db *gorm.DB) *DeviceHandler {
 	return &DeviceHandler{
-		deviceService:   deviceService,
-		synologyService: synologyService,
-		db:              db,
+		deviceService: deviceService,
+		remote:        remote,
+		placeholder:   placeholder,
+		history:       history,
+		db:            db,
 	}
 }
 
@@ -96,6 +100,52 @@ func (h *DeviceHandler) DeleteDevice(c echo.Context) error {
 	if err := h.deviceService.DeleteDevice(uint(id)); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	h.placeholder.RemoveFallback(uint(id))
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// GET /api/devices/:id/fallback
+func (h *DeviceHandler) GetFallback(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	path := h.placeholder.FallbackFile(uint(id))
+	if path == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no fallback image"})
+	}
+	return c.File(path)
+}
+
+// PUT /api/devices/:id/fallback
+// Multipart form with a "file" field. The image is shown instead of the
+// placeholder card when no photo can be served to this device.
+func (h *DeviceHandler) UploadFallback(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var device model.Device
+	if err := h.db.First(&device, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "device not found"})
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "file required"})
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read upload"})
+	}
+	defer src.Close()
+
+	if err := h.placeholder.SaveFallback(device.ID, src); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "saved"})
+}
+
+// DELETE /api/devices/:id/fallback
+func (h *DeviceHandler) DeleteFallback(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.placeholder.RemoveFallback(uint(id)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -119,15 +169,15 @@ func (h *DeviceHandler) PushToDevice(c echo.Context) error {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "image not found"})
 		}
 
-		if img.Source == "synology" {
+		if service.IsRemoteSource(img.Source) {
 			// Download to temporary file
-			data, err := h.synologyService.DownloadPhoto(int(img.SynologyPhotoID))
+			data, err := h.remote.Fetch(img, service.VariantDisplay)
 			if err != nil {
-				return c.JSON(http.StatusInternalServerError, map[string]string{"error": fmt.Sprintf("failed to download synology photo: %v", err)})
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": fmt.Sprintf("failed to download %s photo: %v", img.Source, err)})
 			}
 
 			// Save to temp file
-			tmp, err := ioutil.TempFile("", "syno_push_*.jpg")
+			tmp, err := ioutil.TempFile("", img.Source+"_push_*.jpg")
 			if err != nil {
 				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create temp file"})
 			}
@@ -157,6 +207,9 @@ func (h *DeviceHandler) PushToDevice(c echo.Context) error {
 	if err := h.deviceService.PushToDevice(uint(deviceID), imagePath); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fmt.Sprintf("push failed: %v", err)})
 	}
+	if req.ImageID != 0 {
+		h.history.Record(uint(deviceID), req.ImageID)
+	}
 
 	return c.JSON(http.StatusOK, map[string]string{"status": "pushed"})
 }
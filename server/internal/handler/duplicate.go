@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type DuplicateHandler struct {
+	duplicates *service.DuplicateService
+}
+
+func NewDuplicateHandler(duplicates *service.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{duplicates: duplicates}
+}
+
+// ListDuplicates reports groups of near-duplicate photos, largest first.
+// Photos in a group are oldest first, so the first one is usually the one to keep.
+// GET /api/gallery/duplicates?threshold=6
+func (h *DuplicateHandler) ListDuplicates(c echo.Context) error {
+	threshold := h.duplicates.Threshold()
+	if v := c.QueryParam("threshold"); v != "" {
+		t, err := strconv.Atoi(v)
+		if err != nil || t < 0 || t > 32 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "threshold must be between 0 and 32"})
+		}
+		threshold = t
+	}
+
+	groups, err := h.duplicates.Report(threshold)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	unhashed, err := h.duplicates.Unhashed()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	type DuplicatePhoto struct {
+		ID           uint       `json:"id"`
+		ThumbnailURL string     `json:"thumbnail_url"`
+		CreatedAt    time.Time  `json:"created_at"`
+		CapturedAt   *time.Time `json:"captured_at"`
+		Caption      string     `json:"caption"`
+		Width        int        `json:"width"`
+		Height       int        `json:"height"`
+		Source       string     `json:"source"`
+		PHash        string     `json:"phash"`
+	}
+	type DuplicateGroup struct {
+		Distance int              `json:"distance"`
+		Photos   []DuplicatePhoto `json:"photos"`
+	}
+
+	host := c.Request().Host
+	response := make([]DuplicateGroup, 0, len(groups))
+	for _, g := range groups {
+		group := DuplicateGroup{Distance: g.Distance}
+		for _, item := range g.Photos {
+			group.Photos = append(group.Photos, DuplicatePhoto{
+				ID:           item.ID,
+				ThumbnailURL: fmt.Sprintf("http://%s/api/gallery/thumbnail/%d", host, item.ID),
+				CreatedAt:    item.CreatedAt,
+				CapturedAt:   item.CapturedAt,
+				Caption:      item.Caption,
+				Width:        item.Width,
+				Height:       item.Height,
+				Source:       item.Source,
+				PHash:        item.PHash,
+			})
+		}
+		response = append(response, group)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"groups":    response,
+		"threshold": threshold,
+		"unhashed":  unhashed, // Photos not hashed yet, missing from the report
+	})
+}
+
+// Scan hashes photos that have no hash yet in the background
+// POST /api/gallery/duplicates/scan
+func (h *DuplicateHandler) Scan(c echo.Context) error {
+	go h.duplicates.Backfill()
+	return c.JSON(http.StatusAccepted, map[string]string{"status": "scanning"})
+}
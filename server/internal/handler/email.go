@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type EmailHandler struct {
+	email *service.EmailService
+}
+
+func NewEmailHandler(s *service.EmailService) *EmailHandler {
+	return &EmailHandler{email: s}
+}
+
+func (h *EmailHandler) TestConnection(c echo.Context) error {
+	if err := h.email.TestConnection(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *EmailHandler) Poll(c echo.Context) error {
+	result, err := h.email.Poll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *EmailHandler) GetPhotoCount(c echo.Context) error {
+	count, err := h.email.GetPhotoCount()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"count": count})
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// Comment sent on idle streams, so proxies don't close them
+const eventsKeepAlive = 30 * time.Second
+
+type EventHandler struct {
+	events *service.EventBus
+}
+
+func NewEventHandler(events *service.EventBus) *EventHandler {
+	return &EventHandler{events: events}
+}
+
+// Stream sends server events as Server-Sent Events until the client
+// disconnects. EventSource can't set headers, so pass the token as ?token=.
+// GET /api/events?types=job.updated,push.failed
+func (h *EventHandler) Stream(c echo.Context) error {
+	types := map[string]bool{}
+	for _, t := range strings.Split(c.QueryParam("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types[t] = true
+		}
+	}
+
+	events, unsubscribe := h.events.Subscribe()
+	defer unsubscribe()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable buffering in nginx, e.g. the Home Assistant ingress
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	w.Flush()
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if len(types) > 0 && !types[event.Type] {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		w.Flush()
+	}
+}
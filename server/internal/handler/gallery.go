@@ -1,42 +1,267 @@
 package handler
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/draw"
 	"image/jpeg"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aitjcize/photoframe-server/server/internal/model"
 	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
 	"github.com/labstack/echo/v4"
 	xdraw "golang.org/x/image/draw"
 	"gorm.io/gorm"
 )
 
 type GalleryHandler struct {
-	db       *gorm.DB
-	synology *service.SynologyService
-	dataDir  string
+	db          *gorm.DB
+	remote      *service.RemotePhotoService
+	devices     *service.DeviceService
+	library     *service.LibraryService
+	collections *service.CollectionService
+	history     *service.HistoryService
+	duplicates  *service.DuplicateService
+	trash       *service.TrashService
+	events      *service.EventBus
+	dataDir     string
 }
 
-func NewGalleryHandler(db *gorm.DB, synology *service.SynologyService, dataDir string) *GalleryHandler {
+func NewGalleryHandler(db *gorm.DB, remote *service.RemotePhotoService, devices *service.DeviceService, library *service.LibraryService, collections *service.CollectionService, history *service.HistoryService, duplicates *service.DuplicateService, trash *service.TrashService, events *service.EventBus, dataDir string) *GalleryHandler {
 	return &GalleryHandler{
-		db:       db,
-		synology: synology,
-		dataDir:  dataDir,
+		db:          db,
+		remote:      remote,
+		devices:     devices,
+		library:     library,
+		collections: collections,
+		history:     history,
+		duplicates:  duplicates,
+		trash:       trash,
+		events:      events,
+		dataDir:     dataDir,
 	}
 }
 
-// ListPhotos returns a paginated list of photos, optionally filtered by source
+// photoSorts are the sort keys of ListPhotos. Photos without a capture date
+// sort by their import date.
+var photoSorts = map[string]string{
+	"created":  "images.created_at",
+	"captured": "COALESCE(images.captured_at, images.created_at)",
+	"shown":    "images.shown_count",
+}
+
+// photoCursor is the position after the last photo of a ListPhotos page: its
+// sort value and ID. The value is kept in the cursor, so it stays valid when
+// the photo is shown again or deleted.
+type photoCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// encodePhotoCursor returns the opaque cursor continuing after item
+func encodePhotoCursor(sortKey string, item model.Image) string {
+	var value interface{}
+	switch sortKey {
+	case "shown":
+		value = item.ShownCount
+	case "captured":
+		if item.CapturedAt != nil {
+			value = *item.CapturedAt
+		} else {
+			value = item.CreatedAt
+		}
+	default:
+		value = item.CreatedAt
+	}
+	raw, _ := json.Marshal(value)
+	data, _ := json.Marshal(photoCursor{Sort: sortKey, Value: raw, ID: item.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePhotoCursor returns the sort value and ID of a cursor from
+// encodePhotoCursor
+func decodePhotoCursor(sortKey, cursor string) (interface{}, uint, error) {
+	invalid := errors.New("invalid cursor")
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, invalid
+	}
+	var cur photoCursor
+	if err := json.Unmarshal(data, &cur); err != nil || cur.Sort != sortKey || cur.ID == 0 {
+		return nil, 0, invalid
+	}
+	if sortKey == "shown" {
+		var count int
+		if err := json.Unmarshal(cur.Value, &count); err != nil {
+			return nil, 0, invalid
+		}
+		return count, cur.ID, nil
+	}
+	var t time.Time
+	if err := json.Unmarshal(cur.Value, &t); err != nil {
+		return nil, 0, invalid
+	}
+	return t, cur.ID, nil
+}
+
+// parseDateParam parses YYYY-MM-DD (local time) or RFC 3339. For dates without
+// a time, endOfDay moves to the start of the next day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// filterPhotos applies the ListPhotos query parameters:
+//   - source, orientation
+//   - album (ID), tag (name), collection (ID)
+//   - q: caption contains the text (case-insensitive)
+//   - from, to: capture date range, inclusive (date=created for the import date)
+//   - never_shown=true, shown_on=<device ID>
+//   - favorite=true|false, hidden=true|false
+func (h *GalleryHandler) filterPhotos(c echo.Context, query *gorm.DB) (*gorm.DB, error) {
+	if source := c.QueryParam("source"); source != "" {
+		query = query.Where("images.source = ?", source)
+	}
+	if orientation := c.QueryParam("orientation"); orientation != "" {
+		if orientation != "landscape" && orientation != "portrait" {
+			return nil, fmt.Errorf("orientation must be landscape or portrait")
+		}
+		query = query.Where("images.orientation = ?", orientation)
+	}
+	if album := c.QueryParam("album"); album != "" {
+		query = query.Where("images.id IN (SELECT image_id FROM image_albums WHERE album_id = ?)", album)
+	}
+	if tag := c.QueryParam("tag"); tag != "" {
+		query = query.Where("images.id IN (SELECT image_tags.image_id FROM image_tags JOIN tags ON tags.id = image_tags.tag_id WHERE tags.name = ?)", strings.ToLower(tag))
+	}
+	if collectionStr := c.QueryParam("collection"); collectionStr != "" {
+		id, err := strconv.ParseUint(collectionStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid collection")
+		}
+		collection, err := h.collections.Get(uint(id))
+		if err != nil {
+			return nil, err
+		}
+		scope, err := h.collections.Scope(collection)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Scopes(scope)
+	}
+	if q := strings.TrimSpace(c.QueryParam("q")); q != "" {
+		query = query.Where("instr(lower(images.caption), lower(?)) > 0", q)
+	}
+
+	dateColumn := photoSorts["captured"]
+	switch c.QueryParam("date") {
+	case "", "captured":
+	case "created":
+		dateColumn = photoSorts["created"]
+	default:
+		return nil, fmt.Errorf("date must be captured or created")
+	}
+	if from := c.QueryParam("from"); from != "" {
+		t, err := parseDateParam(from, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date")
+		}
+		query = query.Where(dateColumn+" >= ?", t)
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := parseDateParam(to, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date")
+		}
+		query = query.Where(dateColumn+" < ?", t)
+	}
+
+	if c.QueryParam("never_shown") == "true" {
+		query = query.Where("images.shown_count = 0")
+	}
+	if device := c.QueryParam("shown_on"); device != "" {
+		query = query.Where("images.id IN (SELECT image_id FROM display_history WHERE device_id = ?)", device)
+	}
+	if favorite := c.QueryParam("favorite"); favorite != "" {
+		query = query.Where("images.favorite = ?", favorite == "true")
+	}
+	if hidden := c.QueryParam("hidden"); hidden != "" {
+		query = query.Where("images.hidden = ?", hidden == "true")
+	}
+	return query, nil
+}
+
+// maxWeight bounds the display weight of a photo
+const maxWeight = 100
+
+// UpdatePhotos sets the favorite, hidden and weight fields of photos in bulk.
+// Fields that are left out are not changed.
+// PATCH /api/gallery/photos {"photo_ids": [1, 2], "favorite": true, "hidden": false, "weight": 2}
+func (h *GalleryHandler) UpdatePhotos(c echo.Context) error {
+	var req struct {
+		PhotoIDs []uint   `json:"photo_ids"`
+		Favorite *bool    `json:"favorite"`
+		Hidden   *bool    `json:"hidden"`
+		Weight   *float64 `json:"weight"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if len(req.PhotoIDs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "photo_ids required"})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Favorite != nil {
+		updates["favorite"] = *req.Favorite
+	}
+	if req.Hidden != nil {
+		updates["hidden"] = *req.Hidden
+	}
+	if req.Weight != nil {
+		if *req.Weight < 0 || *req.Weight > maxWeight {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("weight must be between 0 and %d", maxWeight)})
+		}
+		updates["weight"] = *req.Weight
+	}
+	if len(updates) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "nothing to update (favorite, hidden or weight)"})
+	}
+
+	result := h.db.Model(&model.Image{}).Where("id IN ?", req.PhotoIDs).UpdateColumns(updates)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update photos"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":  "updated",
+		"updated": result.RowsAffected,
+	})
+}
+
+// ListPhotos returns a page of photos matching the filters of filterPhotos.
+// Sorted by sort=created (default), captured or shown, order=desc (default) or asc.
+// Pages are selected with limit and either offset, or the opaque cursor
+// returned as next_cursor, which stays stable while new photos are imported.
 func (h *GalleryHandler) ListPhotos(c echo.Context) error {
 	limit := 50
 	offset := 0
-	source := c.QueryParam("source")
 
 	if limitStr := c.QueryParam("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
@@ -50,9 +275,26 @@ func (h *GalleryHandler) ListPhotos(c echo.Context) error {
 		}
 	}
 
-	query := h.db.Model(&model.Image{})
-	if source != "" {
-		query = query.Where("source = ?", source)
+	sortKey := c.QueryParam("sort")
+	if sortKey == "" {
+		sortKey = "created"
+	}
+	sortColumn, ok := photoSorts[sortKey]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "sort must be created, captured or shown"})
+	}
+	direction, cmp := "DESC", "<"
+	switch c.QueryParam("order") {
+	case "", "desc":
+	case "asc":
+		direction, cmp = "ASC", ">"
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "order must be asc or desc"})
+	}
+
+	query, err := h.filterPhotos(c, h.db.Model(&model.Image{}))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
 	var total int64
@@ -60,20 +302,51 @@ func (h *GalleryHandler) ListPhotos(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to count photos"})
 	}
 
+	page := query.Session(&gorm.Session{}).Preload("Albums").Preload("Tags").
+		Order(sortColumn + " " + direction).Order("images.id " + direction)
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		// Keyset pagination: continue after the last photo of the previous
+		// page, comparing against its sort value when the page was listed
+		cursorValue, cursorID, err := decodePhotoCursor(sortKey, cursor)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		page = page.Where(
+			"("+sortColumn+" "+cmp+" ? OR ("+sortColumn+" = ? AND images.id "+cmp+" ?))",
+			cursorValue, cursorValue, cursorID,
+		)
+		offset = 0
+	}
+
 	var items []model.Image
-	if err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&items).Error; err != nil {
+	if err := page.Limit(limit + 1).Offset(offset).Find(&items).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list photos"})
 	}
 
+	nextCursor := ""
+	if len(items) > limit {
+		items = items[:limit]
+		nextCursor = encodePhotoCursor(sortKey, items[limit-1])
+	}
+
 	type PhotoResponse struct {
-		ID           uint      `json:"id"`
-		ThumbnailURL string    `json:"thumbnail_url"`
-		CreatedAt    time.Time `json:"created_at"`
-		Caption      string    `json:"caption"`
-		Width        int       `json:"width"`
-		Height       int       `json:"height"`
-		Orientation  string    `json:"orientation"`
-		Source       string    `json:"source"`
+		ID           uint          `json:"id"`
+		ThumbnailURL string        `json:"thumbnail_url"`
+		CreatedAt    time.Time     `json:"created_at"`
+		CapturedAt   *time.Time    `json:"captured_at"`
+		Caption      string        `json:"caption"`
+		Width        int           `json:"width"`
+		Height       int           `json:"height"`
+		Orientation  string        `json:"orientation"`
+		Source       string        `json:"source"`
+		ShownCount   int           `json:"shown_count"`
+		LastShownAt  *time.Time    `json:"last_shown_at"`
+		Favorite     bool          `json:"favorite"`
+		Hidden       bool          `json:"hidden"`
+		Weight       float64       `json:"weight"`
+		Albums       []model.Album `json:"albums"`
+		Tags         []model.Tag   `json:"tags"`
 	}
 
 	var photos []PhotoResponse
@@ -83,22 +356,50 @@ func (h *GalleryHandler) ListPhotos(c echo.Context) error {
 			ID:           item.ID,
 			ThumbnailURL: fmt.Sprintf("http://%s/api/gallery/thumbnail/%d", host, item.ID),
 			CreatedAt:    item.CreatedAt,
+			CapturedAt:   item.CapturedAt,
 			Caption:      item.Caption,
 			Width:        item.Width,
 			Height:       item.Height,
 			Orientation:  item.Orientation,
 			Source:       item.Source,
+			ShownCount:   item.ShownCount,
+			LastShownAt:  item.LastShownAt,
+			Favorite:     item.Favorite,
+			Hidden:       item.Hidden,
+			Weight:       item.Weight,
+			Albums:       item.Albums,
+			Tags:         item.Tags,
 		})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"photos": photos,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
+		"photos":      photos,
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+		"next_cursor": nextCursor,
 	})
 }
 
+// GetPhotoHistory lists when and where a photo was shown, newest first
+// GET /api/gallery/photos/:id/history?limit=50
+func (h *GalleryHandler) GetPhotoHistory(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	limit := 50
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	entries, err := h.history.ForImage(uint(id), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
 // GetThumbnail serves the thumbnail for a photo.
 // If it's a local/google photo, it serves/generates from disk.
 // If it's a Synology photo, it proxies from Synology API.
@@ -109,28 +410,26 @@ func (h *GalleryHandler) GetThumbnail(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
 	}
 
+	// Photos in the trash keep their thumbnail for the trash view
 	var item model.Image
-	if err := h.db.First(&item, id).Error; err != nil {
+	if err := h.db.Unscoped().Where("purged = ?", false).First(&item, id).Error; err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "photo not found"})
 	}
 
-	// Case 1: Synology (Proxy)
-	if item.Source == "synology" {
-		// Synology thumbnail is fetched via service
-		// We request 'small' (typically ~256px) or 'medium'
-		// Synology sizes: small, medium, large, original
-		thumbBytes, err := h.synology.GetPhoto(item.SynologyPhotoID, item.ThumbnailKey, "small")
+	// Case 1: Synology/Immich (Proxy, through the local cache)
+	if service.IsRemoteSource(item.Source) {
+		thumbBytes, err := h.remote.Fetch(item, service.VariantThumbnail)
 		if err != nil {
-			fmt.Printf("Failed to fetch synology thumbnail (ID=%d): %v\n", item.SynologyPhotoID, err)
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch synology thumbnail"})
+			fmt.Printf("Failed to fetch %s thumbnail (ID=%d): %v\n", item.Source, item.ID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch " + item.Source + " thumbnail"})
 		}
-		c.Response().Header().Set("Content-Type", "image/jpeg")
+		c.Response().Header().Set("Content-Type", http.DetectContentType(thumbBytes))
 		c.Response().Header().Set("Cache-Control", "public, max-age=86400") // Cache for 1 day
 		_, err = c.Response().Write(thumbBytes)
 		return err
 	}
 
-	// Case 2: Local File (Google/Local)
+	// Case 2: Local File (Google/Local/Upload)
 	thumbPath := filepath.Join(h.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID))
 
 	// Check cache
@@ -140,11 +439,15 @@ func (h *GalleryHandler) GetThumbnail(c echo.Context) error {
 	}
 
 	// Generate from high-res file if missing
-	if item.FilePath == "" {
+	srcPath := item.FilePath
+	if item.TrashPath != "" {
+		srcPath = item.TrashPath
+	}
+	if srcPath == "" {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "source file missing"})
 	}
 
-	if err := h.generateThumbnail(item.FilePath, thumbPath); err != nil {
+	if err := h.generateThumbnail(srcPath, thumbPath); err != nil {
 		fmt.Printf("Thumbnail generation failed for %d: %v\n", item.ID, err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate thumbnail"})
 	}
@@ -165,7 +468,7 @@ func (h *GalleryHandler) generateThumbnail(srcPath, destPath string) error {
 	}
 	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	img, _, err := imageops.Decode(f)
 	if err != nil {
 		return err
 	}
@@ -192,7 +495,7 @@ func (h *GalleryHandler) generateThumbnail(srcPath, destPath string) error {
 	return jpeg.Encode(out, dst, &jpeg.Options{Quality: 80})
 }
 
-// DeletePhoto deletes a single photo
+// DeletePhoto moves a single photo to the trash
 func (h *GalleryHandler) DeletePhoto(c echo.Context) error {
 	id := c.Param("id")
 	var item model.Image
@@ -200,25 +503,14 @@ func (h *GalleryHandler) DeletePhoto(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "photo not found"})
 	}
 
-	// If local/google, delete file
-	if item.Source == "google" || item.Source == "local" {
-		if item.FilePath != "" {
-			os.Remove(item.FilePath)
-		}
-		// Also delete thumbnail
-		thumbPath := filepath.Join(h.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID))
-		os.Remove(thumbPath)
+	if err := h.trash.Trash([]model.Image{item}); err != nil {
+		fmt.Printf("DeletePhoto failed: %v\n", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete photo"})
 	}
-	// For Synology, we just remove the DB reference, we don't delete from NAS.
-	// For all (including local/google where we already deleted file), perform Unscoped delete from DB
-	if err := h.db.Unscoped().Delete(&item).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete from db"})
-	}
-
 	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
 
-// DeletePhotos deletes all photos matching a source filter (or all if no filter)
+// DeletePhotos moves all photos matching a source filter (or all if no filter) to the trash
 // e.g. DELETE /api/gallery/photos?source=google
 func (h *GalleryHandler) DeletePhotos(c echo.Context) error {
 	source := c.QueryParam("source")
@@ -233,30 +525,168 @@ func (h *GalleryHandler) DeletePhotos(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to find photos"})
 	}
 
-	for _, item := range items {
-		if item.Source == "google" || item.Source == "local" {
-			if item.FilePath != "" {
-				os.Remove(item.FilePath)
-			}
-			thumbPath := filepath.Join(h.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID))
-			os.Remove(thumbPath)
-		}
-	}
-
-	// Delete from DB in a fresh transaction/query to avoid side effects
-	delQuery := h.db
-	if source != "" {
-		delQuery = delQuery.Where("source = ?", source)
-	}
-	// Use Unscoped to ensure permanent delete
-	if err := delQuery.Unscoped().Delete(&model.Image{}).Error; err != nil {
+	if err := h.trash.Trash(items); err != nil {
 		fmt.Printf("DeletePhotos failed: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete from db"})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete photos"})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"status":  "deleted",
 		"count":   len(items),
-		"message": fmt.Sprintf("Deleted %d photos", len(items)),
+		"message": fmt.Sprintf("Moved %d photos to the trash", len(items)),
 	})
 }
+
+type uploadResult struct {
+	Filename string `json:"filename"`
+	ID       uint   `json:"id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Upload stores one or more photos sent as multipart form files.
+// POST /api/gallery/upload
+// Form fields: files (repeated), caption, album, tags (comma separated), device_id
+func (h *GalleryHandler) Upload(c echo.Context) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid multipart form"})
+	}
+
+	files := append(form.File["files"], form.File["file"]...)
+	if len(files) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no files provided"})
+	}
+
+	var deviceID uint
+	if v := c.FormValue("device_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid device_id"})
+		}
+		deviceID = uint(id)
+	}
+
+	caption := c.FormValue("caption")
+	album := strings.TrimSpace(c.FormValue("album"))
+	tags := service.ParseTags(c.FormValue("tags"))
+
+	photosDir := filepath.Join(h.dataDir, "photos")
+	if err := os.MkdirAll(photosDir, 0755); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create photos directory"})
+	}
+
+	results := make([]uploadResult, 0, len(files))
+	var uploaded []model.Image
+	for _, fh := range files {
+		item, err := h.storeUpload(fh, photosDir, caption)
+		if err != nil {
+			results = append(results, uploadResult{Filename: fh.Filename, Error: err.Error()})
+			continue
+		}
+		uploaded = append(uploaded, *item)
+		results = append(results, uploadResult{Filename: fh.Filename, ID: item.ID})
+	}
+
+	ids := make([]uint, 0, len(uploaded))
+	for _, item := range uploaded {
+		ids = append(ids, item.ID)
+	}
+	resp := map[string]interface{}{
+		"uploaded": len(uploaded),
+		"failed":   len(files) - len(uploaded),
+		"results":  results,
+	}
+
+	// The photos are stored either way, so these are reported like push errors
+	if album != "" && len(ids) > 0 {
+		if err := h.library.AddToAlbum(ids, album); err != nil {
+			resp["album_error"] = err.Error()
+		}
+	}
+	if len(tags) > 0 && len(ids) > 0 {
+		if err := h.library.AddTags(ids, tags); err != nil {
+			resp["tags_error"] = err.Error()
+		}
+	}
+
+	// Show the last uploaded photo right away if a device was given
+	if deviceID != 0 && len(uploaded) > 0 {
+		last := uploaded[len(uploaded)-1]
+		if err := h.devices.PushToDevice(deviceID, last.FilePath); err != nil {
+			resp["push_error"] = err.Error()
+		} else {
+			resp["pushed"] = last.ID
+			h.history.Record(deviceID, last.ID)
+		}
+	}
+
+	status := http.StatusOK
+	if len(uploaded) == 0 {
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, resp)
+}
+
+// storeUpload saves a single uploaded file and creates its image row
+func (h *GalleryHandler) storeUpload(fh *multipart.FileHeader, photosDir, caption string) (*model.Image, error) {
+	if !imageops.IsSupportedFile(fh.Filename) {
+		return nil, fmt.Errorf("unsupported file type")
+	}
+
+	src, err := fh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload")
+	}
+	defer src.Close()
+
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	dstPath := filepath.Join(photosDir, fmt.Sprintf("upload_%d%s", time.Now().UnixNano(), ext))
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save file")
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("failed to save file")
+	}
+	dst.Close()
+
+	width, height, orientation, err := imageops.ReadInfo(dstPath)
+	if err != nil {
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("not a valid image")
+	}
+
+	hash, duplicate := h.duplicates.Check(dstPath)
+	if duplicate != nil {
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("duplicate of photo #%d", duplicate.ID)
+	}
+
+	item := model.Image{
+		FilePath:    dstPath,
+		Caption:     caption,
+		Source:      "upload",
+		UserID:      1,
+		Status:      "pending",
+		CreatedAt:   time.Now(),
+		CapturedAt:  imageops.ReadCaptureTime(dstPath),
+		Width:       width,
+		Height:      height,
+		Orientation: orientation,
+		PHash:       hash,
+	}
+	if err := h.db.Create(&item).Error; err != nil {
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("failed to save to db")
+	}
+
+	thumbPath := filepath.Join(h.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID))
+	if err := h.generateThumbnail(dstPath, thumbPath); err != nil {
+		// Not fatal, GetThumbnail retries on demand
+		fmt.Printf("Thumbnail generation failed for %d: %v\n", item.ID, err)
+	}
+	h.events.PhotoImported(&item)
+	return &item, nil
+}
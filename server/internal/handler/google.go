@@ -15,6 +15,7 @@ import (
 	"github.com/aitjcize/photoframe-server/server/internal/model"
 	"github.com/aitjcize/photoframe-server/server/internal/service"
 	"github.com/aitjcize/photoframe-server/server/pkg/googlephotos"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
 	"github.com/labstack/echo/v4"
 	xdraw "golang.org/x/image/draw"
 	"gorm.io/gorm"
@@ -23,14 +24,16 @@ import (
 type GoogleHandler struct {
 	client  *googlephotos.Client
 	picker  *service.PickerService
+	albums  *service.GoogleAlbumService
 	db      *gorm.DB
 	dataDir string
 }
 
-func NewGoogleHandler(client *googlephotos.Client, picker *service.PickerService, db *gorm.DB, dataDir string) *GoogleHandler {
+func NewGoogleHandler(client *googlephotos.Client, picker *service.PickerService, albums *service.GoogleAlbumService, db *gorm.DB, dataDir string) *GoogleHandler {
 	return &GoogleHandler{
 		client:  client,
 		picker:  picker,
+		albums:  albums,
 		db:      db,
 		dataDir: dataDir,
 	}
@@ -109,16 +112,13 @@ func (h *GoogleHandler) PollPickerSession(c echo.Context) error {
 func (h *GoogleHandler) ProcessPickerSession(c echo.Context) error {
 	id := c.Param("id")
 
-	// Check if already processing? For now blindly start.
-	go func() {
-		_, err := h.picker.ProcessSessionItems(id)
-		if err != nil {
-			// Error is recorded in progress state
-			// fmt.Printf("ProcessSessionItems background error: %v\n", err)
-		}
-	}()
+	// Runs as a background job; a session already being imported is not queued twice
+	job, err := h.picker.StartImport(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 
-	return c.JSON(http.StatusAccepted, map[string]string{"status": "processing"})
+	return c.JSON(http.StatusAccepted, map[string]interface{}{"status": "processing", "job_id": job.ID})
 }
 
 func (h *GoogleHandler) PollPickerProgress(c echo.Context) error {
@@ -130,6 +130,27 @@ func (h *GoogleHandler) PollPickerProgress(c echo.Context) error {
 	return c.JSON(http.StatusOK, progress)
 }
 
+// GET /api/google/albums
+func (h *GoogleHandler) ListAlbums(c echo.Context) error {
+	albums, err := h.albums.ListAlbums()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"albums":   albums,
+		"selected": h.albums.AlbumIDs(),
+	})
+}
+
+// POST /api/google/albums/sync
+func (h *GoogleHandler) SyncAlbums(c echo.Context) error {
+	result, err := h.albums.Sync()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
 func (h *GoogleHandler) DeleteAllGooglePhotos(c echo.Context) error {
 	var items []model.Image
 	// Only fetch Google Photos
@@ -201,7 +222,7 @@ func (h *GoogleHandler) GetGooglePhotoThumbnail(c echo.Context) error {
 	}
 	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	img, _, err := imageops.Decode(f)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to decode image: " + err.Error()})
 	}
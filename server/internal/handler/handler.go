@@ -2,6 +2,8 @@ package handler
 
 import (
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/aitjcize/photoframe-server/server/internal/service"
 	"github.com/aitjcize/photoframe-server/server/pkg/googlephotos"
@@ -11,11 +13,18 @@ import (
 type Handler struct {
 	settings *service.SettingsService
 	telegram *service.TelegramService
+	local    *service.LocalSourceService
+	webdav   *service.WebDAVService
+	email    *service.EmailService
+	albums   *service.GoogleAlbumService
+	synology *service.SynologyService
+	mqtt     *service.MQTTService
 	google   *googlephotos.Client
+	events   *service.EventBus
 }
 
-func NewHandler(s *service.SettingsService, t *service.TelegramService, g *googlephotos.Client) *Handler {
-	return &Handler{settings: s, telegram: t, google: g}
+func NewHandler(s *service.SettingsService, t *service.TelegramService, l *service.LocalSourceService, w *service.WebDAVService, em *service.EmailService, a *service.GoogleAlbumService, syn *service.SynologyService, mq *service.MQTTService, g *googlephotos.Client, ev *service.EventBus) *Handler {
+	return &Handler{settings: s, telegram: t, local: l, webdav: w, email: em, albums: a, synology: syn, mqtt: mq, google: g, events: ev}
 }
 
 func (h *Handler) HealthCheck(c echo.Context) error {
@@ -47,16 +56,71 @@ func (h *Handler) UpdateSettings(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
 	}
 
+	restartTelegram := false
+	restartLocal := false
+	restartWebDAV := false
+	restartEmail := false
+	restartAlbums := false
+	restartSynology := false
+	restartMQTT := false
+	keys := make([]string, 0, len(req.Settings))
 	for k, v := range req.Settings {
+		keys = append(keys, k)
 		if err := h.settings.Set(k, v); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 
-		// Dynamic Telegram Restart
-		if k == "telegram_bot_token" {
-			go h.telegram.Restart(v)
+		if k == "telegram_bot_token" || k == "telegram_webhook_url" {
+			restartTelegram = true
 		}
+		if k == "local_dirs" || k == "local_rescan_interval" {
+			restartLocal = true
+		}
+		if k == "webdav_url" || k == "webdav_sync_interval" {
+			restartWebDAV = true
+		}
+		if k == "email_imap_host" || k == "email_poll_interval" {
+			restartEmail = true
+		}
+		if k == "google_album_ids" || k == "google_album_sync_interval" {
+			restartAlbums = true
+		}
+		if k == "synology_url" || k == "synology_sync_interval" {
+			restartSynology = true
+		}
+		if strings.HasPrefix(k, "mqtt_") {
+			restartMQTT = true
+		}
+	}
+
+	// Dynamic Telegram Restart, switches between long polling and webhook
+	if restartTelegram {
+		token, _ := h.settings.Get("telegram_bot_token")
+		go h.telegram.Restart(token)
 	}
+	// Dynamic Local Folder Restart (once, after all keys are saved)
+	if restartLocal {
+		go h.local.Restart()
+	}
+	if restartWebDAV {
+		go h.webdav.Restart()
+	}
+	if restartEmail {
+		go h.email.Restart()
+	}
+	if restartAlbums {
+		go h.albums.Restart()
+	}
+	if restartSynology {
+		go h.synology.Restart()
+	}
+	if restartMQTT {
+		go h.mqtt.Restart()
+	}
+
+	// Only the keys: values may be secrets
+	sort.Strings(keys)
+	h.events.Publish(service.EventSettingsChanged, map[string]interface{}{"keys": keys})
 
 	return c.JSON(http.StatusOK, map[string]string{"status": "updated"})
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"log"
@@ -25,33 +26,90 @@ import (
 	"gorm.io/gorm"
 )
 
+// imageSources maps the /image/:source route parameter to the `source` column of images
+var imageSources = map[string]string{
+	"google_photos": "google",
+	"synology":      "synology",
+	"telegram":      "telegram",
+	"local":         "local",
+	"upload":        "upload",
+	"immich":        "immich",
+	"webdav":        "webdav",
+	"email":         "email",
+}
+
+// photoSelection is the set of photos a frame shows: a single source, or an
+// album or smart collection spanning all sources
+type photoSelection struct {
+	source string // DB source, empty for albums and collections
+	label  string // Shown on the placeholder when the selection is empty
+	scope  func(*gorm.DB) *gorm.DB
+}
+
+func sourceSelection(source string) *photoSelection {
+	dbSource := imageSources[source]
+	return &photoSelection{
+		source: dbSource,
+		label:  sourceLabels[dbSource],
+		scope: func(db *gorm.DB) *gorm.DB {
+			return db.Where("source = ?", dbSource)
+		},
+	}
+}
+
+// emptyError explains that the selection has no photos
+func (sel *photoSelection) emptyError() error {
+	if sel.source != "" {
+		return noPhotosError(sel.source)
+	}
+	return &noPhotoError{
+		Title:  fmt.Sprintf("No photos in %s", sel.label),
+		Detail: "Add photos to it in the PhotoFrame gallery to show them here.",
+	}
+}
+
 type ImageHandler struct {
-	settings  *service.SettingsService
-	overlay   *service.OverlayService
-	processor *service.ProcessorService
-	google    *googlephotos.Client
-	synology  *service.SynologyService
-	db        *gorm.DB
-	dataDir   string
+	settings    *service.SettingsService
+	overlay     *service.OverlayService
+	dashboard   *service.DashboardService
+	processor   *service.ProcessorService
+	google      *googlephotos.Client
+	remote      *service.RemotePhotoService
+	placeholder *service.PlaceholderService
+	collections *service.CollectionService
+	history     *service.HistoryService
+	devices     *service.DeviceService
+	db          *gorm.DB
+	dataDir     string
 }
 
 func NewImageHandler(
 	s *service.SettingsService,
 	o *service.OverlayService,
+	d *service.DashboardService,
 	p *service.ProcessorService,
 	g *googlephotos.Client,
-	synology *service.SynologyService,
+	remote *service.RemotePhotoService,
+	placeholder *service.PlaceholderService,
+	collections *service.CollectionService,
+	history *service.HistoryService,
+	devices *service.DeviceService,
 	db *gorm.DB,
 	dataDir string,
 ) *ImageHandler {
 	return &ImageHandler{
-		settings:  s,
-		overlay:   o,
-		processor: p,
-		google:    g,
-		synology:  synology,
-		db:        db,
-		dataDir:   dataDir,
+		settings:    s,
+		overlay:     o,
+		dashboard:   d,
+		processor:   p,
+		google:      g,
+		remote:      remote,
+		placeholder: placeholder,
+		collections: collections,
+		history:     history,
+		devices:     devices,
+		db:          db,
+		dataDir:     dataDir,
 	}
 }
 
@@ -60,28 +118,73 @@ func (h *ImageHandler) ServeImage(c echo.Context) error {
 	source := c.Param("source")
 
 	// Validate source is one of the allowed values
-	if source != "google_photos" && source != "synology" && source != "telegram" {
+	if source == "dashboard" {
+		return h.serveImage(c, source, nil)
+	}
+	if _, ok := imageSources[source]; !ok {
 		return c.NoContent(http.StatusNotFound)
 	}
+	return h.serveImage(c, source, sourceSelection(source))
+}
 
-	// 1. Identify Device and Determine Settings
-	// Try to find device by Hostname (X-Hostname header) first, then IP
-	var device model.Device
-	var result *gorm.DB
+// ServeAlbumImage serves a random photo of an album, whatever its source
+// GET /image/album/:id
+func (h *ImageHandler) ServeAlbumImage(c echo.Context) error {
+	var album model.Album
+	if err := h.db.First(&album, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "album not found"})
+	}
+	return h.serveImage(c, "album", &photoSelection{
+		label: fmt.Sprintf("album %q", album.Name),
+		scope: func(db *gorm.DB) *gorm.DB {
+			return db.Where("images.id IN (SELECT image_id FROM image_albums WHERE album_id = ?)", album.ID)
+		},
+	})
+}
 
-	hostname := c.Request().Header.Get("X-Hostname")
-	if hostname != "" {
-		// Try matching Host or Name? Host in DB is often hostname.
-		result = h.db.Where("host = ?", hostname).First(&device)
+// ServeCollectionImage serves a random photo matching a smart collection
+// GET /image/collection/:id
+func (h *ImageHandler) ServeCollectionImage(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "collection not found"})
 	}
+	collection, err := h.collections.Get(uint(id))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	scope, err := h.collections.Scope(collection)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return h.serveImage(c, "collection", &photoSelection{
+		label: fmt.Sprintf("collection %q", collection.Name),
+		scope: scope,
+	})
+}
 
-	// If not found by hostname, try by IP
-	deviceFound := result != nil && result.Error == nil
-	if !deviceFound {
-		clientIP := c.RealIP()
-		result = h.db.Where("host = ?", clientIP).First(&device)
-		deviceFound = result.Error == nil
+// requestDevice finds the device making a request, by hostname (X-Hostname
+// header) first, then by IP
+func (h *ImageHandler) requestDevice(c echo.Context) (model.Device, bool) {
+	var device model.Device
+	if hostname := c.Request().Header.Get("X-Hostname"); hostname != "" {
+		// Host in DB is often hostname
+		if h.db.Where("host = ?", hostname).First(&device).Error == nil {
+			return device, true
+		}
+	}
+	device = model.Device{}
+	if h.db.Where("host = ?", c.RealIP()).First(&device).Error == nil {
+		return device, true
 	}
+	return model.Device{}, false
+}
+
+// serveImage renders a photo of the selection (nil for the dashboard) for the
+// requesting device
+func (h *ImageHandler) serveImage(c echo.Context, source string, sel *photoSelection) error {
+	// 1. Identify Device and Determine Settings
+	device, deviceFound := h.requestDevice(c)
 
 	// Native resolution of the device panel
 	nativeW, nativeH := 800, 480
@@ -94,6 +197,8 @@ func (h *ImageHandler) ServeImage(c echo.Context) error {
 	var lat, lon float64
 
 	if deviceFound {
+		h.devices.Seen(&device)
+
 		nativeW = device.Width
 		nativeH = device.Height
 		logicalW, logicalH = nativeW, nativeH
@@ -148,63 +253,69 @@ func (h *ImageHandler) ServeImage(c echo.Context) error {
 
 	var img image.Image
 	var err error
-
-	if source == "telegram" {
+	var shown []uint // Photos on the frame, for the display history
+
+	if source == "dashboard" {
+		img, err = h.dashboard.Render(logicalW, logicalH, service.DashboardOptions{
+			WeatherLat: lat,
+			WeatherLon: lon,
+		})
+	} else if source == "telegram" {
 		if enableCollage {
 			// Smart Collage for Telegram (requires DB entries)
-			img, _, err = h.fetchSmartCollage(logicalW, logicalH, source)
-			if err != nil {
-				// Fallback to single telegram photo if no DB entries
-				imgPath := filepath.Join(h.dataDir, "photos", "telegram_last.jpg")
-				f, fsErr := os.Open(imgPath)
-				if fsErr != nil {
-					img, err = h.fetchPlaceholder()
-				} else {
-					defer f.Close()
-					img, _, err = image.Decode(f)
-				}
-			}
+			img, shown, err = h.fetchSmartCollage(logicalW, logicalH, sel)
 		} else {
 			// Single photo from DB (newest telegram photo)
-			img, _, err = h.fetchRandomPhoto(source)
-			if err != nil {
-				// Fallback to telegram_last.jpg
-				imgPath := filepath.Join(h.dataDir, "photos", "telegram_last.jpg")
-				f, fsErr := os.Open(imgPath)
-				if fsErr != nil {
-					img, err = h.fetchPlaceholder()
-				} else {
-					defer f.Close()
-					img, _, err = image.Decode(f)
-				}
+			img, shown, err = h.fetchRandomPhotos(sel)
+		}
+		if err != nil {
+			// Fallback to the last photo received by the bot
+			if last, lastErr := h.loadTelegramLast(); lastErr == nil {
+				img, err = last, nil
 			}
 		}
 	} else if enableCollage {
-		img, _, err = h.fetchSmartCollage(logicalW, logicalH, source)
+		img, shown, err = h.fetchSmartCollage(logicalW, logicalH, sel)
+		if err != nil {
+			// e.g. no photo of the preferred orientation, try any photo
+			img, shown, err = h.fetchRandomPhotos(sel)
+		}
 	} else {
-		img, _, err = h.fetchRandomPhoto(source)
+		img, shown, err = h.fetchRandomPhotos(sel)
+	}
+
+	if err != nil && source != "dashboard" {
+		// Never leave the frame without an image: explain the problem on the panel
+		log.Printf("No photo for %s, serving placeholder: %v", source, err)
+		img = h.placeholderImage(device, deviceFound, logicalW, logicalH, err)
+		err = nil
 	}
 
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch photo: " + err.Error()})
 	}
 
-	// 1.5. Resize/Crop to Target Dimensions
-	dst := image.NewRGBA(image.Rect(0, 0, logicalW, logicalH))
-	imageops.DrawCover(dst, dst.Bounds(), img)
-	img = dst
+	// Dashboards are already rendered at the logical resolution and carry
+	// their own date/weather widgets, so cropping and the overlay are skipped.
+	imgWithOverlay := img
+	if source != "dashboard" {
+		// 1.5. Resize/Crop to Target Dimensions
+		dst := image.NewRGBA(image.Rect(0, 0, logicalW, logicalH))
+		imageops.DrawCover(dst, dst.Bounds(), img)
+		img = dst
 
-	// 2. Overlay
-	overlayOpts := service.OverlayOptions{
-		ShowDate:    showDate,
-		ShowWeather: showWeather,
-		WeatherLat:  lat,
-		WeatherLon:  lon,
-	}
+		// 2. Overlay
+		overlayOpts := service.OverlayOptions{
+			ShowDate:    showDate,
+			ShowWeather: showWeather,
+			WeatherLat:  lat,
+			WeatherLon:  lon,
+		}
 
-	imgWithOverlay, err := h.overlay.ApplyOverlay(img, overlayOpts)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "overlay failed: " + err.Error()})
+		imgWithOverlay, err = h.overlay.ApplyOverlay(img, overlayOpts)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "overlay failed: " + err.Error()})
+		}
 	}
 
 	// 3. Tone Mapping + Thumbnail (CLI)
@@ -259,6 +370,12 @@ func (h *ImageHandler) ServeImage(c echo.Context) error {
 		}
 	}
 
+	if len(shown) > 0 {
+		if err := h.history.Record(device.ID, shown...); err != nil {
+			log.Printf("Failed to record display history: %v", err)
+		}
+	}
+
 	// Set Content-Length header
 	c.Response().Header().Set("Content-Length", fmt.Sprintf("%d", len(processedBytes)))
 
@@ -302,15 +419,15 @@ func (h *ImageHandler) getOrientation() string {
 	return val
 }
 
-// Fetch smart photo (Single or Collage)
-func (h *ImageHandler) fetchSmartCollage(screenW, screenH int, sourceFilter string) (image.Image, uint, error) {
+// Fetch smart photo (Single or Collage), returning the IDs of the photos used
+func (h *ImageHandler) fetchSmartCollage(screenW, screenH int, sel *photoSelection) (image.Image, []uint, error) {
 	// Decide if Device is Landscape or Portrait
 	devicePortrait := screenH > screenW
 
 	// Fetch first image
-	img1, id1, err := h.fetchRandomPhotoWithType("portrait", sourceFilter)
+	img1, id1, err := h.fetchRandomPhotoWithType("portrait", sel)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	bounds := img1.Bounds()
@@ -319,7 +436,7 @@ func (h *ImageHandler) fetchSmartCollage(screenW, screenH int, sourceFilter stri
 
 	// Case 1: Match
 	if isPhotoPortrait == devicePortrait {
-		return img1, id1, nil
+		return img1, []uint{id1}, nil
 	}
 
 	// Case 2: Mismatch
@@ -327,66 +444,58 @@ func (h *ImageHandler) fetchSmartCollage(screenW, screenH int, sourceFilter stri
 	if devicePortrait && !isPhotoPortrait {
 		// Try fetch second landscape
 		// 1. Try DB first
-		img2, id2, err := h.fetchRandomPhotoWithType("landscape", sourceFilter)
+		img2, id2, err := h.fetchRandomPhotoWithType("landscape", sel)
 		if err == nil && id2 != id1 {
-			return createVerticalCollage(img1, img2, screenW, screenH), 0, nil
+			return createVerticalCollage(img1, img2, screenW, screenH), []uint{id1, id2}, nil
 		}
 		// 2. Fallback: Try random loop
 		for i := 0; i < 5; i++ {
-			cand, candID, err := h.fetchRandomPhoto(sourceFilter)
+			cand, candID, err := h.fetchRandomPhoto(sel)
 			if err == nil && candID != id1 {
 				b := cand.Bounds()
 				if b.Dx() > b.Dy() { // Is Landscape
 					// fmt.Printf("SmartCollage: Found match via random!\n")
-					return createVerticalCollage(img1, cand, screenW, screenH), 0, nil
+					return createVerticalCollage(img1, cand, screenW, screenH), []uint{id1, candID}, nil
 				}
 			}
 		}
 		// Fallback: Use same photo twice
-		return createVerticalCollage(img1, img1, screenW, screenH), 0, nil
+		return createVerticalCollage(img1, img1, screenW, screenH), []uint{id1}, nil
 	}
 
 	// Device Landscape, Photo Portrait -> Horizontal Side-by-Side
 	if !devicePortrait && isPhotoPortrait {
 		// Try fetch second portrait
-		img2, id2, err := h.fetchRandomPhotoWithType("portrait", sourceFilter)
+		img2, id2, err := h.fetchRandomPhotoWithType("portrait", sel)
 		if err == nil && id2 != id1 {
-			return createHorizontalCollage(img1, img2, screenW, screenH), 0, nil
+			return createHorizontalCollage(img1, img2, screenW, screenH), []uint{id1, id2}, nil
 		}
 		// 2. Fallback
 		for i := 0; i < 5; i++ {
-			cand, candID, err := h.fetchRandomPhoto(sourceFilter)
+			cand, candID, err := h.fetchRandomPhoto(sel)
 			if err == nil && candID != id1 {
 				b := cand.Bounds()
 				if b.Dy() > b.Dx() { // Is Portrait
 					// fmt.Printf("SmartCollage: Found match via random!\n")
-					return createHorizontalCollage(img1, cand, screenW, screenH), 0, nil
+					return createHorizontalCollage(img1, cand, screenW, screenH), []uint{id1, candID}, nil
 				}
 			}
 		}
 		// Fallback: Use same photo twice
-		return createHorizontalCollage(img1, img1, screenW, screenH), 0, nil
+		return createHorizontalCollage(img1, img1, screenW, screenH), []uint{id1}, nil
 	}
 
-	return img1, id1, nil
+	return img1, []uint{id1}, nil
 }
 
-func (h *ImageHandler) fetchRandomPhotoWithType(targetType string, sourceFilter string) (image.Image, uint, error) {
-	var item model.Image
-	query := h.db.Order("RANDOM()").Where("orientation = ?", targetType)
-
-	if sourceFilter == "google_photos" {
-		query = query.Where("source = ?", "google")
-	} else if sourceFilter == "synology" {
-		query = query.Where("source = ?", "synology")
-	} else if sourceFilter == "telegram" {
-		query = query.Where("source = ?", "telegram")
-	} else {
-		return nil, 0, fmt.Errorf("invalid source filter: %s", sourceFilter)
+func (h *ImageHandler) fetchRandomPhotoWithType(targetType string, sel *photoSelection) (image.Image, uint, error) {
+	item, err := service.PickWeightedImage(h.db.Where("orientation = ?", targetType).Scopes(sel.scope), h.settings.FavoriteWeight())
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := query.First(&item).Error; err != nil {
-		return nil, 0, err
+	if service.IsRemoteSource(item.Source) {
+		return h.fetchRemotePhoto(*item)
 	}
 
 	resolvedPath := h.resolvePath(item.FilePath)
@@ -396,7 +505,7 @@ func (h *ImageHandler) fetchRandomPhotoWithType(targetType string, sourceFilter
 	}
 	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	img, _, err := imageops.Decode(f)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -437,15 +546,14 @@ func createHorizontalCollage(img1, img2 image.Image, width, height int) image.Im
 	return dst
 }
 
-// fetchSynologyPhoto retrieves the photo from Synology Service
-func (h *ImageHandler) fetchSynologyPhoto(item model.Image) (image.Image, uint, error) {
-	// Try fetching cache first? Or direct from Service which handles fetching
-	data, err := h.synology.GetPhoto(item.SynologyPhotoID, item.ThumbnailKey, "large")
+// fetchRemotePhoto retrieves a Synology or Immich photo, through the local cache
+func (h *ImageHandler) fetchRemotePhoto(item model.Image) (image.Image, uint, error) {
+	data, err := h.remote.Fetch(item, service.VariantDisplay)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(data))
+	img, _, err := imageops.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -481,42 +589,29 @@ func (h *ImageHandler) resolvePath(path string) string {
 	return path
 }
 
-func (h *ImageHandler) fetchRandomPhoto(sourceFilter string) (image.Image, uint, error) {
-	// Source logic: if "google_photos" (default), we include source="google" OR source="" (legacy)
-	// If "synology", source="synology"
-	// If "telegram", source="telegram"
-
-	// Note: settings uses "google_photos" but DB uses "google"? Or "local"?
-	// Legacy: empty source is usually local or google.
-	// We need to check data model.
-
-	var item model.Image
-	query := h.db.Order("RANDOM()")
+// fetchRandomPhotos is fetchRandomPhoto returning the photo ID as a list, like fetchSmartCollage
+func (h *ImageHandler) fetchRandomPhotos(sel *photoSelection) (image.Image, []uint, error) {
+	img, id, err := h.fetchRandomPhoto(sel)
+	if err != nil {
+		return nil, nil, err
+	}
+	return img, []uint{id}, nil
+}
 
-	if sourceFilter == "google_photos" {
-		query = query.Where("source = ?", "google")
-	} else if sourceFilter == "synology" {
-		query = query.Where("source = ?", "synology")
-	} else if sourceFilter == "telegram" {
-		query = query.Where("source = ?", "telegram")
-	} else {
-		return nil, 0, fmt.Errorf("invalid source filter: %s", sourceFilter)
+func (h *ImageHandler) fetchRandomPhoto(sel *photoSelection) (image.Image, uint, error) {
+	if service.IsRemoteSource(sel.source) {
+		return h.fetchRandomRemotePhoto(sel.source)
 	}
 
-	result := query.First(&item)
-	if result.Error != nil {
-		img, err := h.fetchPlaceholder()
-		return img, 0, err
+	// Hidden photos are skipped, favorites and weights make photos more or less likely
+	item, err := service.PickWeightedImage(h.db.Scopes(sel.scope), h.settings.FavoriteWeight())
+	if err != nil {
+		return nil, 0, sel.emptyError()
 	}
 
-	if item.Source == "synology" {
-		img, _, err := h.fetchSynologyPhoto(item)
-		if err != nil {
-			fmt.Printf("Warning: Failed to fetch Synology photo: %v\n", err)
-			img, err := h.fetchPlaceholder()
-			return img, 0, err
-		}
-		return img, item.ID, nil
+	// Albums and collections may include Synology/Immich photos
+	if service.IsRemoteSource(item.Source) {
+		return h.fetchRemotePhoto(*item)
 	}
 
 	resolvedPath := h.resolvePath(item.FilePath)
@@ -525,27 +620,107 @@ func (h *ImageHandler) fetchRandomPhoto(sourceFilter string) (image.Image, uint,
 		// Do NOT delete the record just because file is missing locally
 		// h.db.Delete(&item)
 		fmt.Printf("Warning: Failed to open image: %s (resolved: %s): %v\n", item.FilePath, resolvedPath, err)
-		img, err := h.fetchPlaceholder()
-		return img, 0, err
+		return nil, 0, &noPhotoError{Title: "File missing", Detail: item.FilePath}
 	}
 	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	img, _, err := imageops.Decode(f)
 	if err != nil {
-		img, err := h.fetchPlaceholder()
-		return img, 0, err
+		return nil, 0, &noPhotoError{Title: "Unreadable photo", Detail: fmt.Sprintf("%s: %v", filepath.Base(item.FilePath), err)}
 	}
 	return img, item.ID, nil
 }
 
-func (h *ImageHandler) fetchPlaceholder() (image.Image, error) {
-	resp, err := http.Get("https://picsum.photos/800/480")
+// fetchRandomRemotePhoto serves the next prefetched candidate (or a random photo)
+// of a remote source. When the server is unreachable, a random cached photo is used.
+func (h *ImageHandler) fetchRandomRemotePhoto(source string) (image.Image, uint, error) {
+	defer func() { go h.remote.Prefetch(source) }()
+
+	item := h.remote.Next(source)
+	if item == nil {
+		var err error
+		item, err = service.PickWeightedImage(h.db.Where("source = ?", source), h.settings.FavoriteWeight())
+		if err != nil {
+			return nil, 0, noPhotosError(source)
+		}
+	}
+
+	img, id, err := h.fetchRemotePhoto(*item)
+	if err == nil {
+		return img, id, nil
+	}
+	log.Printf("Warning: Failed to fetch %s photo %d: %v", source, item.ID, err)
+
+	cached, data, cacheErr := h.remote.RandomCached(source)
+	if cacheErr == nil {
+		if img, _, err := imageops.Decode(bytes.NewReader(data)); err == nil {
+			log.Printf("Serving cached %s photo %d while the server is unreachable", source, cached.ID)
+			return img, cached.ID, nil
+		}
+	}
+
+	return nil, 0, &noPhotoError{
+		Title:  fmt.Sprintf("%s unreachable", sourceLabels[source]),
+		Detail: "No cached photos available yet: " + err.Error(),
+	}
+}
+
+// sourceLabels are the display names of image sources, keyed by route parameter and DB source
+var sourceLabels = map[string]string{
+	"google_photos": "Google Photos",
+	"google":        "Google Photos",
+	"synology":      "Synology",
+	"telegram":      "Telegram",
+	"local":         "Local folder",
+	"upload":        "Uploaded",
+	"immich":        "Immich",
+	"webdav":        "WebDAV",
+	"email":         "Email",
+}
+
+// noPhotoError explains why no photo could be served. ServeImage shows it on
+// the placeholder card instead of failing the request.
+type noPhotoError struct {
+	Title  string
+	Detail string
+}
+
+func (e *noPhotoError) Error() string {
+	return e.Title + ": " + e.Detail
+}
+
+func noPhotosError(source string) error {
+	return &noPhotoError{
+		Title:  fmt.Sprintf("No %s photos synced", sourceLabels[source]),
+		Detail: "Add or sync photos in the PhotoFrame dashboard to show them here.",
+	}
+}
+
+// placeholderImage returns the device's fallback image if it has one, otherwise
+// a card explaining why no photo is shown
+func (h *ImageHandler) placeholderImage(device model.Device, deviceFound bool, width, height int, cause error) image.Image {
+	if deviceFound {
+		if img, err := h.placeholder.Fallback(device.ID); err == nil {
+			return img
+		}
+	}
+
+	var np *noPhotoError
+	if errors.As(cause, &np) {
+		return h.placeholder.Render(width, height, np.Title, np.Detail)
+	}
+	return h.placeholder.Render(width, height, "Could not load photo", cause.Error())
+}
+
+// loadTelegramLast loads the last photo received by the Telegram bot
+func (h *ImageHandler) loadTelegramLast() (image.Image, error) {
+	f, err := os.Open(filepath.Join(h.dataDir, "photos", "telegram_last.jpg"))
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer f.Close()
 
-	img, _, err := image.Decode(resp.Body)
+	img, _, err := imageops.Decode(f)
 	return img, err
 }
 
@@ -585,13 +760,13 @@ func (h *ImageHandler) ServeTelegramImageAfter(c echo.Context) error {
 
 	// Fetch all images with telegram_update_id > given updateID
 	var items []model.Image
-	query := h.db.Where("source = ? AND telegram_update_id > ?", "telegram", updateID)
+	query := h.db.Scopes(service.Visible).Where("source = ? AND telegram_update_id > ?", "telegram", updateID)
 
 	// Special case: if updateID is 0 (initial download), get the newest image directly
 	// This avoids the need for polling to get the latest image
 	if updateID == 0 {
 		var newestImage model.Image
-		result := h.db.Where("source = ?", "telegram").
+		result := h.db.Scopes(service.Visible).Where("source = ?", "telegram").
 			Order("telegram_update_id DESC").
 			First(&newestImage)
 
@@ -632,6 +807,7 @@ func (h *ImageHandler) ServeTelegramImageAfter(c echo.Context) error {
 			}
 		}
 
+		h.recordTelegramShown(c, newestImage.ID)
 		c.Response().Header().Set("Content-Length", fmt.Sprintf("%d", len(processedBytes)))
 		return c.Blob(http.StatusOK, "image/png", processedBytes)
 	}
@@ -694,10 +870,20 @@ func (h *ImageHandler) ServeTelegramImageAfter(c echo.Context) error {
 		}
 	}
 
+	h.recordTelegramShown(c, items[0].ID)
 	c.Response().Header().Set("Content-Length", fmt.Sprintf("%d", len(processedBytes)))
 	return c.Blob(http.StatusOK, "image/png", processedBytes)
 }
 
+// recordTelegramShown logs a Telegram photo in the display history of the
+// requesting device, like serveImage
+func (h *ImageHandler) recordTelegramShown(c echo.Context, imageID uint) {
+	device, _ := h.requestDevice(c)
+	if err := h.history.Record(device.ID, imageID); err != nil {
+		log.Printf("Failed to record display history: %v", err)
+	}
+}
+
 // loadImageFromItem loads an image from a model.Image item
 func (h *ImageHandler) loadImageFromItem(item model.Image) (image.Image, int64, error) {
 	resolvedPath := h.resolvePath(item.FilePath)
@@ -707,7 +893,7 @@ func (h *ImageHandler) loadImageFromItem(item model.Image) (image.Image, int64,
 	}
 	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	img, _, err := imageops.Decode(f)
 	return img, item.TelegramUpdateID, err
 }
 
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type ImmichHandler struct {
+	immich *service.ImmichService
+}
+
+func NewImmichHandler(s *service.ImmichService) *ImmichHandler {
+	return &ImmichHandler{immich: s}
+}
+
+func (h *ImmichHandler) TestConnection(c echo.Context) error {
+	user, err := h.immich.TestConnection()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok", "user": user.Email})
+}
+
+func (h *ImmichHandler) ListAlbums(c echo.Context) error {
+	albums, err := h.immich.ListAlbums()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, albums)
+}
+
+func (h *ImmichHandler) Sync(c echo.Context) error {
+	result, err := h.immich.Sync()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *ImmichHandler) Clear(c echo.Context) error {
+	if err := h.immich.ClearPhotos(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "cleared"})
+}
+
+func (h *ImmichHandler) GetPhotoCount(c echo.Context) error {
+	count, err := h.immich.GetPhotoCount()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"count": count})
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type JobHandler struct {
+	jobs *service.JobService
+}
+
+func NewJobHandler(jobs *service.JobService) *JobHandler {
+	return &JobHandler{jobs: jobs}
+}
+
+// jobResponse is a job with its result decoded
+type jobResponse struct {
+	model.Job
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+func newJobResponse(job model.Job) jobResponse {
+	resp := jobResponse{Job: job}
+	if job.Result != "" {
+		resp.Result = json.RawMessage(job.Result)
+	}
+	return resp
+}
+
+// ListJobs lists the most recent jobs
+// GET /api/jobs?type=synology_sync&status=running&limit=50
+func (h *JobHandler) ListJobs(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	jobs, err := h.jobs.List(c.QueryParam("type"), c.QueryParam("status"), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	resp := make([]jobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, newJobResponse(job))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetJob returns the status, progress and result of a job
+// GET /api/jobs/:id
+func (h *JobHandler) GetJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	job, err := h.jobs.Get(uint(id))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, newJobResponse(*job))
+}
+
+// CancelJob stops a queued or running job
+// POST /api/jobs/:id/cancel
+func (h *JobHandler) CancelJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	if _, err := h.jobs.Get(uint(id)); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	job, err := h.jobs.Cancel(uint(id))
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, newJobResponse(*job))
+}
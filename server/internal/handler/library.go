@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// LibraryHandler manages albums, tags and smart collections of the gallery
+type LibraryHandler struct {
+	library     *service.LibraryService
+	collections *service.CollectionService
+}
+
+func NewLibraryHandler(library *service.LibraryService, collections *service.CollectionService) *LibraryHandler {
+	return &LibraryHandler{
+		library:     library,
+		collections: collections,
+	}
+}
+
+func paramID(c echo.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	return uint(id), err == nil
+}
+
+// GET /api/gallery/albums
+func (h *LibraryHandler) ListAlbums(c echo.Context) error {
+	albums, err := h.library.ListAlbums()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, albums)
+}
+
+// POST /api/gallery/albums {"name": "..."}
+func (h *LibraryHandler) CreateAlbum(c echo.Context) error {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	album, err := h.library.CreateAlbum(req.Name)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, album)
+}
+
+// PUT /api/gallery/albums/:id {"name": "..."}
+func (h *LibraryHandler) UpdateAlbum(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	album, err := h.library.RenameAlbum(id, req.Name)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, album)
+}
+
+// DELETE /api/gallery/albums/:id
+func (h *LibraryHandler) DeleteAlbum(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	if err := h.library.DeleteAlbum(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+type photoIDsRequest struct {
+	PhotoIDs []uint `json:"photo_ids"`
+}
+
+// POST /api/gallery/albums/:id/photos {"photo_ids": [...]}
+func (h *LibraryHandler) AddAlbumPhotos(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	var req photoIDsRequest
+	if err := c.Bind(&req); err != nil || len(req.PhotoIDs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "photo_ids required"})
+	}
+	if err := h.library.AddToAlbumID(id, req.PhotoIDs); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// DELETE /api/gallery/albums/:id/photos {"photo_ids": [...]}
+func (h *LibraryHandler) RemoveAlbumPhotos(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	var req photoIDsRequest
+	if err := c.Bind(&req); err != nil || len(req.PhotoIDs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "photo_ids required"})
+	}
+	if err := h.library.RemoveFromAlbum(id, req.PhotoIDs); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// GET /api/gallery/tags
+func (h *LibraryHandler) ListTags(c echo.Context) error {
+	tags, err := h.library.ListTags()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// PUT /api/gallery/tags/:id {"name": "..."}
+func (h *LibraryHandler) UpdateTag(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	tag, err := h.library.RenameTag(id, req.Name)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tag)
+}
+
+// DELETE /api/gallery/tags/:id
+func (h *LibraryHandler) DeleteTag(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	if err := h.library.DeleteTag(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+type photoTagsRequest struct {
+	PhotoIDs []uint   `json:"photo_ids"`
+	Tags     []string `json:"tags"`
+}
+
+// POST /api/gallery/photos/tags {"photo_ids": [...], "tags": [...]}
+// Tags that don't exist yet are created.
+func (h *LibraryHandler) TagPhotos(c echo.Context) error {
+	var req photoTagsRequest
+	if err := c.Bind(&req); err != nil || len(req.PhotoIDs) == 0 || len(req.Tags) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "photo_ids and tags required"})
+	}
+	if err := h.library.AddTags(req.PhotoIDs, req.Tags); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// DELETE /api/gallery/photos/tags {"photo_ids": [...], "tags": [...]}
+func (h *LibraryHandler) UntagPhotos(c echo.Context) error {
+	var req photoTagsRequest
+	if err := c.Bind(&req); err != nil || len(req.PhotoIDs) == 0 || len(req.Tags) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "photo_ids and tags required"})
+	}
+	if err := h.library.RemoveTags(req.PhotoIDs, req.Tags); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// GET /api/gallery/collections
+func (h *LibraryHandler) ListCollections(c echo.Context) error {
+	collections, err := h.collections.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, collections)
+}
+
+type collectionRequest struct {
+	Name  string                 `json:"name"`
+	Match string                 `json:"match"`
+	Rules []model.CollectionRule `json:"rules"`
+}
+
+// POST /api/gallery/collections
+// {"name": "Family portraits", "match": "all", "rules": [{"field": "tag", "op": "has", "value": "family"}]}
+func (h *LibraryHandler) CreateCollection(c echo.Context) error {
+	var req collectionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	collection, err := h.collections.Create(req.Name, req.Match, req.Rules)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, collection)
+}
+
+// PUT /api/gallery/collections/:id
+func (h *LibraryHandler) UpdateCollection(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	var req collectionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	collection, err := h.collections.Update(id, req.Name, req.Match, req.Rules)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, collection)
+}
+
+// DELETE /api/gallery/collections/:id
+func (h *LibraryHandler) DeleteCollection(c echo.Context) error {
+	id, ok := paramID(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	if err := h.collections.Delete(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
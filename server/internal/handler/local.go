@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type LocalHandler struct {
+	local *service.LocalSourceService
+}
+
+func NewLocalHandler(l *service.LocalSourceService) *LocalHandler {
+	return &LocalHandler{local: l}
+}
+
+// POST /api/local/scan
+func (h *LocalHandler) Scan(c echo.Context) error {
+	if len(h.local.Directories()) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no local directories configured"})
+	}
+
+	result, err := h.local.Scan()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// GET /api/local/count
+func (h *LocalHandler) GetPhotoCount(c echo.Context) error {
+	count, err := h.local.GetPhotoCount()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"count":       count,
+		"directories": h.local.Directories(),
+	})
+}
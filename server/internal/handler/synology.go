@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strings"
 
 	"github.com/aitjcize/photoframe-server/server/internal/service"
 	"github.com/labstack/echo/v4"
@@ -48,18 +47,42 @@ func (h *SynologyHandler) ListAlbums(c echo.Context) error {
 	return c.JSON(http.StatusOK, albums)
 }
 
+// GET /api/synology/people
+func (h *SynologyHandler) ListPeople(c echo.Context) error {
+	people, err := h.synology.ListPeople()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, people)
+}
+
+// GET /api/synology/tags
+func (h *SynologyHandler) ListTags(c echo.Context) error {
+	tags, err := h.synology.ListTags()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// POST /api/synology/sync
+// Queues a sync job and returns its ID, to be polled at GET /api/jobs/:id
 func (h *SynologyHandler) Sync(c echo.Context) error {
-	// Always clear and resync to ensure fresh references
+	// Incremental: new photos are added and photos deleted on the NAS removed.
 	// Synology photos aren't stored locally, just references in DB
-	if err := h.synology.ClearAndResync(); err != nil {
-		// Check if it's an authentication error
-		if strings.Contains(err.Error(), "authentication expired") {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Session expired. Please reconnect to Synology."})
-		}
+	job, err := h.synology.StartSync()
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"status": "queued",
+		"job_id": job.ID,
+	})
+}
 
-	return c.JSON(http.StatusOK, map[string]string{"status": "synced"})
+// GET /api/synology/sync/status
+func (h *SynologyHandler) GetSyncStatus(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.synology.GetSyncStatus())
 }
 
 func (h *SynologyHandler) Clear(c echo.Context) error {
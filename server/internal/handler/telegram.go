@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type TelegramHandler struct {
+	telegram *service.TelegramService
+}
+
+func NewTelegramHandler(s *service.TelegramService) *TelegramHandler {
+	return &TelegramHandler{telegram: s}
+}
+
+// Webhook receives updates from Telegram. It is public: requests are
+// authenticated with the secret token registered with the webhook.
+func (h *TelegramHandler) Webhook(c echo.Context) error {
+	h.telegram.ServeWebhook(c.Response(), c.Request())
+	return nil
+}
+
+// CreatePairing returns a one-time code that authorizes the chat sending "/pair <code>"
+func (h *TelegramHandler) CreatePairing(c echo.Context) error {
+	var req struct {
+		DeviceID uint `json:"device_id"` // Optional device for the paired chat
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	pairing, err := h.telegram.CreatePairing(req.DeviceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, pairing)
+}
+
+func (h *TelegramHandler) ListChats(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.telegram.ListChats())
+}
+
+// UpdateChat allows a user or chat ID and sets the device its photos are pushed to
+func (h *TelegramHandler) UpdateChat(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid chat id"})
+	}
+	var req struct {
+		DeviceID uint `json:"device_id"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if err := h.telegram.SetChat(id, req.DeviceID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, service.TelegramChat{ID: id, DeviceID: req.DeviceID})
+}
+
+func (h *TelegramHandler) DeleteChat(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid chat id"})
+	}
+	if err := h.telegram.RevokeChat(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type TrashHandler struct {
+	trash *service.TrashService
+}
+
+func NewTrashHandler(trash *service.TrashService) *TrashHandler {
+	return &TrashHandler{trash: trash}
+}
+
+// ListTrash lists the photos in the trash, most recently deleted first
+// GET /api/gallery/trash?limit=50&offset=0
+func (h *TrashHandler) ListTrash(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	offset, _ := strconv.Atoi(c.QueryParam("offset"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var total int64
+	if err := h.trash.Trashed().Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	var items []model.Image
+	if err := h.trash.Trashed().Order("images.deleted_at DESC, images.id DESC").Limit(limit).Offset(offset).Find(&items).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	type TrashedPhoto struct {
+		ID           uint       `json:"id"`
+		ThumbnailURL string     `json:"thumbnail_url"`
+		Caption      string     `json:"caption"`
+		Source       string     `json:"source"`
+		CreatedAt    time.Time  `json:"created_at"`
+		DeletedAt    time.Time  `json:"deleted_at"`
+		PurgeAt      *time.Time `json:"purge_at"` // null if kept until the trash is emptied
+	}
+
+	days := h.trash.RetentionDays()
+	host := c.Request().Host
+	photos := make([]TrashedPhoto, 0, len(items))
+	for _, item := range items {
+		photo := TrashedPhoto{
+			ID:           item.ID,
+			ThumbnailURL: fmt.Sprintf("http://%s/api/gallery/thumbnail/%d", host, item.ID),
+			Caption:      item.Caption,
+			Source:       item.Source,
+			CreatedAt:    item.CreatedAt,
+			DeletedAt:    item.DeletedAt.Time,
+		}
+		if days > 0 {
+			purgeAt := item.DeletedAt.Time.AddDate(0, 0, days)
+			photo.PurgeAt = &purgeAt
+		}
+		photos = append(photos, photo)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"photos":         photos,
+		"total":          total,
+		"limit":          limit,
+		"offset":         offset,
+		"retention_days": days,
+	})
+}
+
+// RestorePhotos takes photos out of the trash
+// POST /api/gallery/trash/restore {"photo_ids": [1, 2]}
+func (h *TrashHandler) RestorePhotos(c echo.Context) error {
+	var req photoIDsRequest
+	if err := c.Bind(&req); err != nil || len(req.PhotoIDs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "photo_ids is required"})
+	}
+	restored, err := h.trash.Restore(req.PhotoIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "restored", "restored": restored})
+}
+
+// EmptyTrash deletes photos in the trash for good: the given ones, or all
+// DELETE /api/gallery/trash {"photo_ids": [1, 2]}
+func (h *TrashHandler) EmptyTrash(c echo.Context) error {
+	var req photoIDsRequest
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+	}
+	purged, err := h.trash.Purge(req.PhotoIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "purged", "purged": purged})
+}
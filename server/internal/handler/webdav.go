@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type WebDAVHandler struct {
+	webdav *service.WebDAVService
+}
+
+func NewWebDAVHandler(s *service.WebDAVService) *WebDAVHandler {
+	return &WebDAVHandler{webdav: s}
+}
+
+func (h *WebDAVHandler) TestConnection(c echo.Context) error {
+	if err := h.webdav.TestConnection(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *WebDAVHandler) Sync(c echo.Context) error {
+	result, err := h.webdav.Sync()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *WebDAVHandler) Clear(c echo.Context) error {
+	if err := h.webdav.ClearPhotos(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "cleared"})
+}
+
+func (h *WebDAVHandler) GetPhotoCount(c echo.Context) error {
+	count, err := h.webdav.GetPhotoCount()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"count": count})
+}
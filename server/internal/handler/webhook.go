@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	webhooks *service.WebhookService
+}
+
+func NewWebhookHandler(webhooks *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+type webhookRequest struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Secret  string `json:"secret"` // Generated if empty
+	Events  string `json:"events"` // Comma separated, empty for all
+	Enabled *bool  `json:"enabled"`
+}
+
+// ListWebhooks lists the webhooks and the events they can subscribe to
+// GET /api/webhooks
+func (h *WebhookHandler) ListWebhooks(c echo.Context) error {
+	webhooks, err := h.webhooks.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"webhooks": webhooks,
+		"events":   service.WebhookEvents,
+	})
+}
+
+// CreateWebhook adds a webhook, enabled unless "enabled" is false
+// POST /api/webhooks {"name": "n8n", "url": "https://...", "events": "push.failed,device.stale"}
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	var req webhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	webhook := model.Webhook{Name: req.Name, URL: req.URL, Secret: req.Secret, Events: req.Events, Enabled: req.Enabled == nil || *req.Enabled}
+	if err := h.webhooks.Save(&webhook); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, webhook)
+}
+
+// UpdateWebhook replaces a webhook's settings. The secret is kept if empty.
+// PUT /api/webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(c echo.Context) error {
+	webhook, err := h.webhook(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	var req webhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	webhook.Name = req.Name
+	webhook.URL = req.URL
+	webhook.Events = req.Events
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	if err := h.webhooks.Save(webhook); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+// DELETE /api/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	webhook, err := h.webhook(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err := h.webhooks.Delete(webhook.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TestWebhook sends a webhook.test event and returns the delivery, including
+// the response code or error
+// POST /api/webhooks/:id/test
+func (h *WebhookHandler) TestWebhook(c echo.Context) error {
+	webhook, err := h.webhook(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	delivery, err := h.webhooks.SendTest(webhook.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, delivery)
+}
+
+// ListDeliveries returns the delivery log of a webhook, newest first
+// GET /api/webhooks/:id/deliveries?limit=50
+func (h *WebhookHandler) ListDeliveries(c echo.Context) error {
+	webhook, err := h.webhook(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	deliveries, err := h.webhooks.Deliveries(webhook.ID, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// webhook loads the webhook of the :id parameter
+func (h *WebhookHandler) webhook(c echo.Context) (*model.Webhook, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return h.webhooks.Get(uint(id))
+}
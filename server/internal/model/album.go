@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Album is a user-defined group of images (many-to-many, across all sources)
+type Album struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Tag is a free-form label attached to images (many-to-many, across all sources)
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Collection is a smart album: the images matching its rules, evaluated when
+// the collection is shown
+type Collection struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	Name      string           `gorm:"uniqueIndex" json:"name"`
+	Match     string           `json:"match"` // "all" (AND) or "any" (OR)
+	Rules     []CollectionRule `gorm:"serializer:json" json:"rules"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// CollectionRule is a single condition of a collection, e.g.
+// {"field": "tag", "op": "has", "value": "family"}
+type CollectionRule struct {
+	Field string `json:"field"` // orientation, source, tag, album, caption, captured
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// CacheEntry indexes a locally cached copy of a remote photo. Blobs are
+// content-addressed: Hash names the file under DATA_DIR/cache.
+type CacheEntry struct {
+	Key        string    `gorm:"primaryKey" json:"key"` // e.g. "synology:123:<cache key>:large"
+	Hash       string    `gorm:"index" json:"hash"`     // SHA-256 of the content
+	Size       int64     `json:"size"`
+	ImageID    uint      `gorm:"index" json:"image_id"`
+	Variant    string    `json:"variant"` // "display" or "thumbnail"
+	LastAccess time.Time `gorm:"index" json:"last_access"`
+	CreatedAt  time.Time `json:"created_at"`
+}
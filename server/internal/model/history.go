@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Display records a photo being shown on a device
+type Display struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	ImageID  uint      `gorm:"index" json:"image_id"`
+	DeviceID uint      `gorm:"index" json:"device_id"` // 0 if the device is unknown
+	ShownAt  time.Time `json:"shown_at"`
+}
+
+func (Display) TableName() string {
+	return "display_history"
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Job statuses
+const (
+	JobQueued    = "queued" // Waiting for a worker, or for its next attempt (run_at)
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCanceled  = "canceled"
+)
+
+// Job is a unit of background work, run by service.JobService
+type Job struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	Type        string      `json:"type"`         // e.g. "google_picker", "synology_sync"
+	Key         string      `json:"key"`          // Identifies the work, e.g. the picker session. At most one active job per type and non-empty key.
+	Status      string      `json:"status"`       // See the Job* constants
+	Payload     string      `json:"-"`            // JSON input
+	Result      string      `json:"-"`            // JSON output of a successful run
+	Error       string      `json:"error"`        // Error of the last attempt
+	Attempts    int         `json:"attempts"`     // Attempts started so far
+	MaxAttempts int         `json:"max_attempts"` // Including retries
+	RunAt       time.Time   `json:"run_at"`       // Not started before this time
+	Progress    JobProgress `gorm:"embedded;embeddedPrefix:progress_" json:"progress"`
+	StartedAt   *time.Time  `json:"started_at"`
+	FinishedAt  *time.Time  `json:"finished_at"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// JobProgress is reported by a running job
+type JobProgress struct {
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Failed    int    `json:"failed"`
+	Message   string `json:"message"` // Current phase, e.g. "downloading"
+}
+
+// Finished reports whether the job will not run again
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCanceled
+}
@@ -20,12 +20,32 @@ type Image struct {
 	Orientation      string         `json:"orientation"` // "landscape", "portrait"
 	UserID           int64          `json:"user_id"`
 	Status           string         `json:"status"` // pending, shown
-	Source           string         `json:"source"` // "local", "google", "synology"
+	Source           string         `json:"source"` // "local", "google", "synology", "telegram", "upload", "immich", "webdav", "email"
 	SynologyPhotoID  int            `json:"synology_id"`
-	SynologySpace    string         `json:"synology_space"`     // "personal" or "shared"
-	ThumbnailKey     string         `json:"thumbnail_key"`      // Cache key for Synology
-	TelegramUpdateID int64          `json:"telegram_update_id"` // Telegram update ID for deduplication
+	SynologySpace    string         `json:"synology_space"`                        // "personal" or "shared"
+	SynologyAlbumID  int            `json:"synology_album_id"`                     // Album the photo was synced from (0 = whole space)
+	ThumbnailKey     string         `json:"thumbnail_key"`                         // Cache key for Synology
+	TelegramUpdateID int64          `json:"telegram_update_id"`                    // Telegram update ID for deduplication
+	ImmichAssetID    string         `json:"immich_asset_id"`                       // Immich asset UUID
+	WebDAVPath       string         `gorm:"column:webdav_path" json:"webdav_path"` // Remote path relative to the WebDAV root
+	WebDAVETag       string         `gorm:"column:webdav_etag" json:"-"`           // ETag of the downloaded version
+	GoogleAlbumID    string         `json:"google_album_id"`                       // Google album the photo was synced from (empty for Picker imports)
+	EmailMessageID   string         `json:"email_message_id"`                      // Message-ID of the email the photo was attached to
+	EmailSender      string         `json:"email_sender"`                          // Address the email was sent from
+	CapturedAt       *time.Time     `json:"captured_at"`                           // When the photo was taken, if known
+	FileModTime      *time.Time     `json:"-"`                                     // Modification time of a local folder file when it was read
+	ShownCount       int            `json:"shown_count"`                           // Times the photo was shown on a frame
+	LastShownAt      *time.Time     `json:"last_shown_at"`                         // When the photo was last shown
+	Favorite         bool           `json:"favorite"`                              // Shown more often (setting favorite_weight)
+	Hidden           bool           `json:"hidden"`                                // Kept in the gallery but never shown
+	Weight           float64        `gorm:"default:1" json:"weight"`               // Relative chance of being picked, 1 = normal
+	PHash            string         `gorm:"column:phash" json:"phash"`             // Perceptual hash for duplicate detection
+	TrashPath        string         `json:"-"`                                     // Where the file was moved while in the trash
+	Purged           bool           `json:"-"`                                     // Removed from the trash, row kept so syncs skip the photo
+	Albums           []Album        `gorm:"many2many:image_albums;" json:"albums,omitempty"`
+	Tags             []Tag          `gorm:"many2many:image_tags;" json:"tags,omitempty"`
 	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
@@ -37,17 +57,19 @@ type GoogleAuth struct {
 }
 
 type Device struct {
-	ID                 uint      `gorm:"primaryKey" json:"id"`
-	Name               string    `json:"name"`
-	Host               string    `json:"host"` // IP or Hostname
-	Width              int       `json:"width"`
-	Height             int       `json:"height"`
-	UseDeviceParameter bool      `json:"use_device_parameter"`
-	Orientation        string    `json:"orientation"`
-	EnableCollage      bool      `json:"enable_collage"` // Per-device collage setting
-	ShowDate           bool      `json:"show_date"`
-	ShowWeather        bool      `json:"show_weather"`
-	WeatherLat         float64   `json:"weather_lat"`
-	WeatherLon         float64   `json:"weather_lon"`
-	CreatedAt          time.Time `json:"created_at"`
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	Name               string     `json:"name"`
+	Host               string     `json:"host"` // IP or Hostname
+	Width              int        `json:"width"`
+	Height             int        `json:"height"`
+	UseDeviceParameter bool       `json:"use_device_parameter"`
+	Orientation        string     `json:"orientation"`
+	EnableCollage      bool       `json:"enable_collage"` // Per-device collage setting
+	ShowDate           bool       `json:"show_date"`
+	ShowWeather        bool       `json:"show_weather"`
+	WeatherLat         float64    `json:"weather_lat"`
+	WeatherLon         float64    `json:"weather_lon"`
+	Source             string     `json:"source"`       // Photos shown by "next photo" (DB source, empty for all)
+	LastSeenAt         *time.Time `json:"last_seen_at"` // Last image request, null if never seen
+	CreatedAt          time.Time  `json:"created_at"`
 }
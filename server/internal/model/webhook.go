@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending" // Not sent yet, or waiting for a retry
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook posts events to a URL, see service.WebhookService
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"` // HMAC-SHA256 key of the X-Photoframe-Signature header
+	Events    string    `json:"events"` // Comma separated event types, empty for all
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is an event sent (or to be sent) to a webhook
+type WebhookDelivery struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	WebhookID    uint       `json:"webhook_id"`
+	Event        string     `json:"event"`
+	Payload      string     `json:"payload"` // JSON body
+	Status       string     `json:"status"`  // See the Delivery* constants
+	Attempts     int        `json:"attempts"`
+	ResponseCode int        `json:"response_code"` // HTTP status of the last attempt, 0 if no response
+	Error        string     `json:"error"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at"`
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	"gorm.io/gorm"
+)
+
+// Batch actions
+const (
+	BatchDelete   = "delete"
+	BatchTag      = "tag"
+	BatchAlbum    = "album"
+	BatchHide     = "hide"
+	BatchFavorite = "favorite"
+	BatchPush     = "push"
+	BatchRotate   = "rotate"
+)
+
+// BatchJob is the job type of batches run in the background
+const BatchJob = "batch"
+
+// BatchRequest applies one action to a list of photos
+type BatchRequest struct {
+	PhotoIDs []uint   `json:"photo_ids"`
+	Action   string   `json:"action"`
+	Tags     []string `json:"tags"`      // tag
+	Album    string   `json:"album"`     // album, created if needed
+	Value    *bool    `json:"value"`     // hide, favorite: false to undo (default true)
+	DeviceID uint     `json:"device_id"` // push
+	Degrees  int      `json:"degrees"`   // rotate: 90, 180 or 270 clockwise
+	Async    bool     `json:"async"`     // Run in the background even if the action is quick
+}
+
+// BatchItemResult is the outcome of a batch action for one photo
+type BatchItemResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"` // "ok", "error"
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResult reports the outcome of a batch per photo
+type BatchResult struct {
+	Action    string            `json:"action"`
+	Total     int               `json:"total"`
+	Processed int               `json:"processed"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// BatchService applies gallery actions to many photos at once. Quick actions
+// run right away; pushes and rotations run as background jobs.
+type BatchService struct {
+	db      *gorm.DB
+	library *LibraryService
+	trash   *TrashService
+	devices *DeviceService
+	remote  *RemotePhotoService
+	history *HistoryService
+	jobs    *JobService
+	dataDir string
+}
+
+func NewBatchService(db *gorm.DB, library *LibraryService, trash *TrashService, devices *DeviceService, remote *RemotePhotoService, history *HistoryService, jobs *JobService, dataDir string) *BatchService {
+	s := &BatchService{
+		db:      db,
+		library: library,
+		trash:   trash,
+		devices: devices,
+		remote:  remote,
+		history: history,
+		jobs:    jobs,
+		dataDir: dataDir,
+	}
+	// Not resumed after a restart: rotating twice is not harmless
+	jobs.Register(BatchJob, JobType{Run: s.runJob})
+	return s
+}
+
+// Validate checks that the request is complete for its action
+func (s *BatchService) Validate(req *BatchRequest) error {
+	if len(req.PhotoIDs) == 0 {
+		return errors.New("photo_ids is required")
+	}
+	switch req.Action {
+	case BatchDelete, BatchHide, BatchFavorite:
+	case BatchTag:
+		for _, tag := range req.Tags {
+			if strings.TrimSpace(tag) != "" {
+				return nil
+			}
+		}
+		return errors.New("tags is required")
+	case BatchAlbum:
+		if strings.TrimSpace(req.Album) == "" {
+			return errors.New("album is required")
+		}
+	case BatchPush:
+		if req.DeviceID == 0 {
+			return errors.New("device_id is required")
+		}
+		if err := s.db.First(&model.Device{}, req.DeviceID).Error; err != nil {
+			return errors.New("device not found")
+		}
+	case BatchRotate:
+		if req.Degrees != 90 && req.Degrees != 180 && req.Degrees != 270 {
+			return errors.New("degrees must be 90, 180 or 270")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", req.Action)
+	}
+	return nil
+}
+
+// IsLong reports whether a request runs in the background
+func (s *BatchService) IsLong(req *BatchRequest) bool {
+	return req.Async || req.Action == BatchPush || req.Action == BatchRotate
+}
+
+// Run applies a validated request
+func (s *BatchService) Run(req BatchRequest) *BatchResult {
+	return s.run(context.Background(), nil, req)
+}
+
+// Start queues a validated request as a background job
+func (s *BatchService) Start(req BatchRequest) (*model.Job, error) {
+	return s.jobs.Enqueue(BatchJob, "", req)
+}
+
+func (s *BatchService) runJob(ctx context.Context, run *JobRun) (interface{}, error) {
+	var req BatchRequest
+	if err := run.Payload(&req); err != nil {
+		return nil, err
+	}
+	return s.run(ctx, run, req), nil
+}
+
+// run applies a request. Progress is reported to run, if not nil.
+func (s *BatchService) run(ctx context.Context, run *JobRun, req BatchRequest) *BatchResult {
+	result := &BatchResult{Action: req.Action, Total: len(req.PhotoIDs), Results: []BatchItemResult{}}
+	if run != nil {
+		run.Update(func(p *model.JobProgress) { p.Total = result.Total })
+	}
+	record := func(id uint, err error) {
+		item := BatchItemResult{ID: id, Status: "ok"}
+		if err != nil {
+			item.Status = "error"
+			item.Error = err.Error()
+			result.Failed++
+		}
+		result.Results = append(result.Results, item)
+		result.Processed++
+		if run != nil {
+			run.Update(func(p *model.JobProgress) {
+				p.Processed = result.Processed
+				p.Failed = result.Failed
+			})
+		}
+	}
+
+	var items []model.Image
+	if err := s.db.Find(&items, req.PhotoIDs).Error; err != nil {
+		for _, id := range req.PhotoIDs {
+			record(id, err)
+		}
+		return result
+	}
+	found := make(map[uint]bool, len(items))
+	var ids []uint
+	for _, item := range items {
+		found[item.ID] = true
+		ids = append(ids, item.ID)
+	}
+	for _, id := range req.PhotoIDs {
+		if !found[id] {
+			record(id, errors.New("photo not found"))
+		}
+	}
+	if len(items) == 0 {
+		return result
+	}
+
+	value := req.Value == nil || *req.Value
+
+	// Library actions are applied to all photos at once
+	var err error
+	switch req.Action {
+	case BatchTag:
+		err = s.library.AddTags(ids, req.Tags)
+	case BatchAlbum:
+		err = s.library.AddToAlbum(ids, req.Album)
+	case BatchHide:
+		err = s.db.Model(&model.Image{}).Where("id IN ?", ids).UpdateColumn("hidden", value).Error
+	case BatchFavorite:
+		err = s.db.Model(&model.Image{}).Where("id IN ?", ids).UpdateColumn("favorite", value).Error
+	default:
+		for _, item := range items {
+			if ctx.Err() != nil {
+				record(item.ID, errors.New("canceled"))
+				continue
+			}
+			record(item.ID, s.apply(req, item))
+		}
+		return result
+	}
+	for _, id := range ids {
+		record(id, err)
+	}
+	return result
+}
+
+// apply runs a per-photo action
+func (s *BatchService) apply(req BatchRequest, item model.Image) error {
+	switch req.Action {
+	case BatchDelete:
+		return s.trash.Trash([]model.Image{item})
+	case BatchPush:
+		return s.push(req.DeviceID, item)
+	case BatchRotate:
+		return s.rotate(item, req.Degrees)
+	}
+	return fmt.Errorf("unknown action %q", req.Action)
+}
+
+// push shows a photo on a device. Photos are pushed one after another, so the
+// last one stays on screen.
+func (s *BatchService) push(deviceID uint, item model.Image) error {
+	return pushPhoto(s.devices, s.remote, s.history, deviceID, item)
+}
+
+// rotate rotates the photo file clockwise. Only copies managed by the server
+// are changed: local folder files are the user's own and remote photos live
+// on the server they came from.
+func (s *BatchService) rotate(item model.Image, degrees int) error {
+	if IsRemoteSource(item.Source) {
+		return fmt.Errorf("%s photos can't be rotated", item.Source)
+	}
+	if !ownedFileSources[item.Source] {
+		return errors.New("local folder photos are not modified")
+	}
+
+	ext := strings.ToLower(filepath.Ext(item.FilePath))
+	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+		return fmt.Errorf("rotating %s files is not supported", ext)
+	}
+
+	f, err := os.Open(item.FilePath)
+	if err != nil {
+		return err
+	}
+	img, _, err := imageops.Decode(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	rotated := imageops.Rotate(img, degrees)
+
+	// Write next to the original, then replace it
+	tmpPath := item.FilePath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if ext == ".png" {
+		err = png.Encode(out, rotated)
+	} else {
+		err = jpeg.Encode(out, rotated, &jpeg.Options{Quality: 95})
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, item.FilePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	width, height, orientation, err := imageops.ReadInfo(item.FilePath)
+	if err != nil {
+		return err
+	}
+	// Thumbnail is regenerated on next request
+	os.Remove(filepath.Join(s.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID)))
+	return s.db.Model(&model.Image{}).Where("id = ?", item.ID).UpdateColumns(map[string]interface{}{
+		"width":       width,
+		"height":      height,
+		"orientation": orientation,
+		"phash":       hashPhoto(item.FilePath),
+	}).Error
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestBatchService(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	library := NewLibraryService(db)
+	history := NewHistoryService(db, nil)
+	cache := NewCacheService(db, settings, dataDir)
+	remote := NewRemotePhotoService(db, settings, cache, nil, nil)
+	trash := NewTrashService(db, settings, library, history, cache, dataDir)
+	jobs := NewJobService(db, nil)
+	batch := NewBatchService(db, library, trash, nil, remote, history, jobs, dataDir)
+	jobs.Start(1)
+
+	// A landscape PNG, red in the top left corner
+	uploadPath := filepath.Join(dataDir, "upload.png")
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	f, err := os.Create(uploadPath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	f.Close()
+
+	images := []model.Image{
+		{Source: "upload", FilePath: uploadPath, Width: 40, Height: 20, Orientation: "landscape"},
+		{Source: "local", FilePath: filepath.Join(dataDir, "local.jpg")},
+		{Source: "synology", FilePath: "remote.jpg"},
+	}
+	require.NoError(t, db.Create(&images).Error)
+	ids := []uint{images[0].ID, images[1].ID, images[2].ID, 999}
+
+	req := BatchRequest{PhotoIDs: ids, Action: BatchTag, Tags: []string{"Family"}}
+	require.NoError(t, batch.Validate(&req))
+	progress := batch.Run(req)
+	assert.Equal(t, 4, progress.Processed)
+	assert.Equal(t, 1, progress.Failed)
+	assert.Contains(t, progress.Results, BatchItemResult{ID: 999, Status: "error", Error: "photo not found"})
+
+	var tagged int64
+	db.Table("image_tags").Count(&tagged)
+	assert.Equal(t, int64(3), tagged)
+
+	progress = batch.Run(BatchRequest{PhotoIDs: ids[:2], Action: BatchFavorite})
+	assert.Equal(t, 0, progress.Failed)
+	var favorites int64
+	db.Model(&model.Image{}).Where("favorite = ?", true).Count(&favorites)
+	assert.Equal(t, int64(2), favorites)
+
+	// Rotation only changes files managed by the server, in the background
+	req = BatchRequest{PhotoIDs: ids[:3], Action: BatchRotate, Degrees: 90}
+	require.NoError(t, batch.Validate(&req))
+	assert.True(t, batch.IsLong(&req))
+	job, err := batch.Start(req)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		job, err = jobs.Get(job.ID)
+		return err == nil && job.Finished()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, model.JobSucceeded, job.Status)
+	assert.Equal(t, 3, job.Progress.Processed)
+	assert.Equal(t, 2, job.Progress.Failed)
+
+	var rotated model.Image
+	require.NoError(t, db.First(&rotated, images[0].ID).Error)
+	assert.Equal(t, "portrait", rotated.Orientation)
+	assert.Equal(t, 20, rotated.Width)
+	assert.Len(t, rotated.PHash, 16)
+
+	f, err = os.Open(uploadPath)
+	require.NoError(t, err)
+	decoded, err := png.Decode(f)
+	f.Close()
+	require.NoError(t, err)
+	r, _, _, _ := decoded.At(19, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+
+	progress = batch.Run(BatchRequest{PhotoIDs: ids[:1], Action: BatchDelete})
+	assert.Equal(t, 0, progress.Failed)
+	assert.ErrorIs(t, db.First(&model.Image{}, images[0].ID).Error, gorm.ErrRecordNotFound)
+
+	assert.Error(t, batch.Validate(&BatchRequest{PhotoIDs: ids, Action: BatchRotate, Degrees: 45}))
+	assert.Error(t, batch.Validate(&BatchRequest{PhotoIDs: ids, Action: BatchPush, DeviceID: 7}))
+	assert.Error(t, batch.Validate(&BatchRequest{Action: BatchHide}))
+}
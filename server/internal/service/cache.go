@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultCacheMaxMB = 1024
+
+// CacheUsage describes the current state of the local cache
+type CacheUsage struct {
+	Entries  int64 `json:"entries"`
+	Files    int64 `json:"files"`
+	Bytes    int64 `json:"bytes"`
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+// CacheService is a content-addressed blob store under DATA_DIR/cache, indexed by
+// the cache_entries table. The least recently used entries are evicted once the
+// total size exceeds the cache_max_mb setting.
+type CacheService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	dir      string
+
+	mu sync.Mutex // Serializes reads, writes and eviction
+}
+
+func NewCacheService(db *gorm.DB, settings *SettingsService, dataDir string) *CacheService {
+	return &CacheService{
+		db:       db,
+		settings: settings,
+		dir:      filepath.Join(dataDir, "cache"),
+	}
+}
+
+// MaxBytes returns the configured size limit (setting cache_max_mb, default 1 GB)
+func (s *CacheService) MaxBytes() int64 {
+	val, _ := s.settings.Get("cache_max_mb")
+	mb, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || mb < 0 {
+		mb = defaultCacheMaxMB
+	}
+	return mb * 1024 * 1024
+}
+
+func (s *CacheService) blobPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Get returns the cached content for key and marks it as recently used
+func (s *CacheService) Get(key string) ([]byte, bool) {
+	// Eviction must not remove the blob between the lookup and the read
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entry model.CacheEntry
+	// Find instead of First: misses are expected and should not be logged
+	if err := s.db.Where("key = ?", key).Limit(1).Find(&entry).Error; err != nil || entry.Key == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(s.blobPath(entry.Hash))
+	if err != nil {
+		// Blob vanished from disk, drop the stale index entry
+		s.db.Delete(&entry)
+		return nil, false
+	}
+
+	s.db.Model(&entry).Update("last_access", time.Now())
+	return data, true
+}
+
+// Put stores data under key, then evicts old entries if the cache is over its limit
+func (s *CacheService) Put(key string, imageID uint, variant string, data []byte) error {
+	maxBytes := s.MaxBytes()
+	if maxBytes == 0 {
+		return nil // Caching disabled
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.blobPath(hash)
+	if _, err := os.Stat(path); err != nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0644); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	entry := model.CacheEntry{
+		Key:        key,
+		Hash:       hash,
+		Size:       int64(len(data)),
+		ImageID:    imageID,
+		Variant:    variant,
+		LastAccess: now,
+		CreatedAt:  now,
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hash", "size", "image_id", "variant", "last_access"}),
+	}).Create(&entry).Error; err != nil {
+		return err
+	}
+
+	s.evict(maxBytes)
+	return nil
+}
+
+// evict removes the least recently used entries until the blobs fit in maxBytes.
+// Must be called with s.mu held.
+func (s *CacheService) evict(maxBytes int64) {
+	total := s.totalBytes()
+	if total <= maxBytes {
+		return
+	}
+
+	var entries []model.CacheEntry
+	if err := s.db.Order("last_access ASC").Find(&entries).Error; err != nil {
+		log.Printf("Cache: failed to list entries for eviction: %v", err)
+		return
+	}
+
+	evicted := 0
+	for _, entry := range entries {
+		if total <= maxBytes {
+			break
+		}
+		if err := s.db.Delete(&entry).Error; err != nil {
+			continue
+		}
+		evicted++
+
+		// The blob may still be referenced by another key
+		var refs int64
+		s.db.Model(&model.CacheEntry{}).Where("hash = ?", entry.Hash).Count(&refs)
+		if refs == 0 {
+			os.Remove(s.blobPath(entry.Hash))
+			total -= entry.Size
+		}
+	}
+	log.Printf("Cache: evicted %d entries, %d bytes in use", evicted, total)
+}
+
+// totalBytes sums the size of each distinct blob
+func (s *CacheService) totalBytes() int64 {
+	var total int64
+	s.db.Raw("SELECT COALESCE(SUM(size), 0) FROM (SELECT MAX(size) AS size FROM cache_entries GROUP BY hash)").Scan(&total)
+	return total
+}
+
+// Usage returns the number of entries, blobs and bytes in the cache
+func (s *CacheService) Usage() (*CacheUsage, error) {
+	usage := &CacheUsage{MaxBytes: s.MaxBytes()}
+	if err := s.db.Model(&model.CacheEntry{}).Count(&usage.Entries).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&model.CacheEntry{}).Distinct("hash").Count(&usage.Files).Error; err != nil {
+		return nil, err
+	}
+	usage.Bytes = s.totalBytes()
+	return usage, nil
+}
+
+// Remove drops all cached variants of an image
+func (s *CacheService) Remove(imageIDs []uint) {
+	if len(imageIDs) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []model.CacheEntry
+	s.db.Where("image_id IN ?", imageIDs).Find(&entries)
+	for _, entry := range entries {
+		s.db.Delete(&entry)
+		var refs int64
+		s.db.Model(&model.CacheEntry{}).Where("hash = ?", entry.Hash).Count(&refs)
+		if refs == 0 {
+			os.Remove(s.blobPath(entry.Hash))
+		}
+	}
+}
+
+// Clear deletes every cached blob
+func (s *CacheService) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.db.Where("1 = 1").Delete(&model.CacheEntry{}).Error; err != nil {
+		return err
+	}
+	if err := os.RemoveAll(s.dir); err != nil {
+		return err
+	}
+	log.Println("Cleared photo cache")
+	return nil
+}
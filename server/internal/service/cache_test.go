@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hashOf returns the blob name of cached content
+func hashOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCacheService_SharedBlobs(t *testing.T) {
+	db := setupTestDB(t)
+	cache := NewCacheService(db, NewSettingsService(db), t.TempDir())
+
+	// Two photos with the same content share one blob
+	require.NoError(t, cache.Put("synology:1:xl", 1, "xl", []byte("same")))
+	require.NoError(t, cache.Put("immich:2:preview", 2, "preview", []byte("same")))
+	usage, err := cache.Usage()
+	require.NoError(t, err)
+	assert.Equal(t, CacheUsage{Entries: 2, Files: 1, Bytes: 4, MaxBytes: defaultCacheMaxMB * 1024 * 1024}, *usage)
+
+	// The blob stays until its last key is removed
+	cache.Remove([]uint{1})
+	_, ok := cache.Get("synology:1:xl")
+	assert.False(t, ok)
+	data, ok := cache.Get("immich:2:preview")
+	require.True(t, ok)
+	assert.Equal(t, "same", string(data))
+	assert.FileExists(t, cache.blobPath(hashOf("same")))
+
+	cache.Remove([]uint{2})
+	assert.NoFileExists(t, cache.blobPath(hashOf("same")))
+}
+
+func TestCacheService_Evict(t *testing.T) {
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	require.NoError(t, settings.Set("cache_max_mb", "1"))
+	cache := NewCacheService(db, settings, t.TempDir())
+
+	blob := func(b byte) []byte { return bytes.Repeat([]byte{b}, 400*1024) }
+	put := func(key string, id uint, data []byte) {
+		require.NoError(t, cache.Put(key, id, "xl", data))
+		time.Sleep(2 * time.Millisecond) // Distinct access times
+	}
+	put("a", 1, blob('a'))
+	put("b", 2, blob('b'))
+	put("b2", 3, blob('b')) // Shares the blob of b, so it adds no bytes
+
+	// Reading a makes b the least recently used
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+	time.Sleep(2 * time.Millisecond)
+
+	// Over 1 MB: b goes first, then b2 with the shared blob
+	put("c", 4, blob('c'))
+	for key, want := range map[string]bool{"a": true, "b": false, "b2": false, "c": true} {
+		_, ok := cache.Get(key)
+		assert.Equal(t, want, ok, key)
+	}
+	assert.NoFileExists(t, cache.blobPath(hashOf(string(blob('b')))))
+	usage, err := cache.Usage()
+	require.NoError(t, err)
+	assert.Equal(t, int64(800*1024), usage.Bytes)
+}
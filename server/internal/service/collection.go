@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+)
+
+// collectionSources are the values accepted by "source" rules
+var collectionSources = map[string]bool{
+	"google": true, "synology": true, "telegram": true, "local": true,
+	"upload": true, "immich": true, "webdav": true, "email": true,
+}
+
+// collectionOps lists the operators supported by each rule field
+var collectionOps = map[string][]string{
+	"orientation": {"is", "is_not"},
+	"source":      {"is", "is_not"},
+	"tag":         {"has", "has_not"},
+	"album":       {"in", "not_in"},
+	"caption":     {"contains", "not_contains"},
+	"captured":    {"within", "before", "after"},
+}
+
+// durationPattern matches relative periods such as "2y", "6m", "3w" or "30d"
+var durationPattern = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// capturedColumn is when a photo was taken, or imported if that is unknown
+const capturedColumn = "COALESCE(images.captured_at, images.created_at)"
+
+// CollectionService manages smart collections, which select images across all
+// sources by rules such as "portrait AND tag:family AND captured within 2y"
+type CollectionService struct {
+	db *gorm.DB
+}
+
+func NewCollectionService(db *gorm.DB) *CollectionService {
+	return &CollectionService{db: db}
+}
+
+func (s *CollectionService) List() ([]model.Collection, error) {
+	var collections []model.Collection
+	if err := s.db.Order("name").Find(&collections).Error; err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (s *CollectionService) Get(id uint) (*model.Collection, error) {
+	var collection model.Collection
+	if err := s.db.First(&collection, id).Error; err != nil {
+		return nil, errors.New("collection not found")
+	}
+	return &collection, nil
+}
+
+// Create validates and stores a new collection
+func (s *CollectionService) Create(name, match string, rules []model.CollectionRule) (*model.Collection, error) {
+	collection := &model.Collection{Name: name, Match: match, Rules: rules}
+	if err := s.normalize(collection); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(collection).Error; err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// Update replaces the name and rules of a collection
+func (s *CollectionService) Update(id uint, name, match string, rules []model.CollectionRule) (*model.Collection, error) {
+	collection, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	collection.Name = name
+	collection.Match = match
+	collection.Rules = rules
+	if err := s.normalize(collection); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(collection).Error; err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *CollectionService) Delete(id uint) error {
+	result := s.db.Delete(&model.Collection{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("collection not found")
+	}
+	return nil
+}
+
+// normalize trims and validates a collection before it is saved
+func (s *CollectionService) normalize(c *model.Collection) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+
+	var count int64
+	s.db.Model(&model.Collection{}).Where("name = ? AND id != ?", c.Name, c.ID).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("a collection named %q already exists", c.Name)
+	}
+
+	c.Match = strings.ToLower(strings.TrimSpace(c.Match))
+	if c.Match == "" {
+		c.Match = "all"
+	}
+	if c.Match != "all" && c.Match != "any" {
+		return errors.New(`match must be "all" or "any"`)
+	}
+
+	if c.Rules == nil {
+		c.Rules = []model.CollectionRule{}
+	}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		r.Field = strings.ToLower(strings.TrimSpace(r.Field))
+		r.Op = strings.ToLower(strings.TrimSpace(r.Op))
+		r.Value = strings.TrimSpace(r.Value)
+		if _, _, err := ruleCondition(*r, time.Now()); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Scope returns a query scope selecting the images of a collection. Relative
+// periods ("captured within 2y") are evaluated at the time of the query.
+func (s *CollectionService) Scope(c *model.Collection) (func(*gorm.DB) *gorm.DB, error) {
+	now := time.Now()
+	conds := make([]string, 0, len(c.Rules))
+	var args []interface{}
+	for i, r := range c.Rules {
+		cond, condArgs, err := ruleCondition(r, now)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		conds = append(conds, "("+cond+")")
+		args = append(args, condArgs...)
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if len(conds) == 0 {
+			return db // No rules: every photo
+		}
+		joiner := " AND "
+		if c.Match == "any" {
+			joiner = " OR "
+		}
+		return db.Where(strings.Join(conds, joiner), args...)
+	}, nil
+}
+
+// ruleCondition translates a rule into a SQL condition on images
+func ruleCondition(r model.CollectionRule, now time.Time) (string, []interface{}, error) {
+	ops, ok := collectionOps[r.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown field %q", r.Field)
+	}
+	validOp := false
+	for _, op := range ops {
+		validOp = validOp || op == r.Op
+	}
+	if !validOp {
+		return "", nil, fmt.Errorf("%s does not support %q (use %s)", r.Field, r.Op, strings.Join(ops, ", "))
+	}
+	if r.Value == "" {
+		return "", nil, fmt.Errorf("%s needs a value", r.Field)
+	}
+
+	negate := r.Op == "is_not" || r.Op == "has_not" || r.Op == "not_in" || r.Op == "not_contains"
+	not := func(cond string) string {
+		if negate {
+			return "NOT (" + cond + ")"
+		}
+		return cond
+	}
+
+	switch r.Field {
+	case "orientation":
+		if r.Value != "landscape" && r.Value != "portrait" {
+			return "", nil, errors.New(`orientation must be "landscape" or "portrait"`)
+		}
+		return not("images.orientation = ?"), []interface{}{r.Value}, nil
+
+	case "source":
+		if !collectionSources[r.Value] {
+			return "", nil, fmt.Errorf("unknown source %q", r.Value)
+		}
+		return not("images.source = ?"), []interface{}{r.Value}, nil
+
+	case "tag":
+		return not("images.id IN (SELECT image_tags.image_id FROM image_tags JOIN tags ON tags.id = image_tags.tag_id WHERE tags.name = ?)"),
+			[]interface{}{strings.ToLower(r.Value)}, nil
+
+	case "album":
+		return not("images.id IN (SELECT image_albums.image_id FROM image_albums JOIN albums ON albums.id = image_albums.album_id WHERE albums.name = ?)"),
+			[]interface{}{r.Value}, nil
+
+	case "caption":
+		return not("instr(lower(images.caption), lower(?)) > 0"), []interface{}{r.Value}, nil
+	}
+
+	// captured
+	if r.Op == "within" {
+		since, err := periodStart(r.Value, now)
+		if err != nil {
+			return "", nil, err
+		}
+		return capturedColumn + " >= ?", []interface{}{since}, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", r.Value, time.Local)
+	if err != nil {
+		return "", nil, errors.New("captured date must be YYYY-MM-DD")
+	}
+	if r.Op == "before" {
+		return capturedColumn + " < ?", []interface{}{date}, nil
+	}
+	return capturedColumn + " >= ?", []interface{}{date.AddDate(0, 0, 1)}, nil
+}
+
+// periodStart returns the start of a relative period ending now, e.g. "2y"
+func periodStart(period string, now time.Time) (time.Time, error) {
+	m := durationPattern.FindStringSubmatch(strings.ToLower(period))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid period %q (e.g. 30d, 3w, 6m or 2y)", period)
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "m":
+		return now.AddDate(0, -n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newLibraryTest(t *testing.T) (*gorm.DB, *LibraryService, *CollectionService) {
+	db := setupTestDB(t)
+	return db, NewLibraryService(db), NewCollectionService(db)
+}
+
+// matching returns the captions of the images in a collection
+func matching(t *testing.T, db *gorm.DB, svc *CollectionService, c *model.Collection) []string {
+	scope, err := svc.Scope(c)
+	require.NoError(t, err)
+	var captions []string
+	require.NoError(t, db.Model(&model.Image{}).Scopes(scope).Order("id").Pluck("caption", &captions).Error)
+	return captions
+}
+
+func TestCollectionService_Scope(t *testing.T) {
+	db, library, collections := newLibraryTest(t)
+
+	recent := time.Now().AddDate(0, -3, 0)
+	old := time.Date(2015, 6, 1, 12, 0, 0, 0, time.Local)
+	images := []model.Image{
+		{Caption: "kids on the beach", Orientation: "portrait", Source: "upload", CapturedAt: &recent},
+		{Caption: "grandma", Orientation: "portrait", Source: "synology", CapturedAt: &old},
+		{Caption: "mountains", Orientation: "landscape", Source: "local", CapturedAt: &recent},
+		{Caption: "Beach sunset", Orientation: "portrait", Source: "email"}, // Captured date unknown: uses created_at
+	}
+	require.NoError(t, db.Create(&images).Error)
+	require.NoError(t, library.AddTags([]uint{images[0].ID, images[1].ID}, []string{"Family"}))
+	require.NoError(t, library.AddToAlbum([]uint{images[2].ID}, "Trips"))
+
+	albums, err := library.ListAlbums()
+	require.NoError(t, err)
+	require.Len(t, albums, 1)
+	assert.Equal(t, "Trips", albums[0].Name)
+	assert.Equal(t, int64(1), albums[0].PhotoCount)
+	tags, err := library.ListTags()
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "family", tags[0].Name)
+	assert.Equal(t, int64(2), tags[0].PhotoCount)
+
+	c, err := collections.Create("Family portraits", "", []model.CollectionRule{
+		{Field: "orientation", Op: "is", Value: "portrait"},
+		{Field: "tag", Op: "has", Value: "family"},
+		{Field: "captured", Op: "within", Value: "2y"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "all", c.Match)
+	assert.Equal(t, []string{"kids on the beach"}, matching(t, db, collections, c))
+
+	c.Match = "any"
+	c.Rules = []model.CollectionRule{
+		{Field: "album", Op: "in", Value: "Trips"},
+		{Field: "caption", Op: "contains", Value: "beach"},
+	}
+	assert.Equal(t, []string{"kids on the beach", "mountains", "Beach sunset"}, matching(t, db, collections, c))
+
+	c.Match = "all"
+	c.Rules = []model.CollectionRule{
+		{Field: "tag", Op: "has_not", Value: "family"},
+		{Field: "source", Op: "is_not", Value: "local"},
+	}
+	assert.Equal(t, []string{"Beach sunset"}, matching(t, db, collections, c))
+
+	c.Rules = []model.CollectionRule{{Field: "captured", Op: "before", Value: "2016-01-01"}}
+	assert.Equal(t, []string{"grandma"}, matching(t, db, collections, c))
+
+	c.Rules = nil
+	assert.Len(t, matching(t, db, collections, c), 4)
+}
+
+func TestCollectionService_Validation(t *testing.T) {
+	_, _, collections := newLibraryTest(t)
+
+	invalid := map[string]model.CollectionRule{
+		"unknown field":   {Field: "color", Op: "is", Value: "red"},
+		"unsupported op":  {Field: "tag", Op: "is", Value: "family"},
+		"missing value":   {Field: "tag", Op: "has"},
+		"bad orientation": {Field: "orientation", Op: "is", Value: "square"},
+		"unknown source":  {Field: "source", Op: "is", Value: "flickr"},
+		"bad period":      {Field: "captured", Op: "within", Value: "2 years"},
+		"bad date":        {Field: "captured", Op: "after", Value: "06/01/2020"},
+	}
+	for name, rule := range invalid {
+		_, err := collections.Create("Test", "all", []model.CollectionRule{rule})
+		assert.Error(t, err, name)
+	}
+
+	_, err := collections.Create("Test", "some", nil)
+	assert.Error(t, err)
+	_, err = collections.Create(" ", "all", nil)
+	assert.Error(t, err)
+
+	c, err := collections.Create("Test", "ANY", []model.CollectionRule{{Field: " Tag ", Op: "HAS", Value: " family "}})
+	require.NoError(t, err)
+	assert.Equal(t, "any", c.Match)
+	assert.Equal(t, model.CollectionRule{Field: "tag", Op: "has", Value: "family"}, c.Rules[0])
+
+	_, err = collections.Create("Test", "all", nil)
+	assert.ErrorContains(t, err, "already exists")
+	_, err = collections.Update(c.ID, "Test", "all", nil)
+	assert.NoError(t, err, "keeping the own name is not a conflict")
+}
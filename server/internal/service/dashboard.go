@@ -0,0 +1,530 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/pkg/homeassistant"
+	"github.com/aitjcize/photoframe-server/server/pkg/weather"
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// DashboardTemplate describes an information screen rendered instead of a photo.
+// Widget positions and sizes are relative to the canvas (0..1), so the same
+// template works for landscape and portrait panels of any resolution.
+type DashboardTemplate struct {
+	Background string            `json:"background"`
+	Timezone   string            `json:"timezone,omitempty"`
+	Widgets    []DashboardWidget `json:"widgets"`
+}
+
+type DashboardWidget struct {
+	Type       string   `json:"type"` // clock, date, text, icon, weather, forecast, quote, ha_sensor, rect
+	X          float64  `json:"x"`
+	Y          float64  `json:"y"`
+	W          float64  `json:"w"`
+	H          float64  `json:"h"`
+	Align      string   `json:"align,omitempty"`     // left, center (default), right
+	FontSize   float64  `json:"font_size,omitempty"` // Relative to canvas height, 0 = fit to box
+	Color      string   `json:"color,omitempty"`
+	Background string   `json:"background,omitempty"`
+	Format     string   `json:"format,omitempty"` // Go time layout for clock/date
+	Text       string   `json:"text,omitempty"`
+	Icon       string   `json:"icon,omitempty"` // Material Symbols codepoint, e.g. "e88a"
+	EntityID   string   `json:"entity_id,omitempty"`
+	Label      string   `json:"label,omitempty"`
+	Lat        float64  `json:"lat,omitempty"`
+	Lon        float64  `json:"lon,omitempty"`
+	Days       int      `json:"days,omitempty"`
+	Quotes     []string `json:"quotes,omitempty"`
+}
+
+type DashboardOptions struct {
+	WeatherLat float64
+	WeatherLon float64
+}
+
+type DashboardService struct {
+	weatherClient *weather.Client
+	settings      *SettingsService
+}
+
+func NewDashboardService(w *weather.Client, s *SettingsService) *DashboardService {
+	return &DashboardService{weatherClient: w, settings: s}
+}
+
+// The 6 colors the e-paper panel can show natively. Dashboards are drawn with
+// these only so text and shapes stay crisp instead of being dithered.
+var dashboardPalette = []struct {
+	Name  string
+	Color color.RGBA
+}{
+	{"black", color.RGBA{0, 0, 0, 255}},
+	{"white", color.RGBA{255, 255, 255, 255}},
+	{"yellow", color.RGBA{255, 255, 0, 255}},
+	{"red", color.RGBA{255, 0, 0, 255}},
+	{"blue", color.RGBA{0, 0, 255, 255}},
+	{"green", color.RGBA{0, 255, 0, 255}},
+}
+
+var defaultQuotes = []string{
+	"The best way to predict the future is to invent it. — Alan Kay",
+	"Simplicity is prerequisite for reliability. — Edsger W. Dijkstra",
+	"Well done is better than well said. — Benjamin Franklin",
+	"In the middle of difficulty lies opportunity. — Albert Einstein",
+	"What we think, we become. — Buddha",
+	"Act as if what you do makes a difference. It does. — William James",
+	"Happiness depends upon ourselves. — Aristotle",
+	"It always seems impossible until it's done. — Nelson Mandela",
+}
+
+func defaultDashboardTemplate() *DashboardTemplate {
+	return &DashboardTemplate{
+		Background: "white",
+		Widgets: []DashboardWidget{
+			{Type: "clock", X: 0.05, Y: 0.05, W: 0.55, H: 0.4, Align: "left", Color: "black"},
+			{Type: "date", X: 0.05, Y: 0.45, W: 0.55, H: 0.12, Align: "left", Color: "black"},
+			{Type: "weather", X: 0.62, Y: 0.08, W: 0.33, H: 0.3, Color: "black"},
+			{Type: "forecast", X: 0.62, Y: 0.42, W: 0.33, H: 0.25, Color: "black", Days: 3},
+			{Type: "rect", X: 0.05, Y: 0.7, W: 0.9, H: 0.006, Background: "black"},
+			{Type: "quote", X: 0.05, Y: 0.74, W: 0.9, H: 0.22, Color: "blue"},
+		},
+	}
+}
+
+// GetTemplate returns the configured dashboard template, or the built-in default
+func (s *DashboardService) GetTemplate() (*DashboardTemplate, error) {
+	raw, _ := s.settings.Get("dashboard_template")
+	if raw == "" {
+		return defaultDashboardTemplate(), nil
+	}
+
+	var tpl DashboardTemplate
+	if err := json.Unmarshal([]byte(raw), &tpl); err != nil {
+		return nil, fmt.Errorf("invalid dashboard template: %w", err)
+	}
+	return &tpl, nil
+}
+
+// SaveTemplate validates and stores the dashboard template
+func (s *DashboardService) SaveTemplate(tpl *DashboardTemplate) error {
+	if tpl.Timezone != "" {
+		if _, err := time.LoadLocation(tpl.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %s", tpl.Timezone)
+		}
+	}
+	for i, w := range tpl.Widgets {
+		switch w.Type {
+		case "clock", "date", "text", "icon", "weather", "forecast", "quote", "ha_sensor", "rect":
+		default:
+			return fmt.Errorf("widget %d: unknown type %q", i, w.Type)
+		}
+		if w.Type == "ha_sensor" && w.EntityID == "" {
+			return fmt.Errorf("widget %d: entity_id is required", i)
+		}
+	}
+
+	data, err := json.Marshal(tpl)
+	if err != nil {
+		return err
+	}
+	return s.settings.Set("dashboard_template", string(data))
+}
+
+// Render draws the dashboard at the given logical resolution
+func (s *DashboardService) Render(width, height int, opts DashboardOptions) (image.Image, error) {
+	tpl, err := s.GetTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.Local
+	if tpl.Timezone != "" {
+		if l, err := time.LoadLocation(tpl.Timezone); err == nil {
+			loc = l
+		}
+	}
+	now := time.Now().In(loc)
+
+	dc := gg.NewContext(width, height)
+	dc.SetColor(paletteColor(tpl.Background, "white"))
+	dc.Clear()
+
+	for _, w := range tpl.Widgets {
+		if err := s.drawWidget(dc, w, now, opts); err != nil {
+			// A single broken widget (e.g. weather API down) should not blank the screen
+			log.Printf("Dashboard: failed to draw %s widget: %v", w.Type, err)
+		}
+	}
+
+	return quantizeToPalette(dc.Image()), nil
+}
+
+type widgetBox struct {
+	X, Y, W, H float64
+}
+
+func (s *DashboardService) drawWidget(dc *gg.Context, w DashboardWidget, now time.Time, opts DashboardOptions) error {
+	cw, ch := float64(dc.Width()), float64(dc.Height())
+	box := widgetBox{X: w.X * cw, Y: w.Y * ch, W: w.W * cw, H: w.H * ch}
+	if box.W <= 0 {
+		box.W = cw - box.X
+	}
+	if box.H <= 0 {
+		box.H = 0.15 * ch
+	}
+
+	if w.Background != "" {
+		dc.SetColor(paletteColor(w.Background, "white"))
+		dc.DrawRectangle(box.X, box.Y, box.W, box.H)
+		dc.Fill()
+	}
+
+	fg := paletteColor(w.Color, "black")
+	fontSize := w.FontSize * ch
+
+	switch w.Type {
+	case "rect":
+		return nil
+	case "clock":
+		layout := w.Format
+		if layout == "" {
+			layout = "15:04"
+		}
+		return drawText(dc, now.Format(layout), box, textFontPaths, fontSize, w.Align, fg)
+	case "date":
+		layout := w.Format
+		if layout == "" {
+			layout = "Monday, January 2"
+		}
+		return drawText(dc, now.Format(layout), box, textFontPaths, fontSize, w.Align, fg)
+	case "text":
+		return drawText(dc, w.Text, box, textFontPaths, fontSize, w.Align, fg)
+	case "icon":
+		icon, err := parseIcon(w.Icon)
+		if err != nil {
+			return err
+		}
+		return drawText(dc, icon, box, iconFontPaths, fontSize, w.Align, fg)
+	case "quote":
+		quotes := w.Quotes
+		if len(quotes) == 0 {
+			quotes = defaultQuotes
+		}
+		quote := quotes[now.YearDay()%len(quotes)]
+		return drawWrappedText(dc, quote, box, textFontPaths, fontSize, w.Align, fg)
+	case "weather":
+		return s.drawWeather(dc, w, box, opts, fg)
+	case "forecast":
+		return s.drawForecast(dc, w, box, opts, fg)
+	case "ha_sensor":
+		return s.drawSensor(dc, w, box, fontSize, fg)
+	}
+
+	return fmt.Errorf("unknown widget type: %s", w.Type)
+}
+
+func (s *DashboardService) drawWeather(dc *gg.Context, w DashboardWidget, box widgetBox, opts DashboardOptions, fg color.Color) error {
+	lat, lon := widgetLocation(w, opts)
+	if lat == 0 && lon == 0 {
+		return errors.New("no weather location configured")
+	}
+
+	current, err := s.weatherClient.GetWeather(fmt.Sprintf("%f", lat), fmt.Sprintf("%f", lon))
+	if err != nil {
+		return err
+	}
+
+	// Icon on the left 40%, temperature and condition on the right
+	iconBox := widgetBox{X: box.X, Y: box.Y, W: box.W * 0.4, H: box.H}
+	tempBox := widgetBox{X: box.X + box.W*0.4, Y: box.Y, W: box.W * 0.6, H: box.H * 0.6}
+	descBox := widgetBox{X: box.X + box.W*0.4, Y: box.Y + box.H*0.6, W: box.W * 0.6, H: box.H * 0.4}
+
+	if err := drawText(dc, current.Icon(), iconBox, iconFontPaths, 0, "center", fg); err != nil {
+		log.Printf("Dashboard: weather icon unavailable: %v", err)
+	}
+	if err := drawText(dc, fmt.Sprintf("%.0f°C", current.Temperature), tempBox, textFontPaths, 0, "center", fg); err != nil {
+		return err
+	}
+	return drawText(dc, fmt.Sprintf("%s  %d%%", current.Description(), current.Humidity), descBox, textFontPaths, 0, "center", fg)
+}
+
+func (s *DashboardService) drawForecast(dc *gg.Context, w DashboardWidget, box widgetBox, opts DashboardOptions, fg color.Color) error {
+	lat, lon := widgetLocation(w, opts)
+	if lat == 0 && lon == 0 {
+		return errors.New("no weather location configured")
+	}
+
+	days := w.Days
+	if days <= 0 {
+		days = 3
+	}
+	forecast, err := s.weatherClient.GetForecast(fmt.Sprintf("%f", lat), fmt.Sprintf("%f", lon), days)
+	if err != nil {
+		return err
+	}
+	if len(forecast) == 0 {
+		return errors.New("empty forecast")
+	}
+
+	colW := box.W / float64(len(forecast))
+	for i, day := range forecast {
+		x := box.X + float64(i)*colW
+		nameBox := widgetBox{X: x, Y: box.Y, W: colW, H: box.H * 0.25}
+		iconBox := widgetBox{X: x, Y: box.Y + box.H*0.25, W: colW, H: box.H * 0.45}
+		tempBox := widgetBox{X: x, Y: box.Y + box.H*0.7, W: colW, H: box.H * 0.3}
+
+		if err := drawText(dc, day.Date.Format("Mon"), nameBox, textFontPaths, 0, "center", fg); err != nil {
+			return err
+		}
+		if err := drawText(dc, day.Icon(), iconBox, iconFontPaths, 0, "center", fg); err != nil {
+			log.Printf("Dashboard: forecast icon unavailable: %v", err)
+		}
+		temps := fmt.Sprintf("%.0f° / %.0f°", day.TemperatureMax, day.TemperatureMin)
+		if err := drawText(dc, temps, tempBox, textFontPaths, 0, "center", fg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DashboardService) drawSensor(dc *gg.Context, w DashboardWidget, box widgetBox, fontSize float64, fg color.Color) error {
+	client, err := s.homeAssistantClient()
+	if err != nil {
+		return err
+	}
+
+	state, err := client.GetState(w.EntityID)
+	if err != nil {
+		return err
+	}
+
+	label := w.Label
+	if label == "" {
+		label = state.FriendlyName()
+	}
+	value := state.State
+	if unit := state.Unit(); unit != "" {
+		value += " " + unit
+	}
+
+	labelBox := widgetBox{X: box.X, Y: box.Y, W: box.W, H: box.H * 0.35}
+	valueBox := widgetBox{X: box.X, Y: box.Y + box.H*0.35, W: box.W, H: box.H * 0.65}
+	if err := drawText(dc, label, labelBox, textFontPaths, 0, w.Align, fg); err != nil {
+		return err
+	}
+	return drawText(dc, value, valueBox, textFontPaths, fontSize, w.Align, fg)
+}
+
+// homeAssistantClient builds a client from settings. When running as a Home
+// Assistant add-on, the Supervisor proxy is used if nothing is configured.
+func (s *DashboardService) homeAssistantClient() (*homeassistant.Client, error) {
+	baseURL, _ := s.settings.Get("homeassistant_url")
+	token, _ := s.settings.Get("homeassistant_token")
+
+	if baseURL == "" || token == "" {
+		if supervisorToken := os.Getenv("SUPERVISOR_TOKEN"); supervisorToken != "" {
+			return homeassistant.NewClient("http://supervisor/core", supervisorToken), nil
+		}
+		return nil, errors.New("home assistant not configured")
+	}
+	return homeassistant.NewClient(baseURL, token), nil
+}
+
+func widgetLocation(w DashboardWidget, opts DashboardOptions) (float64, float64) {
+	if w.Lat != 0 || w.Lon != 0 {
+		return w.Lat, w.Lon
+	}
+	return opts.WeatherLat, opts.WeatherLon
+}
+
+// drawText draws a single line of text inside the box, vertically centered.
+// With size 0 the text is sized to fill the box height and shrunk to fit its width.
+func drawText(dc *gg.Context, text string, box widgetBox, fontPaths []string, size float64, align string, fg color.Color) error {
+	if text == "" {
+		return nil
+	}
+	if size <= 0 {
+		size = box.H * 0.7
+	}
+
+	face, err := loadFontFace(fontPaths, size)
+	if err != nil {
+		return err
+	}
+	dc.SetFontFace(face)
+
+	if tw, _ := dc.MeasureString(text); tw > box.W && tw > 0 {
+		face, err = loadFontFace(fontPaths, size*box.W/tw)
+		if err != nil {
+			return err
+		}
+		dc.SetFontFace(face)
+	}
+
+	x, ax := anchorX(box, align)
+	dc.SetColor(fg)
+	dc.DrawStringAnchored(text, x, box.Y+box.H/2, ax, 0.35)
+	return nil
+}
+
+// drawWrappedText draws text wrapped to the box width, shrinking the font until it fits
+func drawWrappedText(dc *gg.Context, text string, box widgetBox, fontPaths []string, size float64, align string, fg color.Color) error {
+	if size <= 0 {
+		size = box.H * 0.3
+	}
+
+	for {
+		face, err := loadFontFace(fontPaths, size)
+		if err != nil {
+			return err
+		}
+		dc.SetFontFace(face)
+		lines := dc.WordWrap(text, box.W)
+		fits := float64(len(lines))*dc.FontHeight()*1.4 <= box.H
+		for _, line := range lines {
+			// A single word longer than the box (e.g. a file path) is not wrapped
+			if lw, _ := dc.MeasureString(line); lw > box.W {
+				fits = false
+			}
+		}
+		if fits || size <= 8 {
+			break
+		}
+		size *= 0.9
+	}
+
+	x, ax := anchorX(box, align)
+	textAlign := gg.AlignCenter
+	switch align {
+	case "left":
+		textAlign = gg.AlignLeft
+	case "right":
+		textAlign = gg.AlignRight
+	}
+
+	dc.SetColor(fg)
+	dc.DrawStringWrapped(text, x, box.Y+box.H/2, ax, 0.5, box.W, 1.4, textAlign)
+	return nil
+}
+
+func anchorX(box widgetBox, align string) (float64, float64) {
+	switch align {
+	case "left":
+		return box.X, 0
+	case "right":
+		return box.X + box.W, 1
+	default:
+		return box.X + box.W/2, 0.5
+	}
+}
+
+// parseIcon turns a Material Symbols codepoint ("e88a" or "0xe88a") into its glyph
+func parseIcon(code string) (string, error) {
+	code = strings.TrimPrefix(strings.ToLower(code), "0x")
+	r, err := strconv.ParseUint(code, 16, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid icon codepoint: %s", code)
+	}
+	return string(rune(r)), nil
+}
+
+// builtinFontPath selects the Go font compiled into the binary, for text that
+// must render even when no system font is installed
+const builtinFontPath = "builtin:goregular"
+
+var (
+	fontCacheMu sync.Mutex
+	fontCache   = make(map[string]*truetype.Font)
+)
+
+// loadFontFace returns a face for the first loadable font in paths. Parsed fonts
+// are cached since the Material Symbols font is several megabytes.
+func loadFontFace(paths []string, size float64) (font.Face, error) {
+	fontCacheMu.Lock()
+	defer fontCacheMu.Unlock()
+
+	for _, path := range paths {
+		f, ok := fontCache[path]
+		if !ok {
+			data, err := os.ReadFile(path)
+			if path == builtinFontPath {
+				data, err = goregular.TTF, nil
+			}
+			if err != nil {
+				continue
+			}
+			f, err = truetype.Parse(data)
+			if err != nil {
+				continue
+			}
+			fontCache[path] = f
+		}
+		return truetype.NewFace(f, &truetype.Options{Size: size}), nil
+	}
+	return nil, errors.New("no usable font found")
+}
+
+// paletteColor maps a color name or #rrggbb value to the nearest palette color
+func paletteColor(value, fallback string) color.RGBA {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		value = fallback
+	}
+
+	for _, p := range dashboardPalette {
+		if p.Name == value {
+			return p.Color
+		}
+	}
+
+	if strings.HasPrefix(value, "#") && len(value) == 7 {
+		if v, err := strconv.ParseUint(value[1:], 16, 32); err == nil {
+			return nearestPaletteColor(uint8(v>>16), uint8(v>>8), uint8(v))
+		}
+	}
+
+	return paletteColor(fallback, "black")
+}
+
+func nearestPaletteColor(r, g, b uint8) color.RGBA {
+	best := dashboardPalette[0].Color
+	bestDist := -1
+	for _, p := range dashboardPalette {
+		dr := int(r) - int(p.Color.R)
+		dg := int(g) - int(p.Color.G)
+		db := int(b) - int(p.Color.B)
+		dist := dr*dr + dg*dg + db*db
+		if bestDist < 0 || dist < bestDist {
+			best = p.Color
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// quantizeToPalette snaps every pixel (including anti-aliased text edges) to
+// the nearest palette color so the e-paper conversion does not dither the text.
+func quantizeToPalette(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			dst.SetRGBA(x, y, nearestPaletteColor(uint8(r>>8), uint8(g>>8), uint8(bl>>8)))
+		}
+	}
+	return dst
+}
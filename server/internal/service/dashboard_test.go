@@ -0,0 +1,68 @@
+package service
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardService_RenderNativeSize(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewDashboardService(nil, NewSettingsService(db))
+	require.NoError(t, svc.SaveTemplate(&DashboardTemplate{
+		Background: "#fafafa",
+		Widgets: []DashboardWidget{
+			{Type: "clock", X: 0.05, Y: 0.05, W: 0.9, H: 0.4, Color: "black"},
+			{Type: "text", X: 0.05, Y: 0.5, W: 0.9, H: 0.1, Text: "Hello", Color: "red"},
+			{Type: "rect", X: 0.5, Y: 0.8, W: 0.25, H: 0.1, Background: "blue"},
+		},
+	}))
+
+	// Landscape and portrait 7.3" panels
+	for _, size := range [][2]int{{800, 480}, {480, 800}} {
+		img, err := svc.Render(size[0], size[1], DashboardOptions{})
+		require.NoError(t, err)
+		b := img.Bounds()
+		assert.Equal(t, size[0], b.Dx())
+		assert.Equal(t, size[1], b.Dy())
+
+		// The background snaps to white and the rect is drawn where the template puts it
+		assert.Equal(t, color.RGBA{255, 255, 255, 255}, color.RGBAModel.Convert(img.At(0, 0)))
+		rx, ry := int(0.625*float64(size[0])), int(0.85*float64(size[1]))
+		assert.Equal(t, color.RGBA{0, 0, 255, 255}, color.RGBAModel.Convert(img.At(rx, ry)))
+
+		// Every pixel, including anti-aliased text edges, is a palette color
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				if c != nearestPaletteColor(c.R, c.G, c.B) {
+					t.Fatalf("pixel (%d,%d) = %v is not a palette color", x, y, c)
+				}
+			}
+		}
+	}
+}
+
+func TestPaletteColor(t *testing.T) {
+	tests := []struct {
+		value, fallback string
+		want            color.RGBA
+	}{
+		{"red", "white", color.RGBA{255, 0, 0, 255}},
+		{" Blue ", "white", color.RGBA{0, 0, 255, 255}},
+		{"", "white", color.RGBA{255, 255, 255, 255}},
+		{"#FF0000", "white", color.RGBA{255, 0, 0, 255}},
+		{"#202020", "white", color.RGBA{0, 0, 0, 255}},
+		{"#e0d010", "white", color.RGBA{255, 255, 0, 255}},
+		{"#10c020", "white", color.RGBA{0, 255, 0, 255}},
+		{"purple", "green", color.RGBA{0, 255, 0, 255}},
+		{"#12345", "red", color.RGBA{255, 0, 0, 255}},
+		{"#zzzzzz", "blue", color.RGBA{0, 0, 255, 255}},
+		{"purple", "pink", color.RGBA{0, 0, 0, 255}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, paletteColor(tt.value, tt.fallback), "paletteColor(%q, %q)", tt.value, tt.fallback)
+	}
+}
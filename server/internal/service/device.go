@@ -6,6 +6,9 @@ import (
 	"image"
 	"log"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/aitjcize/photoframe-server/server/internal/model"
 	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
@@ -13,21 +16,35 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// DefaultStaleHours is how long a device may go without requesting an
+	// image before it is reported, unless device_stale_hours says otherwise
+	DefaultStaleHours = 24
+
+	staleCheckInterval = 10 * time.Minute
+)
+
 type DeviceService struct {
 	db        *gorm.DB
 	settings  *SettingsService
 	processor *ProcessorService
 	overlay   *OverlayService
 	pfClient  *photoframe.Client
+	events    *EventBus
+
+	mu    sync.Mutex // Guards stale
+	stale map[uint]bool
 }
 
-func NewDeviceService(db *gorm.DB, settings *SettingsService, processor *ProcessorService, overlay *OverlayService, pfClient *photoframe.Client) *DeviceService {
+func NewDeviceService(db *gorm.DB, settings *SettingsService, processor *ProcessorService, overlay *OverlayService, pfClient *photoframe.Client, events *EventBus) *DeviceService {
 	return &DeviceService{
 		db:        db,
 		settings:  settings,
 		processor: processor,
 		overlay:   overlay,
 		pfClient:  pfClient,
+		events:    events,
+		stale:     make(map[uint]bool),
 	}
 }
 
@@ -88,6 +105,7 @@ func (s *DeviceService) AddDevice(host string, useDeviceParameter, enableCollage
 	if err := s.db.Create(device).Error; err != nil {
 		return nil, err
 	}
+	s.events.Publish(EventDeviceUpdated, *device)
 	return device, nil
 }
 
@@ -149,12 +167,122 @@ func (s *DeviceService) UpdateDevice(id uint, name, host string, width, height i
 	if err := s.db.Save(&device).Error; err != nil {
 		return nil, err
 	}
+	s.events.Publish(EventDeviceUpdated, device)
 	return &device, nil
 }
 
 func (s *DeviceService) DeleteDevice(id uint) error {
-	result := s.db.Delete(&model.Device{}, id)
-	return result.Error
+	if err := s.db.Delete(&model.Device{}, id).Error; err != nil {
+		return err
+	}
+	s.events.Publish(EventDeviceDeleted, map[string]interface{}{"device_id": id})
+	return nil
+}
+
+// SetCollage enables or disables the smart collage for a device
+func (s *DeviceService) SetCollage(id uint, enabled bool) (*model.Device, error) {
+	var device model.Device
+	if err := s.db.First(&device, id).Error; err != nil {
+		return nil, errors.New("device not found")
+	}
+	if err := s.db.Model(&device).Update("enable_collage", enabled).Error; err != nil {
+		return nil, err
+	}
+	s.events.Publish(EventDeviceUpdated, device)
+	return &device, nil
+}
+
+// DeviceSources are the photo sources a device can be set to, besides all
+// sources (empty)
+var DeviceSources = []string{"google", "synology", "immich", "telegram", "local", "upload", "webdav", "email"}
+
+// SetSource sets the photo source of a device's "next photo" action, empty
+// for all sources
+func (s *DeviceService) SetSource(id uint, source string) (*model.Device, error) {
+	valid := source == ""
+	for _, src := range DeviceSources {
+		valid = valid || src == source
+	}
+	if !valid {
+		return nil, fmt.Errorf("unknown source %q", source)
+	}
+
+	var device model.Device
+	if err := s.db.First(&device, id).Error; err != nil {
+		return nil, errors.New("device not found")
+	}
+	if err := s.db.Model(&device).Update("source", source).Error; err != nil {
+		return nil, err
+	}
+	s.events.Publish(EventDeviceUpdated, device)
+	return &device, nil
+}
+
+// IsOnline reports whether a device is currently reachable. Frames sleep
+// between refreshes, so offline is the normal state for battery powered ones.
+func (s *DeviceService) IsOnline(device *model.Device) bool {
+	return s.pfClient.Ping(device.Host) == nil
+}
+
+// Seen records that a device requested an image
+func (s *DeviceService) Seen(device *model.Device) {
+	now := time.Now()
+	device.LastSeenAt = &now
+	if err := s.db.Model(&model.Device{}).Where("id = ?", device.ID).UpdateColumn("last_seen_at", now).Error; err != nil {
+		log.Printf("Failed to record last seen time of %s: %v", device.Name, err)
+	}
+	s.events.Publish(EventDeviceSeen, map[string]interface{}{"device_id": device.ID, "device": device.Name, "last_seen_at": now})
+}
+
+// StaleAfter returns how long a device may go without requesting an image
+// before it is reported as stale, 0 if disabled
+func (s *DeviceService) StaleAfter() time.Duration {
+	val, _ := s.settings.Get("device_stale_hours")
+	hours, err := strconv.ParseFloat(val, 64)
+	if err != nil || hours < 0 {
+		hours = DefaultStaleHours
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// Start watches for devices that stopped requesting images
+func (s *DeviceService) Start() {
+	go func() {
+		ticker := time.NewTicker(staleCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.checkStale(time.Now())
+		}
+	}()
+}
+
+// checkStale publishes a device.stale event for devices not seen for the stale
+// period. Each device is reported once until it is seen again (and again
+// after a restart of the server). Devices never seen are skipped.
+func (s *DeviceService) checkStale(now time.Time) {
+	after := s.StaleAfter()
+	if after <= 0 {
+		return
+	}
+	var devices []model.Device
+	if err := s.db.Where("last_seen_at IS NOT NULL").Find(&devices).Error; err != nil {
+		log.Printf("Failed to check for stale devices: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, device := range devices {
+		if now.Sub(*device.LastSeenAt) < after {
+			delete(s.stale, device.ID)
+			continue
+		}
+		if !s.stale[device.ID] {
+			s.stale[device.ID] = true
+			log.Printf("Device %s has not been seen since %s", device.Name, device.LastSeenAt.Format(time.RFC3339))
+			s.events.Publish(EventDeviceStale, map[string]interface{}{"device_id": device.ID, "device": device.Name, "last_seen_at": *device.LastSeenAt})
+		}
+	}
 }
 
 // --- Push Logic ---
@@ -203,9 +331,48 @@ func (s *DeviceService) PushToDevice(deviceID uint, imagePath string) error {
 	return nil
 }
 
+// pushPhoto shows a gallery photo on a device and records the display.
+// Synology and Immich photos are downloaded first.
+func pushPhoto(devices *DeviceService, remote *RemotePhotoService, history *HistoryService, deviceID uint, item model.Image) error {
+	imagePath := item.FilePath
+	if IsRemoteSource(item.Source) {
+		data, err := remote.Fetch(item, VariantDisplay)
+		if err != nil {
+			return fmt.Errorf("failed to download %s photo: %w", item.Source, err)
+		}
+		tmp, err := os.CreateTemp("", item.Source+"_push_*.jpg")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(data)
+		tmp.Close()
+		if err != nil {
+			return err
+		}
+		imagePath = tmp.Name()
+	}
+
+	if err := devices.PushToDevice(deviceID, imagePath); err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	return history.Record(deviceID, item.ID)
+}
+
 // PushToHost processes an image file and pushes it to a target host
 // This encapsulates the logic previously in Telegram bot
 func (s *DeviceService) PushToHost(device *model.Device, imagePath string, extraOpts map[string]string) error {
+	data := map[string]interface{}{"device_id": device.ID, "device": device.Name}
+	s.events.Publish(EventPushStarted, data)
+	if err := s.pushToHost(device, imagePath, extraOpts); err != nil {
+		s.events.Publish(EventPushFailed, map[string]interface{}{"device_id": device.ID, "device": device.Name, "error": err.Error()})
+		return err
+	}
+	s.events.Publish(EventPushSucceeded, data)
+	return nil
+}
+
+func (s *DeviceService) pushToHost(device *model.Device, imagePath string, extraOpts map[string]string) error {
 	// 1. Validate dimensions
 	nativeW, nativeH := device.Width, device.Height
 	if nativeW == 0 || nativeH == 0 {
@@ -221,7 +388,7 @@ func (s *DeviceService) PushToHost(device *model.Device, imagePath string, extra
 	defer f.Close()
 
 	// 3. Decode
-	srcImg, _, err := image.Decode(f)
+	srcImg, _, err := imageops.Decode(f)
 	if err != nil {
 		return fmt.Errorf("failed to decode image: %w", err)
 	}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bytes"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultDuplicateThreshold is the largest hash distance (in bits) at which
+	// two photos count as near-duplicates, unless duplicate_threshold is set
+	DefaultDuplicateThreshold = 6
+
+	// unhashable marks photos whose hash could not be computed
+	unhashable = "-"
+
+	// Photos hashed per batch and pause between backfill runs
+	hashBatchSize    = 200
+	backfillInterval = 10 * time.Minute
+)
+
+// DuplicateService detects the same photo arriving from several sources by
+// comparing perceptual hashes (see imageops.DHash). Photos imported from a file
+// are hashed on import, remote (Synology/Immich) and older photos in the
+// background from their thumbnails.
+type DuplicateService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	remote   *RemotePhotoService
+
+	backfillMu sync.Mutex // Serializes backfill runs
+}
+
+func NewDuplicateService(db *gorm.DB, settings *SettingsService, remote *RemotePhotoService) *DuplicateService {
+	return &DuplicateService{
+		db:       db,
+		settings: settings,
+		remote:   remote,
+	}
+}
+
+// Threshold returns the largest hash distance of near-duplicates
+func (s *DuplicateService) Threshold() int {
+	val, _ := s.settings.Get("duplicate_threshold")
+	if t, err := strconv.Atoi(val); err == nil && t >= 0 && t <= 32 {
+		return t
+	}
+	return DefaultDuplicateThreshold
+}
+
+// hashPhoto hashes an image file for images.phash
+func hashPhoto(path string) string {
+	hash, err := imageops.HashFile(path)
+	if err != nil {
+		return unhashable
+	}
+	return hash
+}
+
+// Check hashes a photo about to be imported. If duplicate_auto_skip is
+// enabled, it also returns an existing near-duplicate, so the import can be
+// skipped. A nil service only hashes.
+func (s *DuplicateService) Check(path string) (hash string, duplicate *model.Image) {
+	hash = hashPhoto(path)
+	if s == nil || hash == unhashable {
+		return hash, nil
+	}
+	if autoSkip, _ := s.settings.Get("duplicate_auto_skip"); autoSkip != "true" {
+		return hash, nil
+	}
+	return hash, s.FindSimilar(hash, s.Threshold())
+}
+
+type hashedImage struct {
+	ID    uint
+	PHash string `gorm:"column:phash"`
+}
+
+func (s *DuplicateService) hashed() ([]hashedImage, error) {
+	var rows []hashedImage
+	err := s.db.Model(&model.Image{}).
+		Where("phash <> '' AND phash <> ?", unhashable).
+		Select("id, phash").Scan(&rows).Error
+	return rows, err
+}
+
+// FindSimilar returns a photo whose hash is within threshold bits of hash
+func (s *DuplicateService) FindSimilar(hash string, threshold int) *model.Image {
+	rows, err := s.hashed()
+	if err != nil {
+		log.Printf("Duplicates: failed to load hashes: %v", err)
+		return nil
+	}
+	for _, row := range rows {
+		if d, ok := imageops.HashDistance(hash, row.PHash); ok && d <= threshold {
+			var item model.Image
+			if err := s.db.First(&item, row.ID).Error; err == nil {
+				return &item
+			}
+		}
+	}
+	return nil
+}
+
+// DuplicateGroup is a set of photos that are near-duplicates of each other,
+// oldest first
+type DuplicateGroup struct {
+	Photos   []model.Image `json:"photos"`
+	Distance int           `json:"distance"` // Largest hash distance to the first photo
+}
+
+// Report groups near-duplicate photos. Groups are linked transitively: if A
+// is close to B and B to C, all three are in one group.
+func (s *DuplicateService) Report(threshold int) ([]DuplicateGroup, error) {
+	rows, err := s.hashed()
+	if err != nil {
+		return nil, err
+	}
+
+	// Identical hashes first, then compare the distinct ones pairwise
+	byHash := make(map[string][]uint)
+	var hashes []string
+	for _, row := range rows {
+		if _, ok := byHash[row.PHash]; !ok {
+			hashes = append(hashes, row.PHash)
+		}
+		byHash[row.PHash] = append(byHash[row.PHash], row.ID)
+	}
+
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if d, ok := imageops.HashDistance(hashes[i], hashes[j]); ok && d <= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]uint)
+	for i, hash := range hashes {
+		root := find(i)
+		members[root] = append(members[root], byHash[hash]...)
+	}
+
+	groups := []DuplicateGroup{}
+	for _, ids := range members {
+		if len(ids) < 2 {
+			continue
+		}
+		var photos []model.Image
+		if err := s.db.Order("created_at, id").Find(&photos, ids).Error; err != nil {
+			return nil, err
+		}
+		if len(photos) < 2 {
+			continue
+		}
+		group := DuplicateGroup{Photos: photos}
+		for _, p := range photos[1:] {
+			if d, ok := imageops.HashDistance(photos[0].PHash, p.PHash); ok && d > group.Distance {
+				group.Distance = d
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Photos) != len(groups[j].Photos) {
+			return len(groups[i].Photos) > len(groups[j].Photos)
+		}
+		return groups[i].Photos[0].ID < groups[j].Photos[0].ID
+	})
+	return groups, nil
+}
+
+// Unhashed returns the number of photos still waiting for a hash
+func (s *DuplicateService) Unhashed() (int64, error) {
+	var count int64
+	err := s.db.Model(&model.Image{}).Where("phash = '' OR phash IS NULL").Count(&count).Error
+	return count, err
+}
+
+// Start hashes photos without a hash now and then periodically, e.g. the
+// ones added by a Synology or Immich sync
+func (s *DuplicateService) Start() {
+	go func() {
+		for {
+			s.Backfill()
+			time.Sleep(backfillInterval)
+		}
+	}()
+}
+
+// Backfill hashes all photos that don't have a hash yet and returns how many
+// were hashed
+func (s *DuplicateService) Backfill() int {
+	s.backfillMu.Lock()
+	defer s.backfillMu.Unlock()
+
+	total := 0
+	for {
+		var items []model.Image
+		if err := s.db.Where("phash = '' OR phash IS NULL").Order("id").Limit(hashBatchSize).Find(&items).Error; err != nil {
+			log.Printf("Duplicates: failed to load photos to hash: %v", err)
+			return total
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		for _, item := range items {
+			hash, err := s.hashImage(item)
+			if err != nil {
+				log.Printf("Duplicates: failed to hash photo %d: %v", item.ID, err)
+				hash = unhashable
+			} else {
+				total++
+			}
+			s.db.Model(&model.Image{}).Where("id = ?", item.ID).UpdateColumn("phash", hash)
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Duplicates: hashed %d photos", total)
+	}
+	return total
+}
+
+// hashImage hashes a stored photo, from its thumbnail for remote sources
+func (s *DuplicateService) hashImage(item model.Image) (string, error) {
+	if !IsRemoteSource(item.Source) {
+		return imageops.HashFile(item.FilePath)
+	}
+
+	data, err := s.remote.Fetch(item, VariantThumbnail)
+	if err != nil {
+		return "", err
+	}
+	img, _, err := imageops.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return imageops.FormatHash(imageops.DHash(img)), nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateService_Report(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewDuplicateService(db, NewSettingsService(db), nil)
+
+	images := []model.Image{
+		{Source: "local", PHash: "00000000000000ff"},
+		{Source: "synology", PHash: "00000000000000ff"}, // Same photo from another source
+		{Source: "upload", PHash: "000000000000003f"},   // 2 bits off
+		{Source: "upload", PHash: "000000000000000f"},   // 2 bits off the previous one
+		{Source: "local", PHash: "ffffffff00000000"},
+		{Source: "local", PHash: unhashable},
+		{Source: "immich"},
+	}
+	require.NoError(t, db.Create(&images).Error)
+
+	groups, err := svc.Report(2)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	var ids []uint
+	for _, p := range groups[0].Photos {
+		ids = append(ids, p.ID)
+	}
+	assert.Equal(t, []uint{images[0].ID, images[1].ID, images[2].ID, images[3].ID}, ids)
+	assert.Equal(t, 4, groups[0].Distance)
+
+	groups, err = svc.Report(0)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Len(t, groups[0].Photos, 2)
+
+	unhashed, err := svc.Unhashed()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unhashed)
+}
+
+func TestDuplicateService_Check(t *testing.T) {
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	svc := NewDuplicateService(db, settings, nil)
+
+	// Left half dark, right half bright
+	img := image.NewGray(image.Rect(0, 0, 90, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 90; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 255 / 90)})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "photo.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	f.Close()
+
+	hash, duplicate := svc.Check(path)
+	assert.Len(t, hash, 16)
+	assert.Nil(t, duplicate)
+
+	existing := model.Image{Source: "upload", PHash: hash}
+	require.NoError(t, db.Create(&existing).Error)
+
+	// Only reported unless auto-skip is enabled
+	_, duplicate = svc.Check(path)
+	assert.Nil(t, duplicate)
+
+	require.NoError(t, settings.Set("duplicate_auto_skip", "true"))
+	_, duplicate = svc.Check(path)
+	require.NotNil(t, duplicate)
+	assert.Equal(t, existing.ID, duplicate.ID)
+
+	hash, duplicate = svc.Check(filepath.Join(t.TempDir(), "missing.jpg"))
+	assert.Equal(t, unhashable, hash)
+	assert.Nil(t, duplicate)
+}
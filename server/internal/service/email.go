@@ -0,0 +1,401 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	"github.com/aitjcize/photoframe-server/server/pkg/mailbox"
+	"github.com/aitjcize/photoframe-server/server/pkg/telegram"
+	"gorm.io/gorm"
+)
+
+type EmailPollResult struct {
+	Messages   int `json:"messages"`   // Unread messages processed
+	Imported   int `json:"imported"`   // Photos imported
+	Duplicates int `json:"duplicates"` // Near-duplicate photos skipped (duplicate_auto_skip)
+	Rejected   int `json:"rejected"`   // Messages from senders that are not allowed
+	Pushed     int `json:"pushed"`     // Messages pushed to a device
+	Failed     int `json:"failed"`
+}
+
+// EmailService polls an IMAP mailbox and imports the photos attached to
+// incoming emails into DATA_DIR/photos/email, with the subject as caption.
+// Processed messages are marked as read, or moved to email_processed_folder.
+// Like the Telegram bot, photos are pushed to the device mapped from the sender.
+type EmailService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	dataDir  string
+	pusher   telegram.Pusher
+	dups     *DuplicateService
+	events   *EventBus
+
+	pollMu sync.Mutex // Serializes polls
+	mu     sync.Mutex // Guards stop
+	stop   chan struct{}
+}
+
+func NewEmailService(db *gorm.DB, settings *SettingsService, dataDir string, pusher telegram.Pusher, dups *DuplicateService, events *EventBus) *EmailService {
+	return &EmailService{
+		db:       db,
+		settings: settings,
+		dataDir:  dataDir,
+		pusher:   pusher,
+		dups:     dups,
+		events:   events,
+	}
+}
+
+func (s *EmailService) dial() (*mailbox.Client, error) {
+	host, _ := s.settings.Get("email_imap_host")
+	portStr, _ := s.settings.Get("email_imap_port")
+	security, _ := s.settings.Get("email_imap_security")
+	username, _ := s.settings.Get("email_imap_username")
+	password, _ := s.settings.Get("email_imap_password")
+	skipCertStr, _ := s.settings.Get("email_imap_skip_cert")
+
+	port, _ := strconv.Atoi(portStr)
+	return mailbox.Dial(mailbox.Config{
+		Host:           host,
+		Port:           port,
+		Security:       security,
+		Username:       username,
+		Password:       password,
+		SkipCertVerify: skipCertStr == "true",
+	})
+}
+
+func (s *EmailService) folder() string {
+	if folder, _ := s.settings.Get("email_imap_folder"); strings.TrimSpace(folder) != "" {
+		return strings.TrimSpace(folder)
+	}
+	return "INBOX"
+}
+
+func (s *EmailService) pollInterval() time.Duration {
+	val, _ := s.settings.Get("email_poll_interval")
+	if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 5 * time.Minute
+}
+
+// senderDevices returns the device each sender's photos are pushed to
+// (setting email_sender_devices, a JSON object of address to device ID)
+func (s *EmailService) senderDevices() map[string]uint {
+	raw, _ := s.settings.Get("email_sender_devices")
+	routes := make(map[string]uint)
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &routes); err != nil {
+			log.Printf("Email: invalid email_sender_devices: %v", err)
+		}
+	}
+
+	result := make(map[string]uint, len(routes))
+	for sender, device := range routes {
+		result[strings.ToLower(strings.TrimSpace(sender))] = device
+	}
+	return result
+}
+
+// senderAllowed checks the sender against email_allowed_senders and the
+// senders mapped to a device. Like the Telegram allow-list, nobody is accepted
+// until one of them is set. The From header is easily forged, so
+// secretMatches can require more.
+func (s *EmailService) senderAllowed(sender string, routes map[string]uint) bool {
+	raw, _ := s.settings.Get("email_allowed_senders")
+	allowed := strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' '
+	})
+
+	if _, ok := routes[sender]; ok {
+		return true
+	}
+	for _, a := range allowed {
+		// "@example.com" allows a whole domain
+		if a == sender || (strings.HasPrefix(a, "@") && strings.HasSuffix(sender, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretMatches checks a message for email_secret, in the subject or as the
+// tag of a plus-addressed recipient (frame+<secret>@example.com). Without a
+// secret every message matches.
+func (s *EmailService) secretMatches(msg *mailbox.Message) bool {
+	secret, _ := s.settings.Get("email_secret")
+	secret = strings.ToLower(strings.TrimSpace(secret))
+	if secret == "" {
+		return true
+	}
+	for _, to := range msg.To {
+		local, _, _ := strings.Cut(to, "@")
+		if _, tag, ok := strings.Cut(local, "+"); ok && tag == secret {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(msg.Subject), secret)
+}
+
+// captionOf returns the subject of a message without the secret
+func (s *EmailService) captionOf(msg *mailbox.Message) string {
+	secret, _ := s.settings.Get("email_secret")
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return msg.Subject
+	}
+	re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(secret))
+	return strings.Join(strings.Fields(re.ReplaceAllString(msg.Subject, "")), " ")
+}
+
+// Restart stops polling and starts it again if a mailbox is configured
+func (s *EmailService) Restart() {
+	s.Stop()
+
+	if host, _ := s.settings.Get("email_imap_host"); host == "" {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.mu.Lock()
+	s.stop = stop
+	s.mu.Unlock()
+
+	go s.pollLoop(stop, s.pollInterval())
+}
+
+// Stop stops polling
+func (s *EmailService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *EmailService) pollLoop(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := s.Poll(); err != nil {
+				log.Printf("Email poll failed: %v", err)
+			}
+		}
+	}
+}
+
+// TestConnection checks the credentials and that the folder exists
+func (s *EmailService) TestConnection() error {
+	client, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Select(s.folder())
+}
+
+// Poll imports the photos of all unread messages
+func (s *EmailService) Poll() (*EmailPollResult, error) {
+	s.pollMu.Lock()
+	defer s.pollMu.Unlock()
+
+	client, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if err := client.Select(s.folder()); err != nil {
+		return nil, fmt.Errorf("failed to open folder %s: %w", s.folder(), err)
+	}
+
+	processedFolder, _ := s.settings.Get("email_processed_folder")
+	processedFolder = strings.TrimSpace(processedFolder)
+	if processedFolder != "" {
+		if err := client.EnsureFolder(processedFolder); err != nil {
+			return nil, fmt.Errorf("failed to create folder %s: %w", processedFolder, err)
+		}
+	}
+
+	uids, err := client.Unseen()
+	if err != nil {
+		return nil, err
+	}
+
+	photosDir := filepath.Join(s.dataDir, "photos", "email")
+	if err := os.MkdirAll(photosDir, 0755); err != nil {
+		return nil, err
+	}
+
+	result := &EmailPollResult{}
+	routes := s.senderDevices()
+	for _, uid := range uids {
+		msg, err := client.Fetch(uid)
+		if err != nil {
+			// Left unread, so it is retried on the next poll
+			log.Printf("Email: failed to fetch message %d: %v", uid, err)
+			result.Failed++
+			continue
+		}
+		result.Messages++
+
+		if !s.senderAllowed(msg.From, routes) {
+			log.Printf("Email: ignoring message from %s (not an allowed sender)", msg.From)
+			result.Rejected++
+		} else if !s.secretMatches(msg) {
+			log.Printf("Email: ignoring message from %s (secret missing)", msg.From)
+			result.Rejected++
+		} else {
+			s.importMessage(msg, photosDir, routes, result)
+		}
+
+		if err := client.MarkSeen(uid); err != nil {
+			log.Printf("Email: failed to mark message %d as read: %v", uid, err)
+		}
+		if processedFolder != "" {
+			if err := client.Move(processedFolder, uid); err != nil {
+				log.Printf("Email: failed to move message %d to %s: %v", uid, processedFolder, err)
+			}
+		}
+	}
+
+	if result.Messages > 0 {
+		log.Printf("Email poll complete: messages=%d imported=%d rejected=%d pushed=%d failed=%d", result.Messages, result.Imported, result.Rejected, result.Pushed, result.Failed)
+	}
+	return result, nil
+}
+
+// savePhoto writes an attachment to a new file. The same photo sent again
+// (e.g. after its first copy was deleted) gets a file of its own.
+func savePhoto(photosDir string, photo mailbox.Attachment) (string, error) {
+	sum := sha256.Sum256(photo.Data)
+	f, err := os.CreateTemp(photosDir, hex.EncodeToString(sum[:8])+"_*"+photo.Extension())
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(photo.Data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	// CreateTemp makes the file private
+	return f.Name(), os.Chmod(f.Name(), 0644)
+}
+
+// importMessage stores the photos of a message, then pushes the last one to
+// the sender's device
+func (s *EmailService) importMessage(msg *mailbox.Message, photosDir string, routes map[string]uint, result *EmailPollResult) {
+	if msg.MessageID != "" {
+		// Also matches photos deleted from the gallery, so they are not imported again
+		var count int64
+		s.db.Unscoped().Model(&model.Image{}).Where("source = ? AND email_message_id = ?", "email", msg.MessageID).Count(&count)
+		if count > 0 {
+			return
+		}
+	}
+	if len(msg.Photos) == 0 {
+		log.Printf("Email: message %q from %s has no photos", msg.Subject, msg.From)
+		return
+	}
+
+	var last string
+	for _, photo := range msg.Photos {
+		localPath, err := savePhoto(photosDir, photo)
+		if err != nil {
+			log.Printf("Email: failed to save %s: %v", photo.Filename, err)
+			result.Failed++
+			continue
+		}
+
+		hash, duplicate := s.dups.Check(localPath)
+		if duplicate != nil {
+			log.Printf("Email: skipping %s, duplicate of photo #%d", photo.Filename, duplicate.ID)
+			os.Remove(localPath)
+			result.Duplicates++
+			continue
+		}
+
+		width, height, orientation, err := imageops.ReadInfo(localPath)
+		if err != nil {
+			log.Printf("Email: skipping unreadable attachment %s: %v", photo.Filename, err)
+			os.Remove(localPath)
+			result.Failed++
+			continue
+		}
+
+		img := model.Image{
+			FilePath:       localPath,
+			Caption:        s.captionOf(msg),
+			Source:         "email",
+			EmailMessageID: msg.MessageID,
+			EmailSender:    msg.From,
+			UserID:         1,
+			Status:         "pending",
+			CreatedAt:      time.Now(),
+			CapturedAt:     imageops.ReadCaptureTime(localPath),
+			Width:          width,
+			Height:         height,
+			Orientation:    orientation,
+			PHash:          hash,
+		}
+		if err := s.db.Create(&img).Error; err != nil {
+			log.Printf("Email: failed to import %s: %v", photo.Filename, err)
+			os.Remove(localPath)
+			result.Failed++
+			continue
+		}
+		result.Imported++
+		s.events.PhotoImported(&img)
+		last = localPath
+	}
+
+	deviceID, ok := routes[msg.From]
+	if !ok || last == "" || s.pusher == nil {
+		return
+	}
+
+	var device model.Device
+	if err := s.db.First(&device, deviceID).Error; err != nil {
+		log.Printf("Email: device %d for %s not found: %v", deviceID, msg.From, err)
+		return
+	}
+	if err := s.pusher.PushToHost(&device, last, nil); err != nil {
+		// The photo is in the gallery and shows up when the device wakes up
+		log.Printf("Email: failed to push to device %d: %v", deviceID, err)
+		return
+	}
+	result.Pushed++
+}
+
+// GetPhotoCount returns the number of email photos in the database
+func (s *EmailService) GetPhotoCount() (int64, error) {
+	var count int64
+	if err := s.db.Model(&model.Image{}).Where("source = ?", "email").Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// moveBackend adds MOVE to the memory backend, which the server advertises
+// but the memory mailboxes don't implement
+type moveBackend struct{ *memory.Backend }
+
+func (b moveBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	user, err := b.Backend.Login(info, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return moveUser{user}, nil
+}
+
+type moveUser struct{ backend.User }
+
+func (u moveUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+	return moveMailbox{mbox}, nil
+}
+
+type moveMailbox struct{ backend.Mailbox }
+
+func (m moveMailbox) MoveMessages(uid bool, seqSet *imap.SeqSet, dest string) error {
+	if err := m.CopyMessages(uid, seqSet, dest); err != nil {
+		return err
+	}
+	if err := m.UpdateMessagesFlags(uid, seqSet, imap.AddFlags, []string{imap.DeletedFlag}); err != nil {
+		return err
+	}
+	return m.Expunge()
+}
+
+type fakePusher struct {
+	pushes []string // "<device id>:<path>"
+}
+
+func (p *fakePusher) PushToHost(device *model.Device, imagePath string, extraOpts map[string]string) error {
+	p.pushes = append(p.pushes, fmt.Sprintf("%d:%s", device.ID, imagePath))
+	return nil
+}
+
+type emailTest struct {
+	svc      *EmailService
+	settings *SettingsService
+	db       *gorm.DB
+	pusher   *fakePusher
+	user     backend.User
+}
+
+// newEmailTest starts an in-process IMAP server with the memory backend
+// (user "username", password "password") and an email service polling it
+func newEmailTest(t *testing.T) *emailTest {
+	be := memory.New()
+	srv := server.New(moveBackend{be})
+	srv.AllowInsecureAuth = true
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	user, err := be.Login(nil, "username", "password")
+	require.NoError(t, err)
+
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&model.Device{ID: 1, Name: "Kitchen", Host: "kitchen.local"}).Error)
+
+	settings := NewSettingsService(db)
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	settings.Set("email_imap_host", host)
+	settings.Set("email_imap_port", port)
+	settings.Set("email_imap_security", "none")
+	settings.Set("email_imap_username", "username")
+	settings.Set("email_imap_password", "password")
+
+	pusher := &fakePusher{}
+	return &emailTest{
+		svc:      NewEmailService(db, settings, t.TempDir(), pusher, nil, nil),
+		settings: settings,
+		db:       db,
+		pusher:   pusher,
+		user:     user,
+	}
+}
+
+// deliver appends a message to a folder of the test mailbox
+func (et *emailTest) deliver(t *testing.T, folder, raw string) {
+	mbox, err := et.user.GetMailbox(folder)
+	require.NoError(t, err)
+	body := []byte(strings.ReplaceAll(raw, "\n", "\r\n"))
+	require.NoError(t, mbox.CreateMessage(nil, testDate, bytes.NewReader(body)))
+}
+
+// unseen returns the UIDs of the unread messages in a folder
+func (et *emailTest) unseen(t *testing.T, folder string) []uint32 {
+	mbox, err := et.user.GetMailbox(folder)
+	require.NoError(t, err)
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	uids, err := mbox.SearchMessages(true, criteria)
+	require.NoError(t, err)
+	return uids
+}
+
+func (et *emailTest) count(t *testing.T, folder string) uint32 {
+	mbox, err := et.user.GetMailbox(folder)
+	require.NoError(t, err)
+	status, err := mbox.Status([]imap.StatusItem{imap.StatusMessages})
+	require.NoError(t, err)
+	return status.Messages
+}
+
+var testDate = time.Date(2025, 10, 12, 10, 0, 0, 0, time.UTC)
+
+func testImage(t *testing.T, encode func(*bytes.Buffer, image.Image) error) string {
+	var buf bytes.Buffer
+	require.NoError(t, encode(&buf, image.NewRGBA(image.Rect(0, 0, 60, 40))))
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func pngData(t *testing.T) string {
+	return testImage(t, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+}
+
+func jpegData(t *testing.T) string {
+	return testImage(t, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) })
+}
+
+// attachmentEmail has a text part, a PNG attachment and a PDF that must be ignored
+func attachmentEmail(t *testing.T, from, messageID, subject string) string {
+	return `From: ` + from + `
+To: frame@example.com
+Subject: ` + subject + `
+Date: Sun, 12 Oct 2025 10:00:00 +0000
+Message-ID: ` + messageID + `
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="b1"
+
+--b1
+Content-Type: text/plain; charset=utf-8
+
+Look at the roses!
+--b1
+Content-Type: image/png; name="roses.png"
+Content-Disposition: attachment; filename="roses.png"
+Content-Transfer-Encoding: base64
+
+` + pngData(t) + `
+--b1
+Content-Type: application/pdf; name="recipe.pdf"
+Content-Disposition: attachment; filename="recipe.pdf"
+Content-Transfer-Encoding: base64
+
+JVBERi0xLjQK
+--b1--
+`
+}
+
+// inlineEmail has a Latin-1 encoded subject and an inline JPEG without file name
+func inlineEmail(t *testing.T) string {
+	return `From: Uncle Bob <bob@family.org>
+To: frame@example.com
+Subject: =?ISO-8859-1?Q?Caf=E9?=
+Date: Sun, 12 Oct 2025 11:00:00 +0000
+Message-ID: <m3@family.org>
+MIME-Version: 1.0
+Content-Type: multipart/related; boundary="b2"
+
+--b2
+Content-Type: text/html; charset=utf-8
+
+<img src="cid:photo1">
+--b2
+Content-Type: image/jpeg
+Content-Disposition: inline
+Content-ID: <photo1>
+Content-Transfer-Encoding: base64
+
+` + jpegData(t) + `
+--b2--
+`
+}
+
+func TestEmailService_Poll(t *testing.T) {
+	et := newEmailTest(t)
+	et.settings.Set("email_sender_devices", `{"Grandma@Example.com": 1}`)
+	et.settings.Set("email_allowed_senders", "@family.org")
+
+	et.deliver(t, "INBOX", attachmentEmail(t, "Grandma <grandma@example.com>", "<m1@example.com>", "Garden in bloom"))
+	et.deliver(t, "INBOX", attachmentEmail(t, "Spammer <deals@spam.example>", "<m2@spam.example>", "Cheap watches"))
+	et.deliver(t, "INBOX", inlineEmail(t))
+
+	result, err := et.svc.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, &EmailPollResult{Messages: 3, Imported: 2, Rejected: 1, Pushed: 1}, result)
+
+	var images []model.Image
+	require.NoError(t, et.db.Order("id").Find(&images).Error)
+	require.Len(t, images, 2)
+
+	assert.Equal(t, "Garden in bloom", images[0].Caption)
+	assert.Equal(t, "email", images[0].Source)
+	assert.Equal(t, "grandma@example.com", images[0].EmailSender)
+	assert.Equal(t, "m1@example.com", images[0].EmailMessageID)
+	assert.Equal(t, "landscape", images[0].Orientation)
+	assert.True(t, strings.HasSuffix(images[0].FilePath, ".png"))
+	assert.FileExists(t, images[0].FilePath)
+
+	assert.Equal(t, "Café", images[1].Caption)
+	assert.Equal(t, "bob@family.org", images[1].EmailSender)
+	assert.True(t, strings.HasSuffix(images[1].FilePath, ".jpg"))
+	assert.FileExists(t, images[1].FilePath)
+
+	// Only the mapped sender's photo is pushed
+	assert.Equal(t, []string{"1:" + images[0].FilePath}, et.pusher.pushes)
+
+	// All messages are marked as read and not processed again
+	assert.Empty(t, et.unseen(t, "INBOX"))
+	result, err = et.svc.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, &EmailPollResult{}, result)
+}
+
+func TestEmailService_PollMovesProcessedMessages(t *testing.T) {
+	et := newEmailTest(t)
+	et.settings.Set("email_processed_folder", "Frame/Done")
+	et.settings.Set("email_allowed_senders", "grandma@example.com, @family.org")
+
+	et.deliver(t, "INBOX", attachmentEmail(t, "grandma@example.com", "<m1@example.com>", "Garden in bloom"))
+	et.deliver(t, "INBOX", inlineEmail(t))
+
+	result, err := et.svc.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Messages)
+	assert.Equal(t, 2, result.Imported)
+	assert.Empty(t, et.pusher.pushes, "no sender is mapped to a device")
+
+	// The folder is created, only the message seeded by the memory backend stays
+	assert.Equal(t, uint32(1), et.count(t, "INBOX"))
+	assert.Equal(t, uint32(2), et.count(t, "Frame/Done"))
+
+	// A message delivered twice is only imported once
+	et.deliver(t, "INBOX", attachmentEmail(t, "grandma@example.com", "<m1@example.com>", "Garden in bloom"))
+	result, err = et.svc.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Messages)
+	assert.Equal(t, 0, result.Imported)
+
+	// The same photo in another message gets a file of its own
+	et.deliver(t, "INBOX", attachmentEmail(t, "grandma@example.com", "<m2@example.com>", "Garden again"))
+	result, err = et.svc.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+	var images []model.Image
+	require.NoError(t, et.db.Where("email_message_id IN ?", []string{"m1@example.com", "m2@example.com"}).Find(&images).Error)
+	require.Len(t, images, 2)
+	assert.NotEqual(t, images[0].FilePath, images[1].FilePath)
+
+	count, err := et.svc.GetPhotoCount()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestEmailService_PollRejectsWithoutAllowedSenders(t *testing.T) {
+	et := newEmailTest(t)
+	et.deliver(t, "INBOX", attachmentEmail(t, "grandma@example.com", "<m1@example.com>", "Garden in bloom"))
+
+	result, err := et.svc.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rejected)
+	assert.Equal(t, 0, result.Imported)
+}
+
+func TestEmailService_PollRequiresSecret(t *testing.T) {
+	et := newEmailTest(t)
+	et.settings.Set("email_allowed_senders", "grandma@example.com")
+	et.settings.Set("email_secret", "Tulip42")
+
+	// A forged sender without the secret, the secret in the subject, and a
+	// plus-addressed recipient
+	et.deliver(t, "INBOX", attachmentEmail(t, "grandma@example.com", "<m1@example.com>", "Garden"))
+	et.deliver(t, "INBOX", attachmentEmail(t, "grandma@example.com", "<m2@example.com>", "Garden tulip42 in bloom"))
+	plus := strings.Replace(attachmentEmail(t, "grandma@example.com", "<m3@example.com>", "Roses"), "To: frame@example.com", "To: Frame+tulip42@example.com", 1)
+	et.deliver(t, "INBOX", plus)
+
+	result, err := et.svc.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rejected)
+	assert.Equal(t, 2, result.Imported)
+
+	var captions []string
+	require.NoError(t, et.db.Model(&model.Image{}).Order("id").Pluck("caption", &captions).Error)
+	assert.Equal(t, []string{"Garden in bloom", "Roses"}, captions)
+}
+
+func TestEmailService_TestConnection(t *testing.T) {
+	et := newEmailTest(t)
+	assert.NoError(t, et.svc.TestConnection())
+
+	et.settings.Set("email_imap_folder", "Missing")
+	assert.Error(t, et.svc.TestConnection())
+
+	et.settings.Set("email_imap_password", "wrong")
+	assert.ErrorContains(t, et.svc.TestConnection(), "login failed")
+
+	et.settings.Set("email_imap_host", "")
+	assert.Error(t, et.svc.TestConnection())
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+)
+
+// Event types published on the EventBus
+const (
+	EventPhotoImported   = "photo.imported"   // {"id", "source"}
+	EventJobUpdated      = "job.updated"      // The job: status or progress changed, e.g. a sync
+	EventJobFinished     = "job.finished"     // The job: succeeded, failed or canceled
+	EventPushStarted     = "push.started"     // {"device_id", "device"}
+	EventPushSucceeded   = "push.succeeded"   // {"device_id", "device"}
+	EventPushFailed      = "push.failed"      // {"device_id", "device", "error"}
+	EventDeviceSeen      = "device.seen"      // {"device_id", "device", "last_seen_at"}
+	EventDeviceStale     = "device.stale"     // {"device_id", "device", "last_seen_at"}: not seen for device_stale_hours
+	EventDeviceUpdated   = "device.updated"   // The device: added or settings changed
+	EventDeviceDeleted   = "device.deleted"   // {"device_id"}
+	EventPhotoDisplayed  = "photo.displayed"  // {"device_id", "image_ids"}: shown on a frame
+	EventSettingsChanged = "settings.changed" // {"keys"}
+)
+
+// Slow subscribers miss events once this many are pending
+const eventBuffer = 64
+
+// Event is a message for live UI updates
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time time.Time   `json:"time"`
+}
+
+// EventBus fans out events to subscribers within the process. Publishing never
+// blocks: events are dropped for subscribers that don't keep up, but queues
+// keep all of them.
+type EventBus struct {
+	mu     sync.Mutex // Guards subs and queues
+	subs   map[chan Event]struct{}
+	queues map[*eventQueue]struct{}
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subs:   make(map[chan Event]struct{}),
+		queues: make(map[*eventQueue]struct{}),
+	}
+}
+
+// Publish sends an event to all subscribers. It does nothing on a nil bus, so
+// services can be used without one.
+func (b *EventBus) Publish(eventType string, data interface{}) {
+	if b == nil {
+		return
+	}
+	event := Event{Type: eventType, Data: data, Time: time.Now()}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	for q := range b.queues {
+		if q.filter(event) {
+			q.push(event)
+		}
+	}
+}
+
+// PhotoImported publishes a photo.imported event for a new photo
+func (b *EventBus) PhotoImported(img *model.Image) {
+	b.Publish(EventPhotoImported, map[string]interface{}{"id": img.ID, "source": img.Source})
+}
+
+// Subscribe returns a channel receiving all events published from now on and
+// a function to unsubscribe, which closes the channel
+func (b *EventBus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Queue is like Subscribe, but never drops events: they wait in memory until
+// the channel is read. Only events accepted by filter are queued; it is called
+// while publishing, so it must be quick and must not publish itself.
+func (b *EventBus) Queue(filter func(Event) bool) (<-chan Event, func()) {
+	q := &eventQueue{
+		filter: filter,
+		wake:   make(chan struct{}, 1),
+		out:    make(chan Event),
+		done:   make(chan struct{}),
+	}
+	b.mu.Lock()
+	b.queues[q] = struct{}{}
+	b.mu.Unlock()
+	go q.run()
+
+	var once sync.Once
+	return q.out, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.queues, q)
+			b.mu.Unlock()
+			close(q.done)
+		})
+	}
+}
+
+// eventQueue buffers events for a queue subscriber without limit
+type eventQueue struct {
+	filter func(Event) bool
+
+	mu      sync.Mutex // Guards pending
+	pending []Event
+	wake    chan struct{} // Signals new pending events
+	out     chan Event
+	done    chan struct{}
+}
+
+func (q *eventQueue) push(event Event) {
+	q.mu.Lock()
+	q.pending = append(q.pending, event)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run hands pending events to the subscriber until it unsubscribes
+func (q *eventQueue) run() {
+	defer close(q.out)
+	for {
+		q.mu.Lock()
+		events := q.pending
+		q.pending = nil
+		q.mu.Unlock()
+
+		for _, event := range events {
+			select {
+			case q.out <- event:
+			case <-q.done:
+				return
+			}
+		}
+		select {
+		case <-q.wake:
+		case <-q.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus(t *testing.T) {
+	var none *EventBus
+	none.Publish(EventPushStarted, nil) // No-op without a bus
+
+	bus := NewEventBus()
+	events, unsubscribe := bus.Subscribe()
+	bus.PhotoImported(&model.Image{ID: 7, Source: "upload"})
+
+	event := <-events
+	assert.Equal(t, EventPhotoImported, event.Type)
+	assert.Equal(t, map[string]interface{}{"id": uint(7), "source": "upload"}, event.Data)
+
+	// Events for a subscriber that doesn't keep up are dropped
+	for i := 0; i < eventBuffer+10; i++ {
+		bus.Publish(EventPushStarted, i)
+	}
+	assert.Len(t, events, eventBuffer)
+
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(EventPushStarted, nil)
+	for range events {
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/googlephotos"
+	"gorm.io/gorm"
+)
+
+type GoogleAlbumSyncResult struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Failed  int `json:"failed"`
+}
+
+// GoogleAlbumService continuously mirrors selected Google Photos albums.
+// Unlike the one-shot Picker flow, new photos added to an album show up on
+// the next sync and removed photos are dropped.
+type GoogleAlbumService struct {
+	client   *googlephotos.Client
+	db       *gorm.DB
+	settings *SettingsService
+	trash    *TrashService
+	dataDir  string
+
+	syncMu sync.Mutex // Serializes syncs
+	mu     sync.Mutex // Guards stop
+	stop   chan struct{}
+}
+
+func NewGoogleAlbumService(client *googlephotos.Client, db *gorm.DB, settings *SettingsService, trash *TrashService, dataDir string) *GoogleAlbumService {
+	return &GoogleAlbumService{
+		client:   client,
+		db:       db,
+		settings: settings,
+		trash:    trash,
+		dataDir:  dataDir,
+	}
+}
+
+// AlbumIDs returns the selected album IDs (setting google_album_ids, comma separated)
+func (s *GoogleAlbumService) AlbumIDs() []string {
+	raw, _ := s.settings.Get("google_album_ids")
+
+	var ids []string
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (s *GoogleAlbumService) syncInterval() time.Duration {
+	val, _ := s.settings.Get("google_album_sync_interval")
+	if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return time.Hour
+}
+
+// Restart stops the periodic sync and starts it again if albums are selected
+func (s *GoogleAlbumService) Restart() {
+	s.Stop()
+
+	if len(s.AlbumIDs()) == 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.mu.Lock()
+	s.stop = stop
+	s.mu.Unlock()
+
+	go s.syncLoop(stop, s.syncInterval())
+	log.Printf("Google album sync started for %d albums", len(s.AlbumIDs()))
+}
+
+// Stop stops the periodic sync
+func (s *GoogleAlbumService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *GoogleAlbumService) syncLoop(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if !s.client.IsConnected() {
+				continue
+			}
+			if _, err := s.Sync(); err != nil {
+				log.Printf("Google album sync failed: %v", err)
+			}
+		}
+	}
+}
+
+// ListAlbums returns the albums the app has access to
+func (s *GoogleAlbumService) ListAlbums() ([]googlephotos.Album, error) {
+	return s.client.ListAlbums(50)
+}
+
+// Sync imports new items of the selected albums and removes items that are no
+// longer in any of them. Photos only imported with the Picker are never touched;
+// picked photos found in a selected album are synced from then on.
+func (s *GoogleAlbumService) Sync() (*GoogleAlbumSyncResult, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	albumIDs := s.AlbumIDs()
+	if len(albumIDs) == 0 {
+		return nil, errors.New("no google albums selected")
+	}
+
+	httpClient, err := s.client.GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	photosDir := filepath.Join(s.dataDir, "photos")
+	if err := os.MkdirAll(photosDir, 0755); err != nil {
+		return nil, err
+	}
+
+	result := &GoogleAlbumSyncResult{}
+	seen := make(map[string]bool)
+	for _, albumID := range albumIDs {
+		// A failed listing aborts the sync, so a temporary outage never removes photos
+		items, err := s.client.ListMediaItems(albumID, 100)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list album %s: %w", albumID, err)
+		}
+
+		for _, item := range items {
+			seen[filepath.Join(photosDir, item.ID+".jpg")] = true
+
+			stored, err := storeGoogleMedia(s.db, nil, httpClient, photosDir, googleMedia{
+				ID:       item.ID,
+				BaseUrl:  item.BaseUrl,
+				MimeType: item.MimeType,
+				Filename: item.Filename,
+				AlbumID:  albumID,
+			})
+			if err != nil {
+				log.Printf("Google album sync: failed to import %s: %v", item.Filename, err)
+				result.Failed++
+				continue
+			}
+			if stored != nil {
+				result.Added++
+			}
+		}
+	}
+
+	// Drop photos removed from their album (or from albums no longer selected)
+	var synced []model.Image
+	if err := s.db.Unscoped().Where("source = ? AND google_album_id <> ''", "google").Find(&synced).Error; err != nil {
+		return result, err
+	}
+	for _, item := range synced {
+		if seen[item.FilePath] {
+			continue
+		}
+		if err := s.trash.Remove([]model.Image{item}); err != nil {
+			log.Printf("Google album sync: failed to remove %s: %v", item.FilePath, err)
+			continue
+		}
+		result.Removed++
+	}
+
+	log.Printf("Google album sync complete: added=%d removed=%d failed=%d", result.Added, result.Removed, result.Failed)
+	return result, nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreGoogleMedia_PickedThenSynced(t *testing.T) {
+	db := setupTestDB(t)
+	photosDir := t.TempDir()
+	path := filepath.Join(photosDir, "m1.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("photo"), 0644))
+	picked := model.Image{Source: "google", FilePath: path}
+	require.NoError(t, db.Create(&picked).Error)
+
+	// Already downloaded, so nothing is fetched, but the album sync takes it over
+	stored, err := storeGoogleMedia(db, nil, nil, photosDir, googleMedia{ID: "m1", BaseUrl: "https://example.com/m1", AlbumID: "a1"})
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	var item model.Image
+	require.NoError(t, db.First(&item, picked.ID).Error)
+	assert.Equal(t, "a1", item.GoogleAlbumID)
+}
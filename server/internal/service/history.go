@@ -0,0 +1,87 @@
+package service
+
+import (
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+)
+
+// HistoryService records which photos were shown on which device
+type HistoryService struct {
+	db     *gorm.DB
+	events *EventBus
+}
+
+func NewHistoryService(db *gorm.DB, events *EventBus) *HistoryService {
+	return &HistoryService{db: db, events: events}
+}
+
+// Record logs that the images (one, or two for a collage) were shown on a
+// device, and updates their display counters
+func (s *HistoryService) Record(deviceID uint, imageIDs ...uint) error {
+	now := time.Now()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range imageIDs {
+			if id == 0 {
+				continue
+			}
+			if err := tx.Create(&model.Display{ImageID: id, DeviceID: deviceID, ShownAt: now}).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&model.Image{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+				"shown_count":   gorm.Expr("shown_count + 1"),
+				"last_shown_at": now,
+				"status":        "shown",
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		s.events.Publish(EventPhotoDisplayed, map[string]interface{}{"device_id": deviceID, "image_ids": imageIDs})
+	}
+	return err
+}
+
+// LastShown returns the photo most recently shown on a device
+func (s *HistoryService) LastShown(deviceID uint) (*model.Image, error) {
+	var item model.Image
+	err := s.db.Joins("JOIN display_history ON display_history.image_id = images.id").
+		Where("display_history.device_id = ?", deviceID).
+		Order("display_history.shown_at DESC, display_history.id DESC").
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// DisplayEntry is a display of a photo, with the name of the device
+type DisplayEntry struct {
+	model.Display
+	DeviceName string `json:"device_name"`
+}
+
+// ForImage returns the most recent displays of a photo, newest first
+func (s *HistoryService) ForImage(imageID uint, limit int) ([]DisplayEntry, error) {
+	entries := []DisplayEntry{}
+	err := s.db.Table("display_history").
+		Select("display_history.*, devices.name AS device_name").
+		Joins("LEFT JOIN devices ON devices.id = display_history.device_id").
+		Where("display_history.image_id = ?", imageID).
+		Order("display_history.shown_at DESC, display_history.id DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	return entries, err
+}
+
+// Forget removes the history of permanently deleted images
+func (s *HistoryService) Forget(imageIDs []uint) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	return s.db.Where("image_id IN ?", imageIDs).Delete(&model.Display{}).Error
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/immich"
+	"gorm.io/gorm"
+)
+
+type ImmichSyncResult struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// ImmichService keeps references to the photos of an Immich album.
+// Like Synology, photos are not stored locally but fetched live when served.
+type ImmichService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	trash    *TrashService
+	client   *immich.Client
+	config   string // url|key|skip_cert the client was created with
+	mu       sync.Mutex
+}
+
+func NewImmichService(db *gorm.DB, settings *SettingsService, trash *TrashService) *ImmichService {
+	return &ImmichService{
+		db:       db,
+		settings: settings,
+		trash:    trash,
+	}
+}
+
+// ensureClient (re)creates the client when the configured server or key changed
+func (s *ImmichService) ensureClient() (*immich.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baseURL, _ := s.settings.Get("immich_url")
+	apiKey, _ := s.settings.Get("immich_api_key")
+	skipCertStr, _ := s.settings.Get("immich_skip_cert")
+
+	if baseURL == "" || apiKey == "" {
+		return nil, errors.New("immich url or api key not configured")
+	}
+
+	config := baseURL + "|" + apiKey + "|" + skipCertStr
+	if s.client == nil || s.config != config {
+		s.client = immich.NewClient(baseURL, apiKey, skipCertStr == "true")
+		s.config = config
+	}
+	return s.client, nil
+}
+
+// TestConnection checks that the server is reachable and the API key is valid
+func (s *ImmichService) TestConnection() (*immich.User, error) {
+	s.mu.Lock()
+	s.client = nil // Reload settings
+	s.mu.Unlock()
+
+	client, err := s.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(); err != nil {
+		return nil, fmt.Errorf("immich server unreachable: %w", err)
+	}
+	return client.GetMe()
+}
+
+func (s *ImmichService) ListAlbums() ([]immich.Album, error) {
+	client, err := s.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+
+	albums, err := client.ListAlbums()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the albums list
+	albumsJSON, _ := json.Marshal(albums)
+	s.settings.Set("immich_albums_cache", string(albumsJSON))
+
+	return albums, nil
+}
+
+// Sync brings the database in line with the configured album: new assets are
+// added and assets removed from the album are dropped.
+func (s *ImmichService) Sync() (*ImmichSyncResult, error) {
+	client, err := s.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+
+	albumID, _ := s.settings.Get("immich_album_id")
+	if albumID == "" {
+		return nil, errors.New("no immich album selected")
+	}
+
+	assets, err := client.ListAlbumAssets(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Includes photos in the trash, so they are not imported again
+	var existing []model.Image
+	if err := s.db.Unscoped().Where("source = ?", "immich").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]model.Image, len(existing))
+	for _, img := range existing {
+		known[img.ImmichAssetID] = img
+	}
+
+	result := &ImmichSyncResult{}
+	seen := make(map[string]bool, len(assets))
+	for _, a := range assets {
+		if a.Type != "IMAGE" || a.IsTrashed {
+			continue
+		}
+		seen[a.ID] = true
+		if _, ok := known[a.ID]; ok {
+			continue
+		}
+
+		width, height := a.Dimensions()
+		orientation := "landscape"
+		if height > width {
+			orientation = "portrait"
+		}
+
+		img := model.Image{
+			ImmichAssetID: a.ID,
+			Source:        "immich",
+			FilePath:      a.OriginalFileName,
+			Width:         width,
+			Height:        height,
+			Orientation:   orientation,
+			CreatedAt:     time.Now(),
+			CapturedAt:    a.CapturedAt(),
+			Status:        "pending",
+		}
+		if a.ExifInfo != nil {
+			img.Caption = a.ExifInfo.Description
+		}
+		if err := s.db.Create(&img).Error; err != nil {
+			log.Printf("Failed to insert immich asset %s: %v", a.ID, err)
+			continue
+		}
+		result.Added++
+	}
+
+	var stale []model.Image
+	for assetID, img := range known {
+		if !seen[assetID] {
+			stale = append(stale, img)
+		}
+	}
+	if err := s.trash.Remove(stale); err != nil {
+		return nil, err
+	}
+	result.Removed = len(stale)
+
+	log.Printf("Immich sync complete: album=%s added=%d removed=%d", albumID, result.Added, result.Removed)
+	return result, nil
+}
+
+// GetPhoto fetches an asset from Immich.
+// size: immich.SizeThumbnail or immich.SizePreview
+func (s *ImmichService) GetPhoto(assetID, size string) ([]byte, error) {
+	client, err := s.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetThumbnail(assetID, size)
+}
+
+// ClearPhotos deletes all Immich photos from database
+func (s *ImmichService) ClearPhotos() error {
+	var items []model.Image
+	if err := s.db.Unscoped().Where("source = ?", "immich").Find(&items).Error; err != nil {
+		return err
+	}
+	if err := s.trash.Remove(items); err != nil {
+		return err
+	}
+	log.Println("Cleared all Immich photos from database")
+	return nil
+}
+
+// GetPhotoCount returns the number of Immich photos in the database
+func (s *ImmichService) GetPhotoCount() (int64, error) {
+	var count int64
+	if err := s.db.Model(&model.Image{}).Where("source = ?", "immich").Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultJobWorkers is the number of jobs run at the same time
+	DefaultJobWorkers = 2
+
+	jobPollInterval = time.Second
+	maxJobBackoff   = time.Hour
+	jobRetention    = 7 * 24 * time.Hour // Finished jobs are deleted after a week
+)
+
+// JobType describes how jobs of a type are run
+type JobType struct {
+	// Run does the work. It should return soon after ctx is canceled. The
+	// result is stored as JSON.
+	Run func(ctx context.Context, run *JobRun) (interface{}, error)
+
+	MaxAttempts int           // Attempts including retries (default 1)
+	Backoff     time.Duration // Wait before the first retry, doubled for each further one
+	Resume      bool          // Run again if interrupted by a restart, for idempotent work
+}
+
+// noRetryError marks errors that retrying won't fix
+type noRetryError struct{ err error }
+
+func (e noRetryError) Error() string { return e.err.Error() }
+func (e noRetryError) Unwrap() error { return e.err }
+
+// NoRetry wraps an error so the job fails without further attempts
+func NoRetry(err error) error {
+	if err == nil {
+		return nil
+	}
+	return noRetryError{err}
+}
+
+// JobRun is passed to a running job to read its payload and report progress
+type JobRun struct {
+	job    *model.Job
+	db     *gorm.DB
+	events *EventBus
+}
+
+// ID returns the ID of the running job
+func (r *JobRun) ID() uint {
+	return r.job.ID
+}
+
+// LastAttempt reports whether a failure of this run is final
+func (r *JobRun) LastAttempt() bool {
+	return r.job.Attempts >= r.job.MaxAttempts
+}
+
+// Payload decodes the job's payload into v
+func (r *JobRun) Payload(v interface{}) error {
+	if r.job.Payload == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(r.job.Payload), v)
+}
+
+// Update changes the job's progress and saves it
+func (r *JobRun) Update(fn func(p *model.JobProgress)) {
+	fn(&r.job.Progress)
+	err := r.db.Model(&model.Job{}).Where("id = ?", r.job.ID).UpdateColumns(map[string]interface{}{
+		"progress_total":     r.job.Progress.Total,
+		"progress_processed": r.job.Progress.Processed,
+		"progress_failed":    r.job.Progress.Failed,
+		"progress_message":   r.job.Progress.Message,
+	}).Error
+	if err != nil {
+		log.Printf("Jobs: failed to save progress of job %d: %v", r.job.ID, err)
+	}
+	r.events.Publish(EventJobUpdated, *r.job)
+}
+
+// JobService runs background jobs on a pool of workers. Jobs are stored in
+// the database, so their state survives a restart; failed jobs are retried
+// with exponential backoff. Changes are published as job.updated events, and
+// job.finished once a job succeeded, failed or was canceled.
+type JobService struct {
+	db     *gorm.DB
+	events *EventBus
+	types  map[string]JobType // Registered before Start, read-only afterwards
+
+	wake chan struct{}
+
+	mu      sync.Mutex // Guards cancels
+	cancels map[uint]context.CancelFunc
+}
+
+func NewJobService(db *gorm.DB, events *EventBus) *JobService {
+	return &JobService{
+		db:      db,
+		events:  events,
+		types:   make(map[string]JobType),
+		wake:    make(chan struct{}, 1),
+		cancels: make(map[uint]context.CancelFunc),
+	}
+}
+
+// Register adds a job type. All types must be registered before Start.
+func (s *JobService) Register(name string, t JobType) {
+	if t.MaxAttempts < 1 {
+		t.MaxAttempts = 1
+	}
+	s.types[name] = t
+}
+
+// Enqueue queues a job. If key is set and a job of the same type and key is
+// still queued or running, that job is returned instead. The payload is
+// stored as JSON.
+func (s *JobService) Enqueue(jobType, key string, payload interface{}) (*model.Job, error) {
+	t, ok := s.types[jobType]
+	if !ok {
+		return nil, fmt.Errorf("unknown job type %q", jobType)
+	}
+
+	if key != "" {
+		var active model.Job
+		err := s.db.Where("type = ? AND key = ? AND status IN ?", jobType, key, []string{model.JobQueued, model.JobRunning}).
+			First(&active).Error
+		if err == nil {
+			return &active, nil
+		}
+	}
+
+	job := model.Job{
+		Type:        jobType,
+		Key:         key,
+		Status:      model.JobQueued,
+		MaxAttempts: t.MaxAttempts,
+		RunAt:       time.Now(),
+	}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		job.Payload = string(data)
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	s.events.Publish(EventJobUpdated, job)
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return &job, nil
+}
+
+// Get returns a job
+func (s *JobService) Get(id uint) (*model.Job, error) {
+	var job model.Job
+	if err := s.db.First(&job, id).Error; err != nil {
+		return nil, errors.New("job not found")
+	}
+	return &job, nil
+}
+
+// Latest returns the most recent job of a type and key
+func (s *JobService) Latest(jobType, key string) (*model.Job, error) {
+	var job model.Job
+	if err := s.db.Where("type = ? AND key = ?", jobType, key).Order("id DESC").First(&job).Error; err != nil {
+		return nil, errors.New("job not found")
+	}
+	return &job, nil
+}
+
+// List returns the most recent jobs, optionally of one type and status
+func (s *JobService) List(jobType, status string, limit int) ([]model.Job, error) {
+	query := s.db.Order("id DESC").Limit(limit)
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	jobs := []model.Job{}
+	err := query.Find(&jobs).Error
+	return jobs, err
+}
+
+// Cancel stops a queued or running job. Running jobs stop at their next
+// check of the context.
+func (s *JobService) Cancel(id uint) (*model.Job, error) {
+	job, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		return nil, fmt.Errorf("job already %s", job.Status)
+	}
+
+	now := time.Now()
+	result := s.db.Model(&model.Job{}).Where("id = ? AND status = ?", id, model.JobQueued).
+		UpdateColumns(map[string]interface{}{"status": model.JobCanceled, "finished_at": now})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	s.mu.Lock()
+	if cancel, ok := s.cancels[id]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	if result.RowsAffected > 0 {
+		s.publish(id)
+	}
+	return s.Get(id)
+}
+
+// publish sends the current state of a job to the event bus
+func (s *JobService) publish(id uint) {
+	if s.events == nil {
+		return
+	}
+	if job, err := s.Get(id); err == nil {
+		s.events.Publish(EventJobUpdated, *job)
+		if job.Finished() {
+			s.events.Publish(EventJobFinished, *job)
+		}
+	}
+}
+
+// Start recovers jobs interrupted by a restart and starts running jobs with
+// the given number of workers
+func (s *JobService) Start(workers int) {
+	s.recoverInterrupted()
+	go s.dispatch(workers)
+}
+
+// recoverInterrupted requeues jobs that were running when the server stopped,
+// if their type can be resumed
+func (s *JobService) recoverInterrupted() {
+	var jobs []model.Job
+	if err := s.db.Where("status = ?", model.JobRunning).Find(&jobs).Error; err != nil {
+		log.Printf("Jobs: failed to load interrupted jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		updates := map[string]interface{}{"status": model.JobQueued, "run_at": time.Now()}
+		if t, ok := s.types[job.Type]; !ok || !t.Resume {
+			updates = map[string]interface{}{"status": model.JobFailed, "error": "interrupted by a restart", "finished_at": time.Now()}
+		}
+		s.db.Model(&model.Job{}).Where("id = ?", job.ID).UpdateColumns(updates)
+	}
+}
+
+func (s *JobService) dispatch(workers int) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	free := workers
+	done := make(chan struct{})
+	lastCleanup := time.Time{}
+	for {
+		for free > 0 {
+			job := s.claim()
+			if job == nil {
+				break
+			}
+			free--
+			go func() {
+				s.execute(job)
+				done <- struct{}{}
+			}()
+		}
+
+		if time.Since(lastCleanup) > time.Hour {
+			s.db.Where("status IN ? AND finished_at < ?", []string{model.JobSucceeded, model.JobFailed, model.JobCanceled}, time.Now().Add(-jobRetention)).
+				Delete(&model.Job{})
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-done:
+			free++
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim marks the next due job as running and returns it
+func (s *JobService) claim() *model.Job {
+	var job model.Job
+	err := s.db.Where("status = ? AND run_at <= ?", model.JobQueued, time.Now()).Order("run_at, id").First(&job).Error
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	// Conditional on the status, as the job may have been canceled in the meantime
+	result := s.db.Model(&model.Job{}).Where("id = ? AND status = ?", job.ID, model.JobQueued).
+		UpdateColumns(map[string]interface{}{"status": model.JobRunning, "attempts": job.Attempts + 1, "started_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil
+	}
+	job.Status = model.JobRunning
+	job.Attempts++
+	job.StartedAt = &now
+	s.events.Publish(EventJobUpdated, job)
+	return &job
+}
+
+func (s *JobService) execute(job *model.Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancels[job.ID] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, job.ID)
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	var result interface{}
+	var err error
+	t, ok := s.types[job.Type]
+	if ok {
+		result, err = s.call(ctx, t, &JobRun{job: job, db: s.db, events: s.events})
+	} else {
+		err = NoRetry(fmt.Errorf("unknown job type %q", job.Type))
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"finished_at": now}
+	switch {
+	case ctx.Err() != nil:
+		updates["status"] = model.JobCanceled
+	case err == nil:
+		data, _ := json.Marshal(result)
+		updates["status"] = model.JobSucceeded
+		updates["result"] = string(data)
+		updates["error"] = ""
+	case job.Attempts < job.MaxAttempts && !errors.As(err, &noRetryError{}):
+		backoff := t.Backoff << (job.Attempts - 1)
+		if backoff > maxJobBackoff || backoff <= 0 {
+			backoff = maxJobBackoff
+		}
+		log.Printf("Jobs: %s job %d failed (attempt %d of %d), retrying in %s: %v", job.Type, job.ID, job.Attempts, job.MaxAttempts, backoff, err)
+		updates = map[string]interface{}{"status": model.JobQueued, "error": err.Error(), "run_at": now.Add(backoff)}
+	default:
+		log.Printf("Jobs: %s job %d failed: %v", job.Type, job.ID, err)
+		updates["status"] = model.JobFailed
+		updates["error"] = err.Error()
+	}
+
+	if err := s.db.Model(&model.Job{}).Where("id = ?", job.ID).UpdateColumns(updates).Error; err != nil {
+		log.Printf("Jobs: failed to save job %d: %v", job.ID, err)
+	}
+	s.publish(job.ID)
+}
+
+// call runs a job, turning a panic into an error
+func (s *JobService) call(ctx context.Context, t JobType, run *JobRun) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = NoRetry(fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return t.Run(ctx, run)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobService(t *testing.T) {
+	db := setupTestDB(t)
+	jobs := NewJobService(db, nil)
+
+	// Fails once, then succeeds
+	jobs.Register("flaky", JobType{
+		Run: func(ctx context.Context, run *JobRun) (interface{}, error) {
+			var payload struct{ N int }
+			require.NoError(t, run.Payload(&payload))
+			run.Update(func(p *model.JobProgress) { p.Total = payload.N })
+			var job model.Job
+			db.First(&job, run.ID())
+			if job.Attempts < 2 {
+				return nil, errors.New("temporary")
+			}
+			return map[string]int{"n": payload.N}, nil
+		},
+		MaxAttempts: 3,
+		Backoff:     10 * time.Millisecond,
+	})
+	jobs.Register("broken", JobType{
+		Run: func(ctx context.Context, run *JobRun) (interface{}, error) {
+			return nil, NoRetry(errors.New("bad input"))
+		},
+		MaxAttempts: 3,
+	})
+	jobs.Register("slow", JobType{
+		Run: func(ctx context.Context, run *JobRun) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	jobs.Register("resumable", JobType{
+		Run: func(ctx context.Context, run *JobRun) (interface{}, error) {
+			return nil, nil
+		},
+		Resume: true,
+	})
+
+	// Jobs left running by a restart are resumed or failed
+	interrupted := []model.Job{
+		{Type: "resumable", Status: model.JobRunning, MaxAttempts: 1, RunAt: time.Now()},
+		{Type: "broken", Status: model.JobRunning, MaxAttempts: 1, RunAt: time.Now()},
+	}
+	require.NoError(t, db.Create(&interrupted).Error)
+
+	// Queued jobs are deduplicated by key
+	slow, err := jobs.Enqueue("slow", "key", nil)
+	require.NoError(t, err)
+	again, err := jobs.Enqueue("slow", "key", nil)
+	require.NoError(t, err)
+	assert.Equal(t, slow.ID, again.ID)
+
+	jobs.Start(2)
+
+	finished := func(id uint) *model.Job {
+		var job *model.Job
+		require.Eventually(t, func() bool {
+			job, err = jobs.Get(id)
+			return err == nil && job.Finished()
+		}, 5*time.Second, 10*time.Millisecond)
+		return job
+	}
+
+	assert.Equal(t, model.JobSucceeded, finished(interrupted[0].ID).Status)
+	failed := finished(interrupted[1].ID)
+	assert.Equal(t, model.JobFailed, failed.Status)
+	assert.Equal(t, "interrupted by a restart", failed.Error)
+
+	flaky, err := jobs.Enqueue("flaky", "", map[string]int{"N": 5})
+	require.NoError(t, err)
+	job := finished(flaky.ID)
+	assert.Equal(t, model.JobSucceeded, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, 5, job.Progress.Total)
+	assert.JSONEq(t, `{"n": 5}`, job.Result)
+
+	broken, err := jobs.Enqueue("broken", "", nil)
+	require.NoError(t, err)
+	job = finished(broken.ID)
+	assert.Equal(t, model.JobFailed, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "bad input", job.Error)
+
+	// Running jobs stop when canceled
+	require.Eventually(t, func() bool {
+		job, _ := jobs.Get(slow.ID)
+		return job.Status == model.JobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = jobs.Cancel(slow.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobCanceled, finished(slow.ID).Status)
+	_, err = jobs.Cancel(slow.ID)
+	assert.Error(t, err)
+
+	// A new job with the same key may start once the first one finished
+	again, err = jobs.Enqueue("slow", "key", nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, slow.ID, again.ID)
+
+	_, err = jobs.Enqueue("unknown", "", nil)
+	assert.Error(t, err)
+}
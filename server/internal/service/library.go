@@ -0,0 +1,256 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LibraryService manages albums and tags, which group images across all sources
+type LibraryService struct {
+	db *gorm.DB
+}
+
+func NewLibraryService(db *gorm.DB) *LibraryService {
+	return &LibraryService{db: db}
+}
+
+// FindOrCreateAlbum returns the album with the given name, creating it if needed
+func (s *LibraryService) FindOrCreateAlbum(name string) (*model.Album, error) {
+	album := model.Album{Name: strings.TrimSpace(name)}
+	if err := s.db.Where("name = ?", album.Name).FirstOrCreate(&album).Error; err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// FindOrCreateTag returns the tag with the given name, creating it if needed.
+// Tag names are case-insensitive and stored in lower case.
+func (s *LibraryService) FindOrCreateTag(name string) (*model.Tag, error) {
+	tag := model.Tag{Name: strings.ToLower(strings.TrimSpace(name))}
+	if err := s.db.Where("name = ?", tag.Name).FirstOrCreate(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// AddToAlbum adds images to the named album, creating the album if needed
+func (s *LibraryService) AddToAlbum(imageIDs []uint, albumName string) error {
+	album, err := s.FindOrCreateAlbum(albumName)
+	if err != nil {
+		return err
+	}
+	return s.link("image_albums", "album_id", album.ID, imageIDs)
+}
+
+// AddTags tags images with the given tag names, creating tags if needed
+func (s *LibraryService) AddTags(imageIDs []uint, tagNames []string) error {
+	for _, name := range tagNames {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		tag, err := s.FindOrCreateTag(name)
+		if err != nil {
+			return err
+		}
+		if err := s.link("image_tags", "tag_id", tag.ID, imageIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveTags removes the given tags from images. Tags themselves are kept.
+func (s *LibraryService) RemoveTags(imageIDs []uint, tagNames []string) error {
+	names := make([]string, 0, len(tagNames))
+	for _, name := range tagNames {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(imageIDs) == 0 || len(names) == 0 {
+		return nil
+	}
+	return s.db.Exec("DELETE FROM image_tags WHERE image_id IN ? AND tag_id IN (SELECT id FROM tags WHERE name IN ?)", imageIDs, names).Error
+}
+
+// link adds rows to a join table, ignoring images that are already linked
+func (s *LibraryService) link(table, column string, id uint, imageIDs []uint) error {
+	rows := make([]map[string]interface{}, 0, len(imageIDs))
+	for _, imageID := range imageIDs {
+		rows = append(rows, map[string]interface{}{"image_id": imageID, column: id})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return s.db.Table(table).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// AlbumSummary is an album with the number of photos in it
+type AlbumSummary struct {
+	model.Album
+	PhotoCount int64 `json:"photo_count"`
+}
+
+// TagSummary is a tag with the number of photos carrying it
+type TagSummary struct {
+	model.Tag
+	PhotoCount int64 `json:"photo_count"`
+}
+
+// ListAlbums returns all albums by name, with their photo counts
+func (s *LibraryService) ListAlbums() ([]AlbumSummary, error) {
+	albums := []AlbumSummary{}
+	err := s.db.Table("albums").
+		Select("albums.*, COUNT(images.id) AS photo_count").
+		Joins("LEFT JOIN image_albums ON image_albums.album_id = albums.id").
+		Joins("LEFT JOIN images ON images.id = image_albums.image_id AND images.deleted_at IS NULL").
+		Group("albums.id").
+		Order("albums.name").
+		Scan(&albums).Error
+	return albums, err
+}
+
+// GetAlbum returns an album by ID
+func (s *LibraryService) GetAlbum(id uint) (*model.Album, error) {
+	var album model.Album
+	if err := s.db.First(&album, id).Error; err != nil {
+		return nil, errors.New("album not found")
+	}
+	return &album, nil
+}
+
+// CreateAlbum creates an empty album
+func (s *LibraryService) CreateAlbum(name string) (*model.Album, error) {
+	album := model.Album{Name: strings.TrimSpace(name)}
+	if err := s.checkName("albums", album.Name, 0); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&album).Error; err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// RenameAlbum changes the name of an album
+func (s *LibraryService) RenameAlbum(id uint, name string) (*model.Album, error) {
+	album, err := s.GetAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+	album.Name = strings.TrimSpace(name)
+	if err := s.checkName("albums", album.Name, id); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(album).Update("name", album.Name).Error; err != nil {
+		return nil, err
+	}
+	return album, nil
+}
+
+// DeleteAlbum deletes an album. Its photos stay in the gallery.
+func (s *LibraryService) DeleteAlbum(id uint) error {
+	if _, err := s.GetAlbum(id); err != nil {
+		return err
+	}
+	if err := s.db.Exec("DELETE FROM image_albums WHERE album_id = ?", id).Error; err != nil {
+		return err
+	}
+	return s.db.Delete(&model.Album{}, id).Error
+}
+
+// AddToAlbumID adds images to an existing album
+func (s *LibraryService) AddToAlbumID(albumID uint, imageIDs []uint) error {
+	if _, err := s.GetAlbum(albumID); err != nil {
+		return err
+	}
+	return s.link("image_albums", "album_id", albumID, imageIDs)
+}
+
+// RemoveFromAlbum removes images from an album
+func (s *LibraryService) RemoveFromAlbum(albumID uint, imageIDs []uint) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	return s.db.Exec("DELETE FROM image_albums WHERE album_id = ? AND image_id IN ?", albumID, imageIDs).Error
+}
+
+// ListTags returns all tags by name, with their photo counts
+func (s *LibraryService) ListTags() ([]TagSummary, error) {
+	tags := []TagSummary{}
+	err := s.db.Table("tags").
+		Select("tags.*, COUNT(images.id) AS photo_count").
+		Joins("LEFT JOIN image_tags ON image_tags.tag_id = tags.id").
+		Joins("LEFT JOIN images ON images.id = image_tags.image_id AND images.deleted_at IS NULL").
+		Group("tags.id").
+		Order("tags.name").
+		Scan(&tags).Error
+	return tags, err
+}
+
+// RenameTag changes the name of a tag
+func (s *LibraryService) RenameTag(id uint, name string) (*model.Tag, error) {
+	var tag model.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		return nil, errors.New("tag not found")
+	}
+	tag.Name = strings.ToLower(strings.TrimSpace(name))
+	if err := s.checkName("tags", tag.Name, id); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&tag).Update("name", tag.Name).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// DeleteTag deletes a tag and removes it from all photos
+func (s *LibraryService) DeleteTag(id uint) error {
+	result := s.db.Delete(&model.Tag{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("tag not found")
+	}
+	return s.db.Exec("DELETE FROM image_tags WHERE tag_id = ?", id).Error
+}
+
+// checkName rejects empty names and names used by another album or tag
+func (s *LibraryService) checkName(table, name string, id uint) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	var count int64
+	s.db.Table(table).Where("name = ? AND id != ?", name, id).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("%q already exists", name)
+	}
+	return nil
+}
+
+// ParseTags splits a comma separated tag list
+func ParseTags(value string) []string {
+	var tags []string
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// Detach removes album and tag links of permanently deleted images
+func (s *LibraryService) Detach(imageIDs []uint) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	if err := s.db.Exec("DELETE FROM image_albums WHERE image_id IN ?", imageIDs).Error; err != nil {
+		return err
+	}
+	return s.db.Exec("DELETE FROM image_tags WHERE image_id IN ?", imageIDs).Error
+}
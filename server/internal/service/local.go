@@ -0,0 +1,419 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	"github.com/fsnotify/fsnotify"
+	"gorm.io/gorm"
+)
+
+// How long to wait for a file to settle (e.g. while it is still being copied)
+// before importing it after a filesystem event.
+const localEventDebounce = 2 * time.Second
+
+type LocalScanResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// LocalSourceService keeps `local` images in sync with one or more folders on disk.
+// Network shares (SMB/NFS) usually do not deliver filesystem events for remote
+// changes, so fsnotify is complemented by a periodic full rescan.
+type LocalSourceService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	trash    *TrashService
+	dataDir  string
+
+	scanMu  sync.Mutex // Serializes scans
+	mu      sync.Mutex // Guards watcher, stop and pending
+	watcher *fsnotify.Watcher
+	stop    chan struct{}
+	pending map[string]*time.Timer
+}
+
+func NewLocalSourceService(db *gorm.DB, settings *SettingsService, trash *TrashService, dataDir string) *LocalSourceService {
+	return &LocalSourceService{
+		db:       db,
+		settings: settings,
+		trash:    trash,
+		dataDir:  dataDir,
+		pending:  make(map[string]*time.Timer),
+	}
+}
+
+// Directories returns the configured folders. Relative paths are resolved against DATA_DIR.
+func (s *LocalSourceService) Directories() []string {
+	raw, _ := s.settings.Get("local_dirs")
+
+	var dirs []string
+	for _, d := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == ',' }) {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(s.dataDir, d)
+		}
+		dirs = append(dirs, filepath.Clean(d))
+	}
+	return dirs
+}
+
+func (s *LocalSourceService) rescanInterval() time.Duration {
+	val, _ := s.settings.Get("local_rescan_interval")
+	if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return time.Hour
+}
+
+// Restart stops any running watcher and starts watching the configured folders
+func (s *LocalSourceService) Restart() {
+	s.Stop()
+
+	dirs := s.Directories()
+	if len(dirs) == 0 {
+		log.Println("Local folder source disabled (no directories configured)")
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Failed to create filesystem watcher, relying on periodic rescan: %v", err)
+	}
+
+	stop := make(chan struct{})
+	s.mu.Lock()
+	s.watcher = watcher
+	s.stop = stop
+	s.mu.Unlock()
+
+	if watcher != nil {
+		for _, dir := range dirs {
+			s.watchRecursive(dir)
+		}
+		go s.watchLoop(watcher, stop)
+	}
+
+	go s.rescanLoop(stop)
+	log.Printf("Local folder source started for %v", dirs)
+}
+
+// Stop stops watching and periodic rescans
+func (s *LocalSourceService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	if s.watcher != nil {
+		s.watcher.Close()
+		s.watcher = nil
+	}
+	for path, t := range s.pending {
+		t.Stop()
+		delete(s.pending, path)
+	}
+}
+
+func (s *LocalSourceService) rescanLoop(stop chan struct{}) {
+	if _, err := s.Scan(); err != nil {
+		log.Printf("Local folder scan failed: %v", err)
+	}
+
+	ticker := time.NewTicker(s.rescanInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := s.Scan(); err != nil {
+				log.Printf("Local folder scan failed: %v", err)
+			}
+		}
+	}
+}
+
+func (s *LocalSourceService) watchLoop(watcher *fsnotify.Watcher, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			s.schedule(event.Name)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Local folder watcher error: %v", err)
+		}
+	}
+}
+
+// schedule debounces events per path and processes the path once it settles
+func (s *LocalSourceService) schedule(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		return
+	}
+	if t, ok := s.pending[path]; ok {
+		t.Reset(localEventDebounce)
+		return
+	}
+	s.pending[path] = time.AfterFunc(localEventDebounce, func() {
+		s.mu.Lock()
+		delete(s.pending, path)
+		s.mu.Unlock()
+		s.handlePath(path)
+	})
+}
+
+func (s *LocalSourceService) watchRecursive(root string) {
+	s.mu.Lock()
+	watcher := s.watcher
+	s.mu.Unlock()
+	if watcher == nil {
+		return
+	}
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && skipLocalDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			if err := watcher.Add(path); err != nil {
+				// Usually the inotify watch limit; the periodic rescan still covers it
+				log.Printf("Failed to watch %s: %v", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// handlePath processes a single changed path reported by the watcher
+func (s *LocalSourceService) handlePath(path string) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// File or whole directory removed (or renamed away)
+			if n := s.removeUnder(path); n > 0 {
+				log.Printf("Local folder: removed %d photos under %s", n, path)
+			}
+		}
+		return
+	}
+
+	if info.IsDir() {
+		if skipLocalDir(info.Name()) {
+			return
+		}
+		s.watchRecursive(path)
+		var result LocalScanResult
+		s.walk(path, &result, nil)
+		return
+	}
+
+	if imageops.IsSupportedFile(path) {
+		var result LocalScanResult
+		s.syncFile(path, info, &result)
+	}
+}
+
+// Scan walks all configured folders, imports new and changed files and
+// removes rows whose files no longer exist.
+func (s *LocalSourceService) Scan() (*LocalScanResult, error) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	dirs := s.Directories()
+	result := &LocalScanResult{}
+	seen := make(map[string]bool)
+
+	// Folders that could not be read (e.g. share not mounted) keep their rows
+	var unavailable []string
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			log.Printf("Local folder %s is unavailable, skipping", dir)
+			unavailable = append(unavailable, dir)
+			continue
+		}
+		s.walk(dir, result, seen)
+	}
+
+	var items []model.Image
+	if err := s.db.Unscoped().Where("source = ?", "local").Find(&items).Error; err != nil {
+		return result, err
+	}
+	for _, item := range items {
+		if seen[item.FilePath] || isUnderAny(item.FilePath, unavailable) {
+			continue
+		}
+		s.deleteItem(item)
+		result.Removed++
+	}
+
+	log.Printf("Local folder scan complete: added=%d updated=%d removed=%d", result.Added, result.Updated, result.Removed)
+	return result, nil
+}
+
+func (s *LocalSourceService) walk(root string, result *LocalScanResult, seen map[string]bool) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("Local folder: cannot read %s: %v", path, err)
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && skipLocalDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !imageops.IsSupportedFile(path) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if seen != nil {
+			seen[path] = true
+		}
+		s.syncFile(path, info, result)
+		return nil
+	})
+}
+
+// syncFile creates or refreshes the row for a single file
+func (s *LocalSourceService) syncFile(path string, info fs.FileInfo, result *LocalScanResult) {
+	modTime := info.ModTime()
+	var existing model.Image
+	err := s.db.Unscoped().Where("source = ? AND file_path = ?", "local", path).First(&existing).Error
+	if err == nil {
+		// Deleted from the gallery by the user: keep it out of rotation
+		if existing.DeletedAt.Valid {
+			return
+		}
+		if existing.FileModTime != nil && existing.FileModTime.Equal(info.ModTime()) {
+			return
+		}
+
+		width, height, orientation, err := imageops.ReadInfo(path)
+		if err != nil {
+			log.Printf("Local folder: failed to read %s: %v", path, err)
+			return
+		}
+		existing.Width = width
+		existing.Height = height
+		existing.Orientation = orientation
+		existing.CapturedAt = imageops.ReadCaptureTime(path)
+		existing.PHash = hashPhoto(path)
+		existing.FileModTime = &modTime
+		if err := s.db.Save(&existing).Error; err != nil {
+			log.Printf("Local folder: failed to update %s: %v", path, err)
+			return
+		}
+		// Thumbnail is regenerated on next request
+		os.Remove(filepath.Join(s.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", existing.ID)))
+		result.Updated++
+		return
+	}
+
+	width, height, orientation, err := imageops.ReadInfo(path)
+	if err != nil {
+		log.Printf("Local folder: skipping unreadable file %s: %v", path, err)
+		return
+	}
+
+	img := model.Image{
+		FilePath:    path,
+		Source:      "local",
+		UserID:      1,
+		Status:      "pending",
+		CreatedAt:   time.Now(),
+		CapturedAt:  imageops.ReadCaptureTime(path),
+		Width:       width,
+		Height:      height,
+		Orientation: orientation,
+		PHash:       hashPhoto(path),
+		FileModTime: &modTime,
+	}
+	if err := s.db.Create(&img).Error; err != nil {
+		log.Printf("Local folder: failed to import %s: %v", path, err)
+		return
+	}
+	result.Added++
+}
+
+// removeUnder deletes rows for a removed file, or for all files under a removed directory
+func (s *LocalSourceService) removeUnder(path string) int {
+	var items []model.Image
+	s.db.Unscoped().
+		Where("source = ? AND (file_path = ? OR file_path LIKE ?)", "local", path, path+string(filepath.Separator)+"%").
+		Find(&items)
+
+	for _, item := range items {
+		s.deleteItem(item)
+	}
+	return len(items)
+}
+
+func (s *LocalSourceService) deleteItem(item model.Image) {
+	if err := s.trash.Remove([]model.Image{item}); err != nil {
+		log.Printf("Local folder: failed to remove %s: %v", item.FilePath, err)
+	}
+}
+
+// GetPhotoCount returns the number of local folder photos in the database
+func (s *LocalSourceService) GetPhotoCount() (int64, error) {
+	var count int64
+	if err := s.db.Model(&model.Image{}).Where("source = ?", "local").Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// skipLocalDir skips hidden folders and NAS metadata folders (Synology @eaDir, #recycle)
+func skipLocalDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "@") || strings.HasPrefix(name, "#")
+}
+
+func isUnderAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalSourceService_Scan(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	trash := NewTrashService(db, settings, NewLibraryService(db), NewHistoryService(db, nil), NewCacheService(db, settings, dataDir), dataDir)
+	svc := NewLocalSourceService(db, settings, trash, dataDir)
+
+	dir := filepath.Join(dataDir, "photos")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, "photo.png")
+	writePNG := func(w, h int) {
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, w, h))))
+		f.Close()
+	}
+	writePNG(120, 80)
+	require.NoError(t, settings.Set("local_dirs", "photos"))
+
+	result, err := svc.Scan()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Added)
+
+	// Edits in the gallery don't count as file changes
+	var item model.Image
+	require.NoError(t, db.First(&item).Error)
+	item.Caption = "edited"
+	require.NoError(t, db.Save(&item).Error)
+	result, err = svc.Scan()
+	require.NoError(t, err)
+	assert.Equal(t, LocalScanResult{}, *result)
+
+	// A replaced file is read again, even with an older modification time
+	writePNG(80, 120)
+	old := time.Now().Add(-24 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+	result, err = svc.Scan()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	require.NoError(t, db.First(&item, item.ID).Error)
+	assert.Equal(t, "portrait", item.Orientation)
+}
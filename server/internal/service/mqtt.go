@@ -0,0 +1,427 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	xdraw "golang.org/x/image/draw"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMQTTTopicPrefix     = "photoframe"
+	defaultMQTTDiscoveryPrefix = "homeassistant"
+
+	mqttSourceAll    = "all" // Select option for all sources, stored as empty
+	mqttPreviewSize  = 640   // Max width and height of the camera image
+	mqttPublishLimit = 10 * time.Second
+)
+
+// MQTTService connects to an MQTT broker and exposes every device to Home
+// Assistant through MQTT discovery: a "next photo" button, a collage switch,
+// a source select, a last-seen sensor and a camera showing the current photo.
+// Commands from Home Assistant are executed through DeviceService.
+type MQTTService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	devices  *DeviceService
+	remote   *RemotePhotoService
+	history  *HistoryService
+	events   *EventBus
+	dataDir  string
+
+	mu     sync.Mutex // Guards client and stop
+	client mqtt.Client
+	stop   chan struct{}
+}
+
+func NewMQTTService(db *gorm.DB, settings *SettingsService, devices *DeviceService, remote *RemotePhotoService, history *HistoryService, events *EventBus, dataDir string) *MQTTService {
+	return &MQTTService{
+		db:       db,
+		settings: settings,
+		devices:  devices,
+		remote:   remote,
+		history:  history,
+		events:   events,
+		dataDir:  dataDir,
+	}
+}
+
+// Restart (re)connects to the broker configured in mqtt_broker, or stays
+// disconnected if it is empty
+func (s *MQTTService) Restart() {
+	s.Stop()
+
+	broker, _ := s.settings.Get("mqtt_broker")
+	if broker == "" {
+		return
+	}
+	username, _ := s.settings.Get("mqtt_username")
+	password, _ := s.settings.Get("mqtt_password")
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(fmt.Sprintf("photoframe-server-%d", time.Now().UnixNano())).
+		SetUsername(username).
+		SetPassword(password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(30*time.Second).
+		SetWill(s.availabilityTopic(), "offline", 1, true).
+		SetOnConnectHandler(s.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("MQTT: connection lost: %v", err)
+		})
+	client := mqtt.NewClient(opts)
+
+	stop := make(chan struct{})
+	s.mu.Lock()
+	s.client = client
+	s.stop = stop
+	s.mu.Unlock()
+
+	// Retries in the background until the broker is reachable
+	client.Connect()
+	go s.eventLoop(stop)
+}
+
+// Stop marks the server offline and disconnects from the broker
+func (s *MQTTService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	if s.client != nil {
+		if s.client.IsConnected() {
+			s.client.Publish(s.availabilityTopic(), 1, true, "offline").WaitTimeout(mqttPublishLimit)
+		}
+		s.client.Disconnect(250)
+		s.client = nil
+	}
+}
+
+func (s *MQTTService) topicPrefix() string {
+	if prefix, _ := s.settings.Get("mqtt_topic_prefix"); prefix != "" {
+		return strings.TrimSuffix(prefix, "/")
+	}
+	return defaultMQTTTopicPrefix
+}
+
+func (s *MQTTService) discoveryPrefix() string {
+	if prefix, _ := s.settings.Get("mqtt_discovery_prefix"); prefix != "" {
+		return strings.TrimSuffix(prefix, "/")
+	}
+	return defaultMQTTDiscoveryPrefix
+}
+
+// availabilityTopic is "online" while the server is connected
+func (s *MQTTService) availabilityTopic() string {
+	return s.topicPrefix() + "/status"
+}
+
+// deviceTopic is the topic of a device entity, e.g. photoframe/3/collage
+func (s *MQTTService) deviceTopic(deviceID uint, entity string) string {
+	return fmt.Sprintf("%s/%d/%s", s.topicPrefix(), deviceID, entity)
+}
+
+// publish sends a message if connected. Errors are logged: Home Assistant
+// catches up with the retained state on the next change.
+func (s *MQTTService) publish(topic string, retained bool, payload interface{}) {
+	s.mu.Lock()
+	client := s.client
+	s.mu.Unlock()
+	if client == nil || !client.IsConnected() {
+		return
+	}
+	token := client.Publish(topic, 1, retained, payload)
+	if !token.WaitTimeout(mqttPublishLimit) {
+		log.Printf("MQTT: timed out publishing to %s", topic)
+	} else if err := token.Error(); err != nil {
+		log.Printf("MQTT: failed to publish to %s: %v", topic, err)
+	}
+}
+
+// onConnect runs on every (re)connect: subscriptions and discovery configs
+// don't survive a reconnect to a broker without persistent sessions
+func (s *MQTTService) onConnect(client mqtt.Client) {
+	log.Printf("MQTT: connected")
+	topic := s.topicPrefix() + "/+/+/set"
+	if token := client.Subscribe(topic, 1, s.onCommand); token.WaitTimeout(mqttPublishLimit) && token.Error() != nil {
+		log.Printf("MQTT: failed to subscribe to %s: %v", topic, token.Error())
+	}
+	client.Publish(s.availabilityTopic(), 1, true, "online")
+
+	go func() {
+		devices, err := s.devices.ListDevices()
+		if err != nil {
+			log.Printf("MQTT: failed to list devices: %v", err)
+			return
+		}
+		for _, device := range devices {
+			s.announce(device)
+			s.publishImage(device.ID, nil)
+		}
+	}()
+}
+
+// eventLoop mirrors device changes and displays to MQTT until stop is closed
+func (s *MQTTService) eventLoop(stop chan struct{}) {
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-events:
+			switch event.Type {
+			case EventDeviceUpdated:
+				if device, ok := event.Data.(model.Device); ok {
+					s.announce(device)
+				}
+			case EventDeviceDeleted:
+				if data, ok := event.Data.(map[string]interface{}); ok {
+					if id, ok := data["device_id"].(uint); ok {
+						s.forget(id)
+					}
+				}
+			case EventDeviceSeen:
+				if data, ok := event.Data.(map[string]interface{}); ok {
+					id, _ := data["device_id"].(uint)
+					if seen, ok := data["last_seen_at"].(time.Time); ok {
+						s.publish(s.deviceTopic(id, "last_seen"), true, seen.UTC().Format(time.RFC3339))
+					}
+				}
+			case EventPhotoDisplayed:
+				if data, ok := event.Data.(map[string]interface{}); ok {
+					id, _ := data["device_id"].(uint)
+					ids, _ := data["image_ids"].([]uint)
+					if id != 0 && len(ids) > 0 {
+						go s.publishImage(id, &ids[0])
+					}
+				}
+			}
+		}
+	}
+}
+
+// mqttEntity is a Home Assistant entity of a device
+type mqttEntity struct {
+	component string // Home Assistant platform, e.g. "switch"
+	object    string // Unique within the device, also the topic name
+	config    map[string]interface{}
+}
+
+func (s *MQTTService) entities(device model.Device) []mqttEntity {
+	options := append([]string{mqttSourceAll}, DeviceSources...)
+	return []mqttEntity{
+		{component: "button", object: "next", config: map[string]interface{}{
+			"name":          "Next photo",
+			"icon":          "mdi:skip-next",
+			"command_topic": s.deviceTopic(device.ID, "next/set"),
+		}},
+		{component: "switch", object: "collage", config: map[string]interface{}{
+			"name":          "Collage",
+			"icon":          "mdi:view-split-vertical",
+			"state_topic":   s.deviceTopic(device.ID, "collage"),
+			"command_topic": s.deviceTopic(device.ID, "collage/set"),
+		}},
+		{component: "select", object: "source", config: map[string]interface{}{
+			"name":          "Photo source",
+			"icon":          "mdi:image-multiple",
+			"state_topic":   s.deviceTopic(device.ID, "source"),
+			"command_topic": s.deviceTopic(device.ID, "source/set"),
+			"options":       options,
+		}},
+		{component: "sensor", object: "last_seen", config: map[string]interface{}{
+			"name":            "Last seen",
+			"device_class":    "timestamp",
+			"entity_category": "diagnostic",
+			"state_topic":     s.deviceTopic(device.ID, "last_seen"),
+		}},
+		{component: "camera", object: "image", config: map[string]interface{}{
+			"name":  "Current photo",
+			"topic": s.deviceTopic(device.ID, "image"),
+		}},
+	}
+}
+
+// discoveryTopic is where Home Assistant looks for an entity's config
+func (s *MQTTService) discoveryTopic(deviceID uint, entity mqttEntity) string {
+	return fmt.Sprintf("%s/%s/photoframe_%d/%s/config", s.discoveryPrefix(), entity.component, deviceID, entity.object)
+}
+
+// announce publishes the discovery configs and the state of a device
+func (s *MQTTService) announce(device model.Device) {
+	for _, entity := range s.entities(device) {
+		config := entity.config
+		config["unique_id"] = fmt.Sprintf("photoframe_%d_%s", device.ID, entity.object)
+		config["availability_topic"] = s.availabilityTopic()
+		config["device"] = map[string]interface{}{
+			"identifiers":  []string{fmt.Sprintf("photoframe_%d", device.ID)},
+			"name":         device.Name,
+			"manufacturer": "ESP32 PhotoFrame",
+			"model":        fmt.Sprintf("%dx%d %s", device.Width, device.Height, device.Orientation),
+		}
+		data, err := json.Marshal(config)
+		if err != nil {
+			continue
+		}
+		s.publish(s.discoveryTopic(device.ID, entity), true, data)
+	}
+
+	collage := "OFF"
+	if device.EnableCollage {
+		collage = "ON"
+	}
+	s.publish(s.deviceTopic(device.ID, "collage"), true, collage)
+	source := device.Source
+	if source == "" {
+		source = mqttSourceAll
+	}
+	s.publish(s.deviceTopic(device.ID, "source"), true, source)
+	if device.LastSeenAt != nil {
+		s.publish(s.deviceTopic(device.ID, "last_seen"), true, device.LastSeenAt.UTC().Format(time.RFC3339))
+	}
+}
+
+// forget removes a deleted device from Home Assistant. Empty retained
+// messages delete the discovery configs and the retained states.
+func (s *MQTTService) forget(deviceID uint) {
+	for _, entity := range s.entities(model.Device{ID: deviceID}) {
+		s.publish(s.discoveryTopic(deviceID, entity), true, "")
+	}
+	for _, topic := range []string{"collage", "source", "last_seen", "image"} {
+		s.publish(s.deviceTopic(deviceID, topic), true, "")
+	}
+}
+
+// onCommand handles <prefix>/<device id>/<entity>/set
+func (s *MQTTService) onCommand(_ mqtt.Client, msg mqtt.Message) {
+	parts := strings.Split(strings.TrimPrefix(msg.Topic(), s.topicPrefix()+"/"), "/")
+	if len(parts) != 3 {
+		return
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return
+	}
+	payload := strings.TrimSpace(string(msg.Payload()))
+
+	// Handlers run in the background: the client waits for them before
+	// handling the next message
+	go func() {
+		var err error
+		switch parts[1] {
+		case "next":
+			err = s.Next(uint(id))
+		case "collage":
+			_, err = s.devices.SetCollage(uint(id), strings.EqualFold(payload, "ON"))
+		case "source":
+			if payload == mqttSourceAll {
+				payload = ""
+			}
+			_, err = s.devices.SetSource(uint(id), payload)
+		default:
+			err = fmt.Errorf("unknown command %q", parts[1])
+		}
+		if err != nil {
+			log.Printf("MQTT: %s command for device %d failed: %v", parts[1], id, err)
+		}
+	}()
+}
+
+// Next pushes a random photo of the device's source to the device
+func (s *MQTTService) Next(deviceID uint) error {
+	var device model.Device
+	if err := s.db.First(&device, deviceID).Error; err != nil {
+		return errors.New("device not found")
+	}
+
+	query := s.db
+	if device.Source != "" {
+		query = query.Where("source = ?", device.Source)
+	}
+	item, err := PickWeightedImage(query, s.settings.FavoriteWeight())
+	if err != nil {
+		return errors.New("no photos to show")
+	}
+	return pushPhoto(s.devices, s.remote, s.history, deviceID, *item)
+}
+
+// publishImage publishes a preview of a photo as the device's camera image.
+// Without an image ID, the photo last shown on the device is used.
+func (s *MQTTService) publishImage(deviceID uint, imageID *uint) {
+	var item *model.Image
+	var err error
+	if imageID != nil {
+		item = &model.Image{}
+		err = s.db.First(item, *imageID).Error
+	} else {
+		item, err = s.history.LastShown(deviceID)
+	}
+	if err != nil {
+		return
+	}
+
+	data, err := s.preview(*item)
+	if err != nil {
+		log.Printf("MQTT: failed to create preview of photo %d: %v", item.ID, err)
+		return
+	}
+	s.publish(s.deviceTopic(deviceID, "image"), true, data)
+}
+
+// preview returns a JPEG of a photo small enough for an MQTT message
+func (s *MQTTService) preview(item model.Image) ([]byte, error) {
+	if IsRemoteSource(item.Source) {
+		return s.remote.Fetch(item, VariantThumbnail)
+	}
+
+	f, err := os.Open(item.FilePath)
+	if err != nil {
+		// Fall back to the gallery thumbnail
+		return os.ReadFile(filepath.Join(s.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID)))
+	}
+	defer f.Close()
+	img, _, err := imageops.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > mqttPreviewSize || h > mqttPreviewSize {
+		if w > h {
+			w, h = mqttPreviewSize, h*mqttPreviewSize/w
+		} else {
+			w, h = w*mqttPreviewSize/h, mqttPreviewSize
+		}
+	}
+	w, h = max(w, 1), max(h, 1)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, bounds, xdraw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
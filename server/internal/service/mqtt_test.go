@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	broker "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startBroker runs an embedded MQTT broker and records the last message of
+// every topic
+func startBroker(t *testing.T) (addr string, server *broker.Server, last func(topic string) (string, bool)) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr = l.Addr().String()
+	l.Close()
+
+	server = broker.New(&broker.Options{InlineClient: true, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	require.NoError(t, server.AddHook(new(auth.AllowHook), nil))
+	require.NoError(t, server.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})))
+	require.NoError(t, server.Serve())
+	t.Cleanup(func() { server.Close() })
+
+	var mu sync.Mutex
+	messages := map[string]string{}
+	require.NoError(t, server.Subscribe("#", 1, func(_ *broker.Client, _ packets.Subscription, pk packets.Packet) {
+		mu.Lock()
+		messages[pk.TopicName] = string(pk.Payload)
+		mu.Unlock()
+	}))
+	return addr, server, func(topic string) (string, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		payload, ok := messages[topic]
+		return payload, ok
+	}
+}
+
+func TestMQTTService(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	bus := NewEventBus()
+	settings := NewSettingsService(db)
+	devices := NewDeviceService(db, settings, nil, nil, nil, bus)
+	history := NewHistoryService(db, bus)
+	svc := NewMQTTService(db, settings, devices, nil, history, bus, dataDir)
+
+	device := model.Device{Name: "Living room", Host: "frame.local", Width: 800, Height: 480, Orientation: "landscape"}
+	require.NoError(t, db.Create(&device).Error)
+
+	photoPath := filepath.Join(dataDir, "photo.png")
+	f, err := os.Create(photoPath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, 1200, 800))))
+	f.Close()
+	photo := model.Image{Source: "upload", FilePath: photoPath}
+	require.NoError(t, db.Create(&photo).Error)
+
+	addr, server, last := startBroker(t)
+	require.NoError(t, settings.Set("mqtt_broker", "tcp://"+addr))
+	svc.Restart()
+	defer svc.Stop()
+
+	published := func(topic, payload string) bool {
+		got, ok := last(topic)
+		return ok && got == payload
+	}
+	require.Eventually(t, func() bool { return published("photoframe/status", "online") }, 5*time.Second, 10*time.Millisecond)
+
+	// Discovery configs for all entities, and the current state
+	for _, topic := range []string{
+		"homeassistant/button/photoframe_1/next/config",
+		"homeassistant/switch/photoframe_1/collage/config",
+		"homeassistant/select/photoframe_1/source/config",
+		"homeassistant/sensor/photoframe_1/last_seen/config",
+		"homeassistant/camera/photoframe_1/image/config",
+	} {
+		require.Eventually(t, func() bool { _, ok := last(topic); return ok }, 5*time.Second, 10*time.Millisecond, topic)
+	}
+	payload, _ := last("homeassistant/switch/photoframe_1/collage/config")
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(payload), &config))
+	assert.Equal(t, "photoframe_1_collage", config["unique_id"])
+	assert.Equal(t, "photoframe/1/collage/set", config["command_topic"])
+	assert.Equal(t, "photoframe/status", config["availability_topic"])
+	assert.True(t, published("photoframe/1/collage", "OFF"))
+	assert.True(t, published("photoframe/1/source", "all"))
+
+	// Commands from Home Assistant
+	require.NoError(t, server.Publish("photoframe/1/collage/set", []byte("ON"), false, 1))
+	require.Eventually(t, func() bool { return published("photoframe/1/collage", "ON") }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, server.Publish("photoframe/1/source/set", []byte("synology"), false, 1))
+	require.Eventually(t, func() bool { return published("photoframe/1/source", "synology") }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, server.Publish("photoframe/1/source/set", []byte("unknown"), false, 1))
+
+	var stored model.Device
+	require.NoError(t, db.First(&stored, device.ID).Error)
+	assert.True(t, stored.EnableCollage)
+	assert.Equal(t, "synology", stored.Source)
+
+	// No synology photos to push
+	assert.EqualError(t, svc.Next(device.ID), "no photos to show")
+
+	// Displays update the camera, requests the last-seen sensor
+	require.NoError(t, history.Record(device.ID, photo.ID))
+	require.Eventually(t, func() bool {
+		jpeg, ok := last("photoframe/1/image")
+		return ok && len(jpeg) > 2 && jpeg[:2] == "\xff\xd8"
+	}, 5*time.Second, 10*time.Millisecond)
+	devices.Seen(&stored)
+	require.Eventually(t, func() bool {
+		return published("photoframe/1/last_seen", stored.LastSeenAt.UTC().Format(time.RFC3339))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Deleted devices are removed from Home Assistant
+	require.NoError(t, devices.DeleteDevice(device.ID))
+	require.Eventually(t, func() bool {
+		return published("homeassistant/camera/photoframe_1/image/config", "")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	svc.Stop()
+	assert.True(t, published("photoframe/status", "offline"))
+}
@@ -10,6 +10,19 @@ import (
 	"github.com/fogleman/gg"
 )
 
+// Font search paths, in order of preference
+var textFontPaths = []string{
+	"/usr/share/fonts/noto/NotoSans-Regular.ttf",   // Linux (Docker)
+	"../bin/fonts/NotoSans-Regular.ttf",            // Local dev
+	"/System/Library/Fonts/Supplemental/Arial.ttf", // macOS fallback
+	"/Library/Fonts/Arial.ttf",                     // macOS alternative
+}
+
+var iconFontPaths = []string{
+	"/usr/share/fonts/material/MaterialSymbolsOutlined.ttf",
+	"../bin/fonts/MaterialSymbolsOutlined.ttf",
+}
+
 type OverlayService struct {
 	weatherClient *weather.Client
 	settings      *SettingsService
@@ -52,14 +65,8 @@ func (s *OverlayService) ApplyOverlay(img image.Image, opts OverlayOptions) (ima
 	dc.Fill()
 
 	// 3. Load Font
-	fontPaths := []string{
-		"/usr/share/fonts/noto/NotoSans-Regular.ttf",   // Linux (Docker)
-		"../bin/fonts/NotoSans-Regular.ttf",            // Local dev
-		"/System/Library/Fonts/Supplemental/Arial.ttf", // macOS fallback
-		"/Library/Fonts/Arial.ttf",                     // macOS alternative
-	}
 	var validFontPath string
-	for _, fontPath := range fontPaths {
+	for _, fontPath := range textFontPaths {
 		if err := dc.LoadFontFace(fontPath, 25); err == nil {
 			validFontPath = fontPath
 			break
@@ -93,10 +100,6 @@ func (s *OverlayService) ApplyOverlay(img image.Image, opts OverlayOptions) (ima
 		weather, err := s.weatherClient.GetWeather(lat, lon)
 		if err == nil {
 			// Draw large weather icon using Material Symbols font
-			iconFontPaths := []string{
-				"/usr/share/fonts/material/MaterialSymbolsOutlined.ttf",
-				"../bin/fonts/MaterialSymbolsOutlined.ttf",
-			}
 			iconFontLoaded := false
 			for _, p := range iconFontPaths {
 				if err := dc.LoadFontFace(p, 72); err == nil {
@@ -2,17 +2,19 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"image"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aitjcize/photoframe-server/server/internal/model"
 	"github.com/aitjcize/photoframe-server/server/pkg/googlephotos"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
 	"gorm.io/gorm"
 )
 
@@ -41,31 +43,71 @@ type MediaItemsResponse struct {
 type PickerProgress struct {
 	Total     int    `json:"total"`
 	Processed int    `json:"processed"`
-	Status    string `json:"status"` // "processing", "done", "error"
+	Status    string `json:"status"` // "processing", "listing", "downloading", "done", "error"
 	Error     string `json:"error,omitempty"`
+	JobID     uint   `json:"job_id"`
 }
 
+// PickerJob is the job type importing the items picked in a Picker session
+const PickerJob = "google_picker"
+
 type PickerService struct {
-	client   *googlephotos.Client
-	db       *gorm.DB
-	dataDir  string
-	progress map[string]*PickerProgress
+	client  *googlephotos.Client
+	db      *gorm.DB
+	dataDir string
+	dups    *DuplicateService
+	jobs    *JobService
+	events  *EventBus
 }
 
-func NewPickerService(client *googlephotos.Client, db *gorm.DB, dataDir string) *PickerService {
-	return &PickerService{
-		client:   client,
-		db:       db,
-		dataDir:  dataDir,
-		progress: make(map[string]*PickerProgress),
+func NewPickerService(client *googlephotos.Client, db *gorm.DB, dataDir string, dups *DuplicateService, jobs *JobService, events *EventBus) *PickerService {
+	s := &PickerService{
+		client:  client,
+		db:      db,
+		dataDir: dataDir,
+		dups:    dups,
+		jobs:    jobs,
+		events:  events,
 	}
+	// Imports are idempotent: items already downloaded are skipped
+	jobs.Register(PickerJob, JobType{Run: s.runImport, MaxAttempts: 3, Backoff: 30 * time.Second, Resume: true})
+	return s
+}
+
+// StartImport queues the import of the items picked in a session
+func (s *PickerService) StartImport(sessionID string) (*model.Job, error) {
+	return s.jobs.Enqueue(PickerJob, sessionID, pickerPayload{SessionID: sessionID})
 }
 
+// GetProgress returns the progress of a session's import, or nil if it was
+// never started
 func (s *PickerService) GetProgress(sessionID string) *PickerProgress {
-	if p, ok := s.progress[sessionID]; ok {
-		return p
+	job, err := s.jobs.Latest(PickerJob, sessionID)
+	if err != nil {
+		return nil
+	}
+
+	progress := &PickerProgress{
+		Total:     job.Progress.Total,
+		Processed: job.Progress.Processed,
+		Status:    job.Progress.Message,
+		JobID:     job.ID,
+	}
+	switch job.Status {
+	case model.JobSucceeded:
+		progress.Status = "done"
+	case model.JobFailed, model.JobCanceled:
+		progress.Status = "error"
+		progress.Error = job.Error
+		if progress.Error == "" {
+			progress.Error = "import " + job.Status
+		}
+	default:
+		if progress.Status == "" {
+			progress.Status = "processing"
+		}
 	}
-	return nil
+	return progress
 }
 
 func (s *PickerService) CreateSession() (string, string, error) {
@@ -130,16 +172,26 @@ func (s *PickerService) PollSession(sessionID string) (bool, error) {
 	return session.MediaItemsSet, nil
 }
 
-func (s *PickerService) ProcessSessionItems(sessionID string) (int, error) {
+type pickerPayload struct {
+	SessionID string `json:"session_id"`
+}
+
+// runImport lists the items picked in a session and downloads them
+func (s *PickerService) runImport(ctx context.Context, run *JobRun) (interface{}, error) {
+	var payload pickerPayload
+	if err := run.Payload(&payload); err != nil {
+		return nil, NoRetry(err)
+	}
+	sessionID := payload.SessionID
+
 	httpClient, err := s.client.GetClient()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	// Initialize Progress
-	s.progress[sessionID] = &PickerProgress{
-		Status: "listing",
-	}
+	run.Update(func(p *model.JobProgress) {
+		*p = model.JobProgress{Message: "listing"}
+	})
 
 	// Pagination loop
 	var allItems []PickedMediaItem
@@ -152,34 +204,32 @@ func (s *PickerService) ProcessSessionItems(sessionID string) (int, error) {
 			url += "&pageToken=" + pageToken
 		}
 
-		req, err := http.NewRequest("GET", url, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			s.progress[sessionID].Status = "error"
-			s.progress[sessionID].Error = err.Error()
-			return 0, err
+			return nil, NoRetry(err)
 		}
 
 		resp, err := httpClient.Do(req)
 		if err != nil {
-			s.progress[sessionID].Status = "error"
-			s.progress[sessionID].Error = err.Error()
-			return 0, err
+			return nil, err
 		}
-		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
 			body, _ := io.ReadAll(resp.Body)
-			errStr := fmt.Sprintf("failed to list items: %s", string(body))
-			s.progress[sessionID].Status = "error"
-			s.progress[sessionID].Error = errStr
-			return 0, fmt.Errorf("%s", errStr)
+			resp.Body.Close()
+			err := fmt.Errorf("failed to list items: %s", string(body))
+			// Expired or unknown sessions won't come back
+			if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+				return nil, NoRetry(err)
+			}
+			return nil, err
 		}
 
 		var listResp MediaItemsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
-			s.progress[sessionID].Status = "error"
-			s.progress[sessionID].Error = err.Error()
-			return 0, err
+		err = json.NewDecoder(resp.Body).Decode(&listResp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
 		}
 
 		allItems = append(allItems, listResp.MediaItems...)
@@ -189,124 +239,148 @@ func (s *PickerService) ProcessSessionItems(sessionID string) (int, error) {
 		}
 	}
 
-	// Update Total
-	s.progress[sessionID].Total = len(allItems)
-	s.progress[sessionID].Status = "downloading"
+	run.Update(func(p *model.JobProgress) {
+		p.Total = len(allItems)
+		p.Message = "downloading"
+	})
 
 	// Download items
 	count := 0
 	photosDir := filepath.Join(s.dataDir, "photos")
 	if err := os.MkdirAll(photosDir, 0755); err != nil {
-		s.progress[sessionID].Status = "error"
-		s.progress[sessionID].Error = err.Error()
-		return 0, err
+		return nil, err
 	}
 
 	for _, item := range allItems {
-		// Download High Quality
-		if item.MediaFile.BaseUrl == "" {
-			s.progress[sessionID].Processed++ // Count skipped as processed? yes
-			continue
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
-		// Skip videos
-		if len(item.MediaFile.MimeType) >= 5 && item.MediaFile.MimeType[:5] == "video" {
-			fmt.Printf("Skipping video: %s (%s)\n", item.MediaFile.Filename, item.MediaFile.MimeType)
-			s.progress[sessionID].Processed++
-			continue
+		stored, err := storeGoogleMedia(s.db, s.dups, httpClient, photosDir, googleMedia{
+			ID:       item.ID,
+			BaseUrl:  item.MediaFile.BaseUrl,
+			MimeType: item.MediaFile.MimeType,
+			Filename: item.MediaFile.Filename,
+		})
+		if err != nil {
+			fmt.Printf("Failed to import %s: %v\n", item.MediaFile.Filename, err)
+		} else if stored != nil {
+			count++
+			s.events.PhotoImported(stored)
 		}
 
-		downloadUrl := item.MediaFile.BaseUrl + "=w1600-h1600"
+		run.Update(func(p *model.JobProgress) {
+			p.Processed++
+			if err != nil {
+				p.Failed++
+			}
+		})
+	}
 
-		resp, err := httpClient.Get(downloadUrl)
-		if err != nil {
-			fmt.Printf("Failed to download %s: %v\n", item.MediaFile.Filename, err)
-			s.progress[sessionID].Processed++
-			continue
-		}
-		defer resp.Body.Close()
+	return map[string]int{"imported": count}, nil
+}
 
-		if resp.StatusCode != 200 {
-			fmt.Printf("Failed to download %s: status %d\n", item.MediaFile.Filename, resp.StatusCode)
-			s.progress[sessionID].Processed++
-			continue
-		}
+// googleMedia is a downloadable Google Photos item, from either the Picker or the Library API
+type googleMedia struct {
+	ID       string
+	BaseUrl  string
+	MimeType string
+	Filename string
+	AlbumID  string // Set for items imported by album sync
+}
 
-		// Save to file
-		// Use ID to avoid collisions?
-		ext := ".jpg"
-		localFilename := fmt.Sprintf("%s%s", item.ID, ext)
-		localPath := filepath.Join(photosDir, localFilename)
-
-		// Check for duplicate in DB
-		var existing model.Image
-		if err := s.db.Where("file_path = ?", localPath).First(&existing).Error; err == nil {
-			// Record exists. Check if file exists.
-			if _, err := os.Stat(localPath); err == nil {
-				// Both exist. Skip.
-				fmt.Printf("Skipping duplicate: %s\n", localFilename)
-				s.progress[sessionID].Processed++
-				continue
-			}
-			// File missing, delete old record so we can re-download and strictly create new one
-			s.db.Unscoped().Delete(&existing)
-		}
+// storeGoogleMedia downloads a Google Photos item into photosDir and creates its image row.
+// Items are deduplicated by their media item ID, picked items also by content when
+// duplicate_auto_skip is enabled. Returns nil without error if the item was skipped
+// (video, no URL, already imported or a near-duplicate).
+func storeGoogleMedia(db *gorm.DB, dups *DuplicateService, httpClient *http.Client, photosDir string, item googleMedia) (*model.Image, error) {
+	// Download High Quality
+	if item.BaseUrl == "" {
+		return nil, nil
+	}
 
-		// Create file
-		out, err := os.Create(localPath)
-		if err != nil {
-			s.progress[sessionID].Processed++
-			continue
-		}
+	// Skip videos
+	if strings.HasPrefix(item.MimeType, "video") {
+		fmt.Printf("Skipping video: %s (%s)\n", item.Filename, item.MimeType)
+		return nil, nil
+	}
 
-		// Write to file
-		_, err = io.Copy(out, resp.Body)
-		out.Close() // Close before opening for decode
-		if err != nil {
-			s.progress[sessionID].Processed++
-			continue
-		}
+	localPath := filepath.Join(photosDir, item.ID+".jpg")
 
-		// Decode image config to get dimensions
-		f, err := os.Open(localPath)
-		if err != nil {
-			s.progress[sessionID].Processed++
-			continue
-		}
-		imgConfig, _, err := image.DecodeConfig(f)
-		f.Close()
-
-		width := 0
-		height := 0
-		orientation := "landscape"
-
-		if err == nil {
-			width = imgConfig.Width
-			height = imgConfig.Height
-			if height > width {
-				orientation = "portrait"
+	// Check for duplicate in DB
+	var existing model.Image
+	if err := db.Unscoped().Where("file_path = ?", localPath).First(&existing).Error; err == nil {
+		// Picked before the album sync found it: the sync keeps track of it from now on
+		if item.AlbumID != "" && existing.GoogleAlbumID == "" {
+			if err := db.Unscoped().Model(&existing).UpdateColumn("google_album_id", item.AlbumID).Error; err != nil {
+				return nil, err
 			}
 		}
-
-		// Add to DB queue
-		image := model.Image{
-			FilePath:    localPath,
-			Source:      "google", // Set source to google
-			UserID:      1,        // Default user
-			Status:      "pending",
-			CreatedAt:   time.Now(),
-			Caption:     "From Google Photos",
-			Width:       width,
-			Height:      height,
-			Orientation: orientation,
+		// Deleted from the gallery by the user: don't bring it back
+		if existing.DeletedAt.Valid {
+			return nil, nil
+		}
+		// Record exists. Check if file exists.
+		if _, err := os.Stat(localPath); err == nil {
+			// Both exist. Skip.
+			return nil, nil
 		}
-		s.db.Create(&image)
-		count++
+		// File missing, delete old record so we can re-download and strictly create new one
+		db.Unscoped().Delete(&existing)
+	}
 
-		// Update Progress
-		s.progress[sessionID].Processed++
+	resp, err := httpClient.Get(item.BaseUrl + "=w1600-h1600")
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	s.progress[sessionID].Status = "done"
-	return count, nil
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(out, resp.Body)
+	out.Close() // Close before opening for decode
+	if err != nil {
+		os.Remove(localPath)
+		return nil, err
+	}
+
+	// Album sync mirrors the album, so its photos are only hashed for the report
+	hash, duplicate := dups.Check(localPath)
+	if duplicate != nil && item.AlbumID == "" {
+		fmt.Printf("Skipping %s: duplicate of photo #%d\n", item.Filename, duplicate.ID)
+		os.Remove(localPath)
+		return nil, nil
+	}
+
+	width, height, orientation, err := imageops.ReadInfo(localPath)
+	if err != nil {
+		// Keep the photo, dimensions are only used for collage matching
+		width, height, orientation = 0, 0, "landscape"
+	}
+
+	// Add to DB queue
+	img := model.Image{
+		FilePath:      localPath,
+		Source:        "google",
+		GoogleAlbumID: item.AlbumID,
+		UserID:        1, // Default user
+		Status:        "pending",
+		CreatedAt:     time.Now(),
+		Caption:       "From Google Photos",
+		CapturedAt:    imageops.ReadCaptureTime(localPath),
+		Width:         width,
+		Height:        height,
+		Orientation:   orientation,
+		PHash:         hash,
+	}
+	if err := db.Create(&img).Error; err != nil {
+		return nil, err
+	}
+	return &img, nil
 }
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	"github.com/fogleman/gg"
+)
+
+// placeholderFontPaths prefer the system font but never leave the card blank
+var placeholderFontPaths = append(append([]string{}, textFontPaths...), builtinFontPath)
+
+// PlaceholderService renders the card shown when no photo can be served, e.g.
+// an empty source or a missing file. A device can override it with its own
+// fallback image, stored as DATA_DIR/fallback/device_<id>.jpg.
+type PlaceholderService struct {
+	dataDir string
+}
+
+func NewPlaceholderService(dataDir string) *PlaceholderService {
+	return &PlaceholderService{dataDir: dataDir}
+}
+
+// Render draws a card explaining why no photo is shown, at the given logical resolution
+func (s *PlaceholderService) Render(width, height int, title, detail string) image.Image {
+	dc := gg.NewContext(width, height)
+	dc.SetColor(paletteColor("white", "white"))
+	dc.Clear()
+
+	cw, ch := float64(width), float64(height)
+	margin := 0.06 * min(cw, ch)
+	dc.SetColor(paletteColor("black", "black"))
+	dc.SetLineWidth(max(2, 0.006*min(cw, ch)))
+	dc.DrawRoundedRectangle(margin, margin, cw-2*margin, ch-2*margin, margin/2)
+	dc.Stroke()
+
+	inner := widgetBox{X: 2 * margin, W: cw - 4*margin}
+	unit := min(cw, ch) // Text sizes follow the short side so portrait panels look the same
+	fg := paletteColor("black", "black")
+
+	// image_not_supported
+	icon, _ := parseIcon("f116")
+	drawText(dc, icon, widgetBox{X: inner.X, Y: 0.2 * ch, W: inner.W, H: 0.2 * ch}, iconFontPaths, 0.2*unit, "center", paletteColor("red", "red"))
+	drawText(dc, title, widgetBox{X: inner.X, Y: 0.42 * ch, W: inner.W, H: 0.1 * ch}, placeholderFontPaths, 0.08*unit, "center", fg)
+	if detail != "" {
+		drawWrappedText(dc, detail, widgetBox{X: inner.X, Y: 0.54 * ch, W: inner.W, H: 0.18 * ch}, placeholderFontPaths, 0.045*unit, "center", fg)
+	}
+	drawText(dc, "Updated "+time.Now().Format("2006-01-02 15:04"), widgetBox{X: inner.X, Y: 0.76 * ch, W: inner.W, H: 0.06 * ch}, placeholderFontPaths, 0.035*unit, "center", fg)
+
+	return quantizeToPalette(dc.Image())
+}
+
+func (s *PlaceholderService) fallbackPath(deviceID uint) string {
+	return filepath.Join(s.dataDir, "fallback", fmt.Sprintf("device_%d.jpg", deviceID))
+}
+
+// Fallback returns the device's own fallback image, if one was uploaded
+func (s *PlaceholderService) Fallback(deviceID uint) (image.Image, error) {
+	f, err := os.Open(s.fallbackPath(deviceID))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := imageops.Decode(f)
+	return img, err
+}
+
+// FallbackFile returns the path of the device's fallback image, or "" if it has none
+func (s *PlaceholderService) FallbackFile(deviceID uint) string {
+	path := s.fallbackPath(deviceID)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// SaveFallback decodes an uploaded image and stores it as the device's fallback
+func (s *PlaceholderService) SaveFallback(deviceID uint, r io.Reader) error {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("not a valid image: %w", err)
+	}
+
+	path := s.fallbackPath(deviceID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(out, img, &jpeg.Options{Quality: 95}); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RemoveFallback deletes the device's fallback image
+func (s *PlaceholderService) RemoveFallback(deviceID uint) error {
+	if err := os.Remove(s.fallbackPath(deviceID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
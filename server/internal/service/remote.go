@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/immich"
+	"gorm.io/gorm"
+)
+
+// Variants of a remote photo that can be fetched and cached
+const (
+	VariantDisplay   = "display"   // Sized for the frame (Synology "large", Immich preview)
+	VariantThumbnail = "thumbnail" // Sized for the gallery
+)
+
+// remoteSources are the sources whose photos are not stored locally
+var remoteSources = map[string]bool{
+	"synology": true,
+	"immich":   true,
+}
+
+// IsRemoteSource reports whether photos of a source are fetched from a server when served
+func IsRemoteSource(source string) bool {
+	return remoteSources[source]
+}
+
+// RemotePhotoService fetches Synology and Immich photos through the local cache,
+// so frames keep showing photos while the server is asleep or offline. It can also
+// prefetch the next rotation candidates (setting cache_prefetch_count).
+type RemotePhotoService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	cache    *CacheService
+	synology *SynologyService
+	immich   *ImmichService
+
+	mu          sync.Mutex
+	queues      map[string][]uint // Upcoming image IDs per source, already cached
+	prefetching map[string]bool
+}
+
+func NewRemotePhotoService(db *gorm.DB, settings *SettingsService, cache *CacheService, synology *SynologyService, immich *ImmichService) *RemotePhotoService {
+	return &RemotePhotoService{
+		db:          db,
+		settings:    settings,
+		cache:       cache,
+		synology:    synology,
+		immich:      immich,
+		queues:      make(map[string][]uint),
+		prefetching: make(map[string]bool),
+	}
+}
+
+func cacheKey(item model.Image, variant string) string {
+	switch item.Source {
+	case "synology":
+		return fmt.Sprintf("synology:%d:%s:%s", item.SynologyPhotoID, item.ThumbnailKey, variant)
+	case "immich":
+		return fmt.Sprintf("immich:%s:%s", item.ImmichAssetID, variant)
+	}
+	return ""
+}
+
+// Fetch returns a remote photo, from the cache when possible
+func (s *RemotePhotoService) Fetch(item model.Image, variant string) ([]byte, error) {
+	key := cacheKey(item, variant)
+	if key == "" {
+		return nil, fmt.Errorf("source %s is not a remote source", item.Source)
+	}
+
+	if data, ok := s.cache.Get(key); ok {
+		return data, nil
+	}
+
+	data, err := s.fetchRemote(item, variant)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Put(key, item.ID, variant, data); err != nil {
+		log.Printf("Cache: failed to store %s: %v", key, err)
+	}
+	return data, nil
+}
+
+func (s *RemotePhotoService) fetchRemote(item model.Image, variant string) ([]byte, error) {
+	switch item.Source {
+	case "synology":
+		size := "large"
+		if variant == VariantThumbnail {
+			size = "small"
+		}
+		return s.synology.GetPhoto(item.SynologyPhotoID, item.ThumbnailKey, size)
+	case "immich":
+		size := immich.SizePreview
+		if variant == VariantThumbnail {
+			size = immich.SizeThumbnail
+		}
+		return s.immich.GetPhoto(item.ImmichAssetID, size)
+	}
+	return nil, fmt.Errorf("source %s is not a remote source", item.Source)
+}
+
+// RandomCached picks a random photo of a source that can be served from the
+// cache alone, honoring favorites and weights
+func (s *RemotePhotoService) RandomCached(source string) (*model.Image, []byte, error) {
+	query := s.db.Joins("JOIN cache_entries ON cache_entries.image_id = images.id AND cache_entries.variant = ?", VariantDisplay).
+		Where("images.source = ?", source)
+	ids, err := PickWeighted(query, 5, s.settings.FavoriteWeight())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, id := range ids {
+		var item model.Image
+		if err := s.db.First(&item, id).Error; err != nil {
+			continue
+		}
+		if data, ok := s.cache.Get(cacheKey(item, VariantDisplay)); ok {
+			return &item, data, nil
+		}
+	}
+	return nil, nil, errors.New("no cached photos available")
+}
+
+func (s *RemotePhotoService) prefetchCount() int {
+	val, _ := s.settings.Get("cache_prefetch_count")
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Next pops the next prefetched rotation candidate of a source, if any
+func (s *RemotePhotoService) Next(source string) *model.Image {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.queues[source]) > 0 {
+		id := s.queues[source][0]
+		s.queues[source] = s.queues[source][1:]
+
+		var item model.Image
+		// The photo may have been deleted or hidden since it was queued
+		if err := s.db.Scopes(Visible).Where("source = ?", source).First(&item, id).Error; err == nil {
+			return &item
+		}
+	}
+	return nil
+}
+
+// Prefetch picks the next rotation candidates of a source and downloads them
+// into the cache. It is a no-op unless cache_prefetch_count is set.
+func (s *RemotePhotoService) Prefetch(source string) {
+	count := s.prefetchCount()
+	if count == 0 || !IsRemoteSource(source) {
+		return
+	}
+
+	s.mu.Lock()
+	if s.prefetching[source] {
+		s.mu.Unlock()
+		return
+	}
+	s.prefetching[source] = true
+	queued := append([]uint(nil), s.queues[source]...)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.prefetching[source] = false
+		s.mu.Unlock()
+	}()
+
+	missing := count - len(queued)
+	if missing <= 0 {
+		return
+	}
+
+	query := s.db.Where("source = ?", source)
+	if len(queued) > 0 {
+		query = query.Where("id NOT IN ?", queued)
+	}
+	ids, err := PickWeighted(query, missing, s.settings.FavoriteWeight())
+	if err != nil {
+		log.Printf("Prefetch: failed to pick %s candidates: %v", source, err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	var candidates []model.Image
+	if err := s.db.Find(&candidates, ids).Error; err != nil {
+		log.Printf("Prefetch: failed to load %s candidates: %v", source, err)
+		return
+	}
+
+	for _, item := range candidates {
+		if _, err := s.Fetch(item, VariantDisplay); err != nil {
+			// Server unreachable, leave the rest for the next round
+			log.Printf("Prefetch: failed to fetch %s photo %d: %v", source, item.ID, err)
+			return
+		}
+
+		s.mu.Lock()
+		s.queues[source] = append(s.queues[source], item.ID)
+		s.mu.Unlock()
+	}
+}
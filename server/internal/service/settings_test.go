@@ -1,22 +1,27 @@
 package service
 
 import (
+	"path/filepath"
 	"testing"
 
-	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/internal/db"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
-func setupTestDB() *gorm.DB {
-	db, _ := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	db.AutoMigrate(&model.Setting{})
-	return db
+// setupTestDB returns a database in a temporary directory with the schema
+// built like in production: the models created by db.Init and the migrations
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	database, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, db.MigrateFrom(database, "../../db/migrations"))
+	return database
 }
 
 func TestSettingsService_SetGet(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	svc := NewSettingsService(db)
 
 	err := svc.Set("foo", "bar")
@@ -28,7 +33,7 @@ func TestSettingsService_SetGet(t *testing.T) {
 }
 
 func TestSettingsService_Update(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	svc := NewSettingsService(db)
 
 	svc.Set("foo", "bar")
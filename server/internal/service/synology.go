@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
@@ -16,18 +17,33 @@ import (
 	"gorm.io/gorm"
 )
 
+// SynologySyncJob is the job type of Synology syncs
+const SynologySyncJob = "synology_sync"
+
 type SynologyService struct {
 	db       *gorm.DB
 	settings *SettingsService
+	jobs     *JobService
+	trash    *TrashService
 	client   *synology.Client
-	mu       sync.Mutex
+	mu       sync.Mutex // Guards client
+
+	syncMu   sync.Mutex // Serializes syncs
+	statusMu sync.Mutex // Guards status
+	status   *SynologySyncStatus
+	loopMu   sync.Mutex // Guards stop
+	stop     chan struct{}
 }
 
-func NewSynologyService(db *gorm.DB, settings *SettingsService) *SynologyService {
-	return &SynologyService{
+func NewSynologyService(db *gorm.DB, settings *SettingsService, jobs *JobService, trash *TrashService) *SynologyService {
+	s := &SynologyService{
 		db:       db,
 		settings: settings,
+		jobs:     jobs,
+		trash:    trash,
 	}
+	jobs.Register(SynologySyncJob, JobType{Run: s.runSync, MaxAttempts: 3, Backoff: time.Minute, Resume: true})
+	return s
 }
 
 // ensureClient initializes and logs in the client if needed
@@ -127,18 +143,8 @@ func (s *SynologyService) GetPhoto(id int, cacheKeyStr, size string) ([]byte, er
 		return s.client.GetPhoto(id, cacheKeyStr, size, "personal", 0, s.client.SynoToken)
 	}
 
-	// 2. Get albumID from settings for the request
-	albumIDStr, _ := s.settings.Get("synology_album_id")
-	var albumID int
-	if albumIDStr != "" {
-		id, err := strconv.Atoi(albumIDStr)
-		if err == nil {
-			albumID = id
-		}
-	}
-
-	// Use stored ThumbnailKey (cache_key), SynologySpace, albumID, and SynoToken
-	return s.client.GetPhoto(id, img.ThumbnailKey, size, img.SynologySpace, albumID, s.client.SynoToken)
+	// Use stored ThumbnailKey (cache_key), SynologySpace, album and SynoToken
+	return s.client.GetPhoto(id, img.ThumbnailKey, size, img.SynologySpace, img.SynologyAlbumID, s.client.SynoToken)
 }
 
 func (s *SynologyService) ListAlbums() ([]synology.Album, error) {
@@ -148,15 +154,7 @@ func (s *SynologyService) ListAlbums() ([]synology.Album, error) {
 
 	albums, err := s.client.ListAlbums(0, 100)
 	if err != nil {
-		// Check if it's an auth error
-		if strings.Contains(err.Error(), "code: 119") {
-			s.mu.Lock()
-			s.client.SID = ""
-			s.mu.Unlock()
-			s.settings.Set("synology_sid", "")
-			return nil, errors.New("authentication expired: please reconnect")
-		}
-		return nil, err
+		return nil, s.checkAuthError(err)
 	}
 
 	// Cache the albums list
@@ -166,125 +164,352 @@ func (s *SynologyService) ListAlbums() ([]synology.Album, error) {
 	return albums, nil
 }
 
-// ImportPhotos fetches photos from Synology and adds them to DB
-func (s *SynologyService) ImportPhotos() error {
+// space returns the configured photo space ("personal" or "shared")
+func (s *SynologyService) space() string {
+	space, _ := s.settings.Get("synology_space")
+	if space == "" {
+		space = "personal" // default
+	}
+	return space
+}
+
+// ListPeople returns the people recognized by Synology's face grouping
+func (s *SynologyService) ListPeople() ([]synology.Person, error) {
 	if err := s.ensureClient(""); err != nil {
-		return err
+		return nil, err
 	}
 
-	// Fetch photos with pagination
-	offset := 0
-	limit := 500 // Fetch 500 at a time
-	totalFetched := 0
+	people, err := s.client.ListPeople(0, 500, s.space())
+	if err != nil {
+		return nil, s.checkAuthError(err)
+	}
+	return people, nil
+}
 
-	// Get album ID from settings
-	albumIDStr, _ := s.settings.Get("synology_album_id")
-	var albumID int
-	if albumIDStr != "" {
-		id, err := strconv.Atoi(albumIDStr)
-		if err == nil {
-			albumID = id
-		}
+// ListTags returns the general tags of the configured space
+func (s *SynologyService) ListTags() ([]synology.Tag, error) {
+	if err := s.ensureClient(""); err != nil {
+		return nil, err
 	}
 
-	// Get space from settings
-	space, _ := s.settings.Get("synology_space")
-	if space == "" {
-		space = "personal" // default
+	tags, err := s.client.ListTags(0, 500, s.space())
+	if err != nil {
+		return nil, s.checkAuthError(err)
 	}
+	return tags, nil
+}
 
-	log.Printf("Synology ImportPhotos: albumID=%d, space=%s, limit=%d", albumID, space, limit)
+// SynologySyncStatus reports the state of the last sync
+type SynologySyncStatus struct {
+	Running  bool      `json:"running"`
+	LastRun  time.Time `json:"last_run"`
+	Duration string    `json:"duration"`
+	Fetched  int       `json:"fetched"`
+	Added    int       `json:"added"`
+	Removed  int       `json:"removed"`
+	Error    string    `json:"error,omitempty"`
+}
 
-	// Fetch all photos (up to 1000 total for now)
-	for offset < 1000 {
-		photos, err := s.client.ListPhotos(offset, limit, albumID, space)
-		if err != nil {
-			log.Printf("Synology ListPhotos error: %v", err)
-			// Check if it's an auth error (code 119 = session expired)
-			if strings.Contains(err.Error(), "code: 119") {
-				// Clear the SID
-				s.mu.Lock()
-				s.client.SID = ""
-				s.mu.Unlock()
-				s.settings.Set("synology_sid", "")
-				return errors.New("authentication expired: please reconnect")
-			}
-			return err
+// idList parses a comma separated list of IDs from a setting
+func (s *SynologyService) idList(key string) []int {
+	raw, _ := s.settings.Get(key)
+
+	var ids []int
+	for _, part := range strings.Split(raw, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && id >= 0 {
+			ids = append(ids, id)
 		}
+	}
+	return ids
+}
+
+// AlbumIDs returns the selected albums (setting synology_album_ids, comma separated,
+// falling back to the single synology_album_id). 0 means all photos in the space.
+func (s *SynologyService) AlbumIDs() []int {
+	if ids := s.idList("synology_album_ids"); len(ids) > 0 {
+		return ids
+	}
+	return s.idList("synology_album_id")
+}
 
-		if len(photos) == 0 {
-			break // No more photos
+// syncTargets returns what to import: the selected albums, people
+// (synology_person_ids) and tags (synology_tag_ids), or the whole space
+// if nothing is selected.
+func (s *SynologyService) syncTargets() []synology.ItemFilter {
+	var targets []synology.ItemFilter
+	for _, id := range s.AlbumIDs() {
+		targets = append(targets, synology.ItemFilter{AlbumID: id})
+	}
+	for _, id := range s.idList("synology_person_ids") {
+		if id > 0 {
+			targets = append(targets, synology.ItemFilter{PersonID: id})
 		}
+	}
+	for _, id := range s.idList("synology_tag_ids") {
+		if id > 0 {
+			targets = append(targets, synology.ItemFilter{TagID: id})
+		}
+	}
+	if len(targets) == 0 {
+		targets = []synology.ItemFilter{{}}
+	}
+	return targets
+}
 
-		totalFetched += len(photos)
-		log.Printf("Fetched %d photos (offset=%d, total so far=%d)", len(photos), offset, totalFetched)
+func (s *SynologyService) syncInterval() time.Duration {
+	val, _ := s.settings.Get("synology_sync_interval")
+	if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return time.Hour
+}
 
-		count := 0
-		for _, p := range photos {
-			// Dedup by SynologyID
-			var existing model.Image
-			result := s.db.Where("synology_photo_id = ? AND source = ?", p.ID, "synology").First(&existing)
+// Restart stops the periodic sync and starts it again if Synology is configured
+func (s *SynologyService) Restart() {
+	s.Stop()
 
-			if result.Error == nil {
-				// Update cache key if changed
-				if existing.ThumbnailKey != p.Additional.Thumbnail.M {
-					existing.ThumbnailKey = p.Additional.Thumbnail.M
-					s.db.Save(&existing)
-				}
-				continue
-			}
+	if baseURL, _ := s.settings.Get("synology_url"); baseURL == "" {
+		return
+	}
 
-			// Create
-			img := model.Image{
-				SynologyPhotoID: p.ID,
-				SynologySpace:   space,
-				Source:          "synology",
-				FilePath:        p.Filename,
-				ThumbnailKey:    p.Additional.Thumbnail.M,
-				CreatedAt:       time.Now(),
-				Status:          "pending",
-			}
+	stop := make(chan struct{})
+	s.loopMu.Lock()
+	s.stop = stop
+	s.loopMu.Unlock()
 
-			// Use XL cache key if available
-			if p.Additional.Thumbnail.XL != "" {
-				img.ThumbnailKey = p.Additional.Thumbnail.XL
-			}
+	go s.syncLoop(stop, s.syncInterval())
+}
+
+// Stop stops the periodic sync
+func (s *SynologyService) Stop() {
+	s.loopMu.Lock()
+	defer s.loopMu.Unlock()
 
-			if err := s.db.Create(&img).Error; err != nil {
-				log.Printf("Failed to insert synology photo %d: %v", p.ID, err)
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *SynologyService) syncLoop(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// Don't try to log in while the user hasn't connected (or needs to enter an OTP)
+			if sid, _ := s.settings.Get("synology_sid"); sid == "" {
 				continue
 			}
-			count++
+			if _, err := s.StartSync(); err != nil {
+				log.Printf("Failed to queue Synology sync: %v", err)
+			}
 		}
+	}
+}
 
-		log.Printf("Imported %d new photos from this batch", count)
+// GetSyncStatus returns the status of the running or last sync
+func (s *SynologyService) GetSyncStatus() SynologySyncStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
 
-		if len(photos) < limit {
-			break // Last page
+	if s.status == nil {
+		// Restore the last result after a restart
+		status := SynologySyncStatus{}
+		if raw, _ := s.settings.Get("synology_sync_status"); raw != "" {
+			json.Unmarshal([]byte(raw), &status)
 		}
+		status.Running = false
+		s.status = &status
+	}
+	return *s.status
+}
+
+func (s *SynologyService) setSyncStatus(status SynologySyncStatus) {
+	s.statusMu.Lock()
+	s.status = &status
+	s.statusMu.Unlock()
 
-		offset += limit
+	if !status.Running {
+		statusJSON, _ := json.Marshal(status)
+		s.settings.Set("synology_sync_status", string(statusJSON))
 	}
+}
 
-	log.Printf("ImportPhotos complete: fetched %d photos total", totalFetched)
-	return nil
+// StartSync queues a sync, unless one is already queued or running
+func (s *SynologyService) StartSync() (*model.Job, error) {
+	return s.jobs.Enqueue(SynologySyncJob, "sync", nil)
 }
 
-// ClearPhotos deletes all Synology photos from database
-func (s *SynologyService) ClearPhotos() error {
-	if err := s.db.Unscoped().Where("source = ?", "synology").Delete(&model.Image{}).Error; err != nil {
+// runSync incrementally brings the database in line with the selected albums:
+// new photos are added, cache keys refreshed and photos no longer on the NAS removed.
+func (s *SynologyService) runSync(ctx context.Context, run *JobRun) (interface{}, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	status := SynologySyncStatus{Running: true, LastRun: time.Now()}
+	s.setSyncStatus(status)
+
+	err := s.sync(ctx, run, &status)
+	status.Running = false
+	status.Duration = time.Since(status.LastRun).Round(time.Millisecond).String()
+	if err != nil {
+		status.Error = err.Error()
+	}
+	s.setSyncStatus(status)
+
+	if err != nil {
+		// Reconnecting needs the user (and maybe an OTP code)
+		if strings.Contains(err.Error(), "authentication expired") {
+			err = NoRetry(err)
+		}
+		return &status, err
+	}
+	log.Printf("Synology sync complete: fetched=%d added=%d removed=%d", status.Fetched, status.Added, status.Removed)
+	return &status, nil
+}
+
+func (s *SynologyService) sync(ctx context.Context, run *JobRun, status *SynologySyncStatus) error {
+	if err := s.ensureClient(""); err != nil {
 		return err
 	}
-	log.Println("Cleared all Synology photos from database")
+
+	space := s.space()
+
+	// Includes photos in the trash, so they are not imported again
+	var existing []model.Image
+	if err := s.db.Unscoped().Where("source = ?", "synology").Find(&existing).Error; err != nil {
+		return err
+	}
+	// Photo IDs are only unique within a space
+	type photoKey struct {
+		space string
+		id    int
+	}
+	known := make(map[photoKey]model.Image, len(existing))
+	for _, img := range existing {
+		imgSpace := img.SynologySpace
+		if imgSpace == "" {
+			imgSpace = "personal"
+		}
+		known[photoKey{imgSpace, img.SynologyPhotoID}] = img
+	}
+
+	const limit = 500 // Fetch 500 at a time
+	seen := make(map[int]bool)
+	for _, target := range s.syncTargets() {
+		log.Printf("Synology sync: albumID=%d personID=%d tagID=%d space=%s", target.AlbumID, target.PersonID, target.TagID, space)
+		// Thumbnails of album photos are requested through the album (needed for shared albums)
+		albumID := target.AlbumID
+
+		// A failed listing aborts the sync, so a temporary outage never removes photos
+		for offset := 0; ; offset += limit {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			photos, err := s.client.ListItems(offset, limit, space, target)
+			if err != nil {
+				return s.checkAuthError(err)
+			}
+			status.Fetched += len(photos)
+			run.Update(func(p *model.JobProgress) {
+				p.Processed = status.Fetched
+				p.Message = "fetching"
+			})
+
+			for _, p := range photos {
+				if seen[p.ID] {
+					continue // Already synced through another album, person or tag
+				}
+				seen[p.ID] = true
+
+				// Use XL cache key if available
+				cacheKey := p.Additional.Thumbnail.M
+				if p.Additional.Thumbnail.XL != "" {
+					cacheKey = p.Additional.Thumbnail.XL
+				}
+
+				if img, ok := known[photoKey{space, p.ID}]; ok {
+					// Update cache key (changes when the photo is edited) and location.
+					// Purged photos are skipped for good.
+					if !img.Purged && (img.ThumbnailKey != cacheKey || img.SynologyAlbumID != albumID || img.SynologySpace != space) {
+						s.db.Unscoped().Model(&model.Image{}).Where("id = ?", img.ID).UpdateColumns(map[string]interface{}{
+							"thumbnail_key":     cacheKey,
+							"synology_album_id": albumID,
+							"synology_space":    space,
+						})
+					}
+					continue
+				}
+
+				img := model.Image{
+					SynologyPhotoID: p.ID,
+					SynologySpace:   space,
+					SynologyAlbumID: albumID,
+					Source:          "synology",
+					FilePath:        p.Filename,
+					ThumbnailKey:    cacheKey,
+					CreatedAt:       time.Now(),
+					Status:          "pending",
+				}
+				if p.Time > 0 {
+					taken := time.Unix(p.Time, 0)
+					img.CapturedAt = &taken
+				}
+				if err := s.db.Create(&img).Error; err != nil {
+					log.Printf("Failed to insert synology photo %d: %v", p.ID, err)
+					continue
+				}
+				status.Added++
+			}
+
+			if len(photos) < limit {
+				break // Last page
+			}
+		}
+	}
+
+	// Remove photos deleted on the NAS (or no longer in a selected album, person
+	// or tag), and those of the previous space after a space switch
+	var stale []model.Image
+	for key, img := range known {
+		if key.space != space || !seen[key.id] {
+			stale = append(stale, img)
+		}
+	}
+	if err := s.trash.Remove(stale); err != nil {
+		return err
+	}
+	status.Removed = len(stale)
 	return nil
 }
 
-// ClearAndResync deletes all Synology photos and re-imports them
-func (s *SynologyService) ClearAndResync() error {
-	if err := s.ClearPhotos(); err != nil {
+// checkAuthError clears the session on "session expired" (code 119) errors
+func (s *SynologyService) checkAuthError(err error) error {
+	if strings.Contains(err.Error(), "code: 119") {
+		s.mu.Lock()
+		s.client.SID = ""
+		s.mu.Unlock()
+		s.settings.Set("synology_sid", "")
+		return errors.New("authentication expired: please reconnect")
+	}
+	return err
+}
+
+// ClearPhotos deletes all Synology photos from database
+func (s *SynologyService) ClearPhotos() error {
+	var items []model.Image
+	if err := s.db.Unscoped().Where("source = ?", "synology").Find(&items).Error; err != nil {
+		return err
+	}
+	if err := s.trash.Remove(items); err != nil {
 		return err
 	}
-	return s.ImportPhotos()
+	log.Println("Cleared all Synology photos from database")
+	return nil
 }
 
 // GetPhotoCount returns the number of Synology photos in the database
@@ -295,9 +520,3 @@ func (s *SynologyService) GetPhotoCount() (int64, error) {
 	}
 	return count, nil
 }
-
-// DownloadPhoto fetches the large thumbnail by ID (avoiding full download/EXIF issues)
-func (s *SynologyService) DownloadPhoto(id int) ([]byte, error) {
-	// Re-use GetPhoto logic which handles DB lookup, cache keys, and space
-	return s.GetPhoto(id, "", "large")
-}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/synology"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSynologyService_SyncSelection(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	trash := NewTrashService(db, settings, NewLibraryService(db), NewHistoryService(db, nil), NewCacheService(db, settings, dataDir), dataDir)
+	svc := NewSynologyService(db, settings, NewJobService(db, nil), trash)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var list []synology.Item
+		switch {
+		case r.URL.Path == "/webapi/auth.cgi":
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": map[string]string{"sid": "sid1"}})
+			return
+		case q.Get("api") == "SYNO.FotoTeam.Browse.Item" && q.Get("person_id") == "7":
+			list = []synology.Item{{ID: 10, Filename: "grandma.jpg"}, {ID: 11, Filename: "family.jpg"}}
+		case q.Get("api") == "SYNO.FotoTeam.Browse.Item" && q.Get("general_tag_id") == "3":
+			list = []synology.Item{{ID: 11, Filename: "family.jpg"}, {ID: 12, Filename: "beach.jpg"}}
+		case q.Get("api") == "SYNO.Foto.Browse.Item":
+			list = []synology.Item{{ID: 10, Filename: "mine.jpg"}} // Same ID as a shared photo
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": map[string]interface{}{"list": list}})
+	}))
+	defer server.Close()
+	require.NoError(t, settings.Set("synology_url", server.URL))
+	require.NoError(t, settings.Set("synology_account", "me"))
+	require.NoError(t, settings.Set("synology_password", "secret"))
+	require.NoError(t, settings.Set("synology_space", "shared"))
+	require.NoError(t, settings.Set("synology_person_ids", "7"))
+	require.NoError(t, settings.Set("synology_tag_ids", "3"))
+
+	sync := func() SynologySyncStatus {
+		var status SynologySyncStatus
+		require.NoError(t, svc.sync(context.Background(), &JobRun{job: &model.Job{}, db: db}, &status))
+		return status
+	}
+
+	// A photo of the person that is also tagged is imported once
+	status := sync()
+	assert.Equal(t, 4, status.Fetched)
+	assert.Equal(t, 3, status.Added)
+	var shared []model.Image
+	require.NoError(t, db.Where("source = ?", "synology").Order("synology_photo_id").Find(&shared).Error)
+	require.Len(t, shared, 3)
+	for _, img := range shared {
+		assert.Equal(t, "shared", img.SynologySpace)
+	}
+
+	// After switching to the personal space, a colliding ID is a new photo
+	require.NoError(t, settings.Set("synology_space", "personal"))
+	require.NoError(t, settings.Set("synology_person_ids", ""))
+	require.NoError(t, settings.Set("synology_tag_ids", ""))
+	status = sync()
+	assert.Equal(t, 1, status.Added)
+	assert.Equal(t, 3, status.Removed)
+	var personal []model.Image
+	require.NoError(t, db.Unscoped().Where("source = ?", "synology").Find(&personal).Error)
+	require.Len(t, personal, 1)
+	assert.Equal(t, "personal", personal[0].SynologySpace)
+	assert.Equal(t, "mine.jpg", personal[0].FilePath)
+	assert.NotEqual(t, shared[0].ID, personal[0].ID)
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"log"
+	"net/http"
+	"sort"
 	"sync"
 
+	"github.com/aitjcize/photoframe-server/server/internal/model"
 	"github.com/aitjcize/photoframe-server/server/pkg/telegram"
 	"gorm.io/gorm"
 )
@@ -13,16 +16,22 @@ type TelegramService struct {
 	db       *gorm.DB
 	dataDir  string
 	settings *SettingsService
-	pusher   telegram.Pusher
+	devices  telegram.Devices
+	dups     *DuplicateService
+	events   *EventBus
+	trash    *TrashService
 	mu       sync.Mutex
 }
 
-func NewTelegramService(db *gorm.DB, dataDir string, settings *SettingsService, pusher telegram.Pusher) *TelegramService {
+func NewTelegramService(db *gorm.DB, dataDir string, settings *SettingsService, devices telegram.Devices, dups *DuplicateService, events *EventBus, trash *TrashService) *TelegramService {
 	return &TelegramService{
 		db:       db,
 		dataDir:  dataDir,
 		settings: settings,
-		pusher:   pusher,
+		devices:  devices,
+		dups:     dups,
+		events:   events,
+		trash:    trash,
 	}
 }
 
@@ -40,7 +49,7 @@ func (s *TelegramService) Restart(token string) {
 		return
 	}
 
-	bot, err := telegram.NewBot(token, s.db, s.dataDir, s.settings, s.pusher)
+	bot, err := telegram.NewBot(token, s.db, s.dataDir, s.settings, s.devices, s.dups, s.events, s, s.trash)
 	if err != nil {
 		log.Printf("Failed to start Telegram bot: %v", err)
 		return
@@ -50,3 +59,59 @@ func (s *TelegramService) Restart(token string) {
 	s.bot.Start()
 	log.Println("Telegram bot started/restarted")
 }
+
+// PickImage picks a random photo for the bot's /random command, honoring
+// favorites and weights
+func (s *TelegramService) PickImage(query *gorm.DB) (*model.Image, error) {
+	return PickWeightedImage(query, s.settings.FavoriteWeight())
+}
+
+// ServeWebhook passes an update posted by Telegram to the running bot
+func (s *TelegramService) ServeWebhook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	bot := s.bot
+	s.mu.Unlock()
+
+	if bot == nil || !bot.UsesWebhook() {
+		http.Error(w, "webhook is not enabled", http.StatusNotFound)
+		return
+	}
+	bot.ServeHTTP(w, r)
+}
+
+// TelegramChat is an allow-listed user or chat and the device its photos go to
+type TelegramChat struct {
+	ID       int64 `json:"id"`
+	DeviceID uint  `json:"device_id"` // 0 = telegram_target_device_id
+}
+
+// CreatePairing generates a one-time code to send to the bot with /pair.
+// If deviceID is set, the paired chat is routed to that device.
+func (s *TelegramService) CreatePairing(deviceID uint) (*telegram.Pairing, error) {
+	return telegram.NewPairing(s.settings, deviceID)
+}
+
+// ListChats returns the allow-listed users and chats with their routes
+func (s *TelegramService) ListChats() []TelegramChat {
+	routes := telegram.ChatDevices(s.settings)
+
+	chats := make([]TelegramChat, 0)
+	for _, id := range telegram.AllowedIDs(s.settings) {
+		chats = append(chats, TelegramChat{ID: id, DeviceID: routes[id]})
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].ID < chats[j].ID })
+	return chats
+}
+
+// SetChat allows a user or chat and routes it to a device (0 = default device)
+func (s *TelegramService) SetChat(id int64, deviceID uint) error {
+	if err := telegram.Allow(s.settings, id); err != nil {
+		return err
+	}
+	return telegram.SetChatDevice(s.settings, id, deviceID)
+}
+
+// RevokeChat removes a user or chat from the allow-list
+func (s *TelegramService) RevokeChat(id int64) error {
+	return telegram.Revoke(s.settings, id)
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTrashRetentionDays is how long deleted photos stay in the trash,
+	// unless trash_retention_days is set
+	DefaultTrashRetentionDays = 30
+
+	trashPurgeInterval = time.Hour
+)
+
+// ownedFileSources are sources whose files are copies managed by the server,
+// so purging the photo also deletes the file
+var ownedFileSources = map[string]bool{"google": true, "upload": true, "webdav": true, "email": true, "telegram": true}
+
+// syncedSources mirror an external folder or album. Their rows are kept after
+// purging so the next sync doesn't import the photo again.
+var syncedSources = map[string]bool{"local": true, "webdav": true, "synology": true, "immich": true}
+
+// isSynced reports whether a photo is kept in sync with an external folder or album
+func isSynced(item model.Image) bool {
+	return syncedSources[item.Source] || item.GoogleAlbumID != ""
+}
+
+// TrashService keeps deleted photos restorable. Deleting soft-deletes the row
+// and moves owned files to DATA_DIR/trash; purging (by hand or after the
+// retention period) removes them for good. Syncs remove photos gone from their
+// source through it as well.
+type TrashService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	library  *LibraryService
+	history  *HistoryService
+	cache    *CacheService
+	dataDir  string
+}
+
+func NewTrashService(db *gorm.DB, settings *SettingsService, library *LibraryService, history *HistoryService, cache *CacheService, dataDir string) *TrashService {
+	return &TrashService{
+		db:       db,
+		settings: settings,
+		library:  library,
+		history:  history,
+		cache:    cache,
+		dataDir:  dataDir,
+	}
+}
+
+// RetentionDays returns how many days photos stay in the trash. 0 keeps them
+// until the trash is emptied.
+func (s *TrashService) RetentionDays() int {
+	val, _ := s.settings.Get("trash_retention_days")
+	if d, err := strconv.Atoi(val); err == nil && d >= 0 {
+		return d
+	}
+	return DefaultTrashRetentionDays
+}
+
+// Trashed returns a query for the photos in the trash
+func (s *TrashService) Trashed() *gorm.DB {
+	return s.db.Unscoped().Model(&model.Image{}).
+		Where("images.deleted_at IS NOT NULL AND images.purged = ?", false)
+}
+
+// Trash moves photos to the trash
+func (s *TrashService) Trash(items []model.Image) error {
+	trashDir := filepath.Join(s.dataDir, "trash")
+	if err := os.MkdirAll(trashDir, 0755); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		// Local folder files are the user's own and stay where they are
+		if ownedFileSources[item.Source] && item.FilePath != "" {
+			trashPath := filepath.Join(trashDir, fmt.Sprintf("%d_%s", item.ID, filepath.Base(item.FilePath)))
+			if err := os.Rename(item.FilePath, trashPath); err != nil {
+				if !os.IsNotExist(err) {
+					return fmt.Errorf("failed to move %s to the trash: %w", item.FilePath, err)
+				}
+			} else {
+				s.db.Model(&model.Image{}).Where("id = ?", item.ID).UpdateColumn("trash_path", trashPath)
+			}
+		}
+		if err := s.db.Delete(&model.Image{}, item.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore takes photos out of the trash and returns how many were restored
+func (s *TrashService) Restore(ids []uint) (int, error) {
+	var items []model.Image
+	if err := s.Trashed().Where("images.id IN ?", ids).Find(&items).Error; err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for _, item := range items {
+		if item.TrashPath != "" {
+			if err := os.MkdirAll(filepath.Dir(item.FilePath), 0755); err != nil {
+				log.Printf("Trash: failed to restore %d: %v", item.ID, err)
+				continue
+			}
+			if err := os.Rename(item.TrashPath, item.FilePath); err != nil {
+				log.Printf("Trash: failed to restore %d: %v", item.ID, err)
+				continue
+			}
+		}
+		err := s.db.Unscoped().Model(&model.Image{}).Where("id = ?", item.ID).
+			UpdateColumns(map[string]interface{}{"deleted_at": nil, "trash_path": ""}).Error
+		if err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// Purge deletes photos in the trash for good (all of them if ids is nil) and
+// returns how many were purged
+func (s *TrashService) Purge(ids []uint) (int, error) {
+	query := s.Trashed()
+	if ids != nil {
+		query = query.Where("images.id IN ?", ids)
+	}
+	var items []model.Image
+	if err := query.Find(&items).Error; err != nil {
+		return 0, err
+	}
+	return s.purge(items)
+}
+
+// PurgeExpired purges photos that have been in the trash longer than the
+// retention period
+func (s *TrashService) PurgeExpired() (int, error) {
+	days := s.RetentionDays()
+	if days == 0 {
+		return 0, nil
+	}
+	var items []model.Image
+	cutoff := time.Now().AddDate(0, 0, -days)
+	if err := s.Trashed().Where("images.deleted_at < ?", cutoff).Find(&items).Error; err != nil {
+		return 0, err
+	}
+	return s.purge(items)
+}
+
+func (s *TrashService) purge(items []model.Image) (int, error) {
+	var synced, deleted []model.Image
+	for _, item := range items {
+		if isSynced(item) {
+			synced = append(synced, item)
+		} else {
+			deleted = append(deleted, item)
+		}
+	}
+
+	if len(synced) > 0 {
+		ids := s.removeFiles(synced)
+		err := s.db.Unscoped().Model(&model.Image{}).Where("id IN ?", ids).
+			UpdateColumns(map[string]interface{}{"purged": true, "trash_path": ""}).Error
+		if err != nil {
+			return 0, err
+		}
+		s.forget(ids)
+	}
+	if err := s.Remove(deleted); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+// Remove deletes photos for good, in the trash or not: the rows, owned files,
+// thumbnails, album and tag links, display history and cached copies
+func (s *TrashService) Remove(items []model.Image) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := s.removeFiles(items)
+	if err := s.db.Unscoped().Delete(&model.Image{}, ids).Error; err != nil {
+		return err
+	}
+	s.forget(ids)
+	return nil
+}
+
+// removeFiles deletes the owned files and thumbnails of photos and returns
+// their IDs
+func (s *TrashService) removeFiles(items []model.Image) []uint {
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		if item.TrashPath != "" {
+			os.Remove(item.TrashPath)
+		}
+		if ownedFileSources[item.Source] && item.FilePath != "" {
+			os.Remove(item.FilePath)
+		}
+		os.Remove(filepath.Join(s.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID)))
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+// forget drops everything referring to removed photos
+func (s *TrashService) forget(ids []uint) {
+	if err := s.library.Detach(ids); err != nil {
+		log.Printf("Trash: failed to remove album and tag links: %v", err)
+	}
+	if err := s.history.Forget(ids); err != nil {
+		log.Printf("Trash: failed to remove display history: %v", err)
+	}
+	s.cache.Remove(ids)
+}
+
+// Start purges expired photos now and then every hour
+func (s *TrashService) Start() {
+	go func() {
+		for {
+			if n, err := s.PurgeExpired(); err != nil {
+				log.Printf("Trash: failed to purge expired photos: %v", err)
+			} else if n > 0 {
+				log.Printf("Trash: purged %d expired photos", n)
+			}
+			time.Sleep(trashPurgeInterval)
+		}
+	}()
+}
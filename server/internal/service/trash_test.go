@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/immich"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTrashService(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	trash := NewTrashService(db, settings, NewLibraryService(db), NewHistoryService(db, nil), NewCacheService(db, settings, dataDir), dataDir)
+
+	photosDir := filepath.Join(dataDir, "photos")
+	require.NoError(t, os.MkdirAll(photosDir, 0755))
+	uploadPath := filepath.Join(photosDir, "upload.jpg")
+	localPath := filepath.Join(photosDir, "local.jpg")
+	telegramPath := filepath.Join(photosDir, "telegram_1.jpg")
+	require.NoError(t, os.WriteFile(uploadPath, []byte("upload"), 0644))
+	require.NoError(t, os.WriteFile(localPath, []byte("local"), 0644))
+	require.NoError(t, os.WriteFile(telegramPath, []byte("telegram"), 0644))
+
+	images := []model.Image{
+		{Source: "upload", FilePath: uploadPath},
+		{Source: "local", FilePath: localPath},
+		{Source: "synology", FilePath: "remote.jpg"},
+		{Source: "telegram", FilePath: telegramPath},
+	}
+	require.NoError(t, db.Create(&images).Error)
+
+	// Uploaded and Telegram files move to the trash, the user's own files stay
+	require.NoError(t, trash.Trash(images))
+	assert.NoFileExists(t, uploadPath)
+	assert.NoFileExists(t, telegramPath)
+	assert.FileExists(t, localPath)
+	var count int64
+	db.Model(&model.Image{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	trash.Trashed().Count(&count)
+	assert.Equal(t, int64(4), count)
+	var telegram model.Image
+	require.NoError(t, db.Unscoped().First(&telegram, images[3].ID).Error)
+	assert.FileExists(t, telegram.TrashPath)
+
+	restored, err := trash.Restore([]uint{images[0].ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	assert.FileExists(t, uploadPath)
+	var item model.Image
+	require.NoError(t, db.First(&item, images[0].ID).Error)
+	assert.Empty(t, item.TrashPath)
+
+	// Only photos past the retention period are purged
+	require.NoError(t, settings.Set("trash_retention_days", "7"))
+	db.Unscoped().Model(&model.Image{}).Where("id = ?", images[1].ID).UpdateColumn("deleted_at", time.Now().AddDate(0, 0, -8))
+	purged, err := trash.PurgeExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	// Synced photos keep a row, so the next sync skips them
+	var tombstone model.Image
+	require.NoError(t, db.Unscoped().First(&tombstone, images[1].ID).Error)
+	assert.True(t, tombstone.Purged)
+	assert.FileExists(t, localPath)
+
+	purged, err = trash.Purge(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	var remoteTombstone model.Image
+	require.NoError(t, db.Unscoped().First(&remoteTombstone, images[2].ID).Error)
+	assert.True(t, remoteTombstone.Purged)
+	assert.ErrorIs(t, db.Unscoped().First(&model.Image{}, images[3].ID).Error, gorm.ErrRecordNotFound)
+	assert.NoFileExists(t, telegram.TrashPath)
+	trash.Trashed().Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestTrashService_PurgeThenSync(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	trash := NewTrashService(db, settings, NewLibraryService(db), NewHistoryService(db, nil), NewCacheService(db, settings, dataDir), dataDir)
+	svc := NewImmichService(db, settings, trash)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(immich.Album{ID: "a1", Assets: []immich.Asset{
+			{ID: "p1", Type: "IMAGE"},
+			{ID: "p2", Type: "IMAGE"},
+		}})
+	}))
+	defer server.Close()
+	require.NoError(t, settings.Set("immich_url", server.URL))
+	require.NoError(t, settings.Set("immich_api_key", "key"))
+	require.NoError(t, settings.Set("immich_album_id", "a1"))
+
+	result, err := svc.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Added)
+
+	var photo model.Image
+	require.NoError(t, db.Where("immich_asset_id = ?", "p1").First(&photo).Error)
+	require.NoError(t, trash.Trash([]model.Image{photo}))
+	purged, err := trash.Purge(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	// The photo is still in the album, but stays purged
+	result, err = svc.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Added)
+	assert.Equal(t, 0, result.Removed)
+	var count int64
+	db.Model(&model.Image{}).Where("source = ?", "immich").Count(&count)
+	assert.Equal(t, int64(1), count)
+	var tombstone model.Image
+	require.NoError(t, db.Unscoped().First(&tombstone, photo.ID).Error)
+	assert.True(t, tombstone.Purged)
+}
+
+func TestTrashService_SyncRemoval(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	library := NewLibraryService(db)
+	history := NewHistoryService(db, nil)
+	trash := NewTrashService(db, settings, library, history, NewCacheService(db, settings, dataDir), dataDir)
+	svc := NewImmichService(db, settings, trash)
+
+	assets := []immich.Asset{{ID: "p1", Type: "IMAGE"}, {ID: "p2", Type: "IMAGE"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(immich.Album{ID: "a1", Assets: assets})
+	}))
+	defer server.Close()
+	require.NoError(t, settings.Set("immich_url", server.URL))
+	require.NoError(t, settings.Set("immich_api_key", "key"))
+	require.NoError(t, settings.Set("immich_album_id", "a1"))
+
+	_, err := svc.Sync()
+	require.NoError(t, err)
+	var photo model.Image
+	require.NoError(t, db.Where("immich_asset_id = ?", "p2").First(&photo).Error)
+	require.NoError(t, library.AddToAlbum([]uint{photo.ID}, "Favorites"))
+	require.NoError(t, library.AddTags([]uint{photo.ID}, []string{"beach"}))
+	require.NoError(t, history.Record(1, photo.ID))
+
+	// Photos removed from the album take their links and history with them
+	assets = assets[:1]
+	result, err := svc.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Removed)
+	assert.ErrorIs(t, db.Unscoped().First(&model.Image{}, photo.ID).Error, gorm.ErrRecordNotFound)
+	var count int64
+	db.Table("image_albums").Count(&count)
+	assert.Equal(t, int64(0), count)
+	db.Table("image_tags").Count(&count)
+	assert.Equal(t, int64(0), count)
+	db.Model(&model.Display{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
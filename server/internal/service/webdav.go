@@ -0,0 +1,324 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	"github.com/aitjcize/photoframe-server/server/pkg/webdav"
+	"gorm.io/gorm"
+)
+
+type WebDAVSyncResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+	Failed  int `json:"failed"`
+}
+
+// WebDAVService mirrors a remote WebDAV folder (e.g. Nextcloud) into DATA_DIR/photos/webdav.
+// Files are only downloaded again when their ETag changes.
+type WebDAVService struct {
+	db       *gorm.DB
+	settings *SettingsService
+	trash    *TrashService
+	dataDir  string
+
+	syncMu sync.Mutex // Serializes syncs
+	mu     sync.Mutex // Guards stop
+	stop   chan struct{}
+}
+
+func NewWebDAVService(db *gorm.DB, settings *SettingsService, trash *TrashService, dataDir string) *WebDAVService {
+	return &WebDAVService{
+		db:       db,
+		settings: settings,
+		trash:    trash,
+		dataDir:  dataDir,
+	}
+}
+
+func (s *WebDAVService) newClient() (*webdav.Client, error) {
+	baseURL, _ := s.settings.Get("webdav_url")
+	username, _ := s.settings.Get("webdav_username")
+	password, _ := s.settings.Get("webdav_password")
+	skipCertStr, _ := s.settings.Get("webdav_skip_cert")
+
+	if baseURL == "" {
+		return nil, errors.New("webdav url not configured")
+	}
+	return webdav.NewClient(baseURL, username, password, skipCertStr == "true")
+}
+
+// remoteRoot returns the configured folder, relative to the WebDAV root
+func (s *WebDAVService) remoteRoot() string {
+	p, _ := s.settings.Get("webdav_path")
+	return path.Clean("/" + strings.TrimSpace(p))
+}
+
+func (s *WebDAVService) syncInterval() time.Duration {
+	val, _ := s.settings.Get("webdav_sync_interval")
+	if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return time.Hour
+}
+
+// Restart stops the periodic sync and starts it again if WebDAV is configured
+func (s *WebDAVService) Restart() {
+	s.Stop()
+
+	if baseURL, _ := s.settings.Get("webdav_url"); baseURL == "" {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.mu.Lock()
+	s.stop = stop
+	s.mu.Unlock()
+
+	go s.syncLoop(stop, s.syncInterval())
+}
+
+// Stop stops the periodic sync
+func (s *WebDAVService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *WebDAVService) syncLoop(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := s.Sync(); err != nil {
+				log.Printf("WebDAV sync failed: %v", err)
+			}
+		}
+	}
+}
+
+// TestConnection checks the credentials and that the configured folder exists
+func (s *WebDAVService) TestConnection() error {
+	client, err := s.newClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Stat(s.remoteRoot())
+	if err != nil {
+		return err
+	}
+	if !res.IsDir {
+		return fmt.Errorf("%s is not a folder", s.remoteRoot())
+	}
+	return nil
+}
+
+// Sync downloads new and changed photos and removes photos deleted on the server
+func (s *WebDAVService) Sync() (*WebDAVSyncResult, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	client, err := s.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	root := s.remoteRoot()
+	// A failed listing aborts the sync, so a temporary outage never removes photos
+	files, err := client.Walk(root)
+	if err != nil {
+		return nil, err
+	}
+
+	photosDir := filepath.Join(s.dataDir, "photos", "webdav")
+	if err := os.MkdirAll(photosDir, 0755); err != nil {
+		return nil, err
+	}
+
+	var existing []model.Image
+	if err := s.db.Unscoped().Where("source = ?", "webdav").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]model.Image, len(existing))
+	for _, img := range existing {
+		known[img.WebDAVPath] = img
+	}
+
+	result := &WebDAVSyncResult{}
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if !imageops.IsSupportedFile(f.Path) || inSkippedDir(f.Path) {
+			continue
+		}
+		seen[f.Path] = true
+
+		item, exists := known[f.Path]
+		if exists {
+			// Deleted from the gallery by the user: keep it out of rotation
+			if item.DeletedAt.Valid {
+				continue
+			}
+			if item.WebDAVETag == f.ETag && f.ETag != "" {
+				if _, err := os.Stat(item.FilePath); err == nil {
+					continue
+				}
+			}
+		}
+
+		localPath := filepath.Join(photosDir, webdavFilename(f.Path))
+		if err := s.download(client, f.Path, localPath); err != nil {
+			log.Printf("WebDAV: failed to download %s: %v", f.Path, err)
+			result.Failed++
+			continue
+		}
+
+		width, height, orientation, err := imageops.ReadInfo(localPath)
+		if err != nil {
+			log.Printf("WebDAV: skipping unreadable file %s: %v", f.Path, err)
+			os.Remove(localPath)
+			result.Failed++
+			continue
+		}
+
+		if exists {
+			item.FilePath = localPath
+			item.WebDAVETag = f.ETag
+			item.Width = width
+			item.Height = height
+			item.Orientation = orientation
+			item.CapturedAt = imageops.ReadCaptureTime(localPath)
+			item.PHash = hashPhoto(localPath)
+			if err := s.db.Save(&item).Error; err != nil {
+				log.Printf("WebDAV: failed to update %s: %v", f.Path, err)
+				result.Failed++
+				continue
+			}
+			// Thumbnail is regenerated on next request
+			os.Remove(filepath.Join(s.dataDir, "thumbnails", fmt.Sprintf("%d.jpg", item.ID)))
+			result.Updated++
+			continue
+		}
+
+		img := model.Image{
+			FilePath:    localPath,
+			Source:      "webdav",
+			WebDAVPath:  f.Path,
+			WebDAVETag:  f.ETag,
+			UserID:      1,
+			Status:      "pending",
+			CreatedAt:   time.Now(),
+			CapturedAt:  imageops.ReadCaptureTime(localPath),
+			Width:       width,
+			Height:      height,
+			Orientation: orientation,
+			PHash:       hashPhoto(localPath),
+		}
+		if err := s.db.Create(&img).Error; err != nil {
+			log.Printf("WebDAV: failed to import %s: %v", f.Path, err)
+			os.Remove(localPath)
+			result.Failed++
+			continue
+		}
+		result.Added++
+	}
+
+	// Removed on the server (or the configured folder changed)
+	for remotePath, item := range known {
+		if seen[remotePath] {
+			continue
+		}
+		s.deleteItem(item)
+		result.Removed++
+	}
+
+	log.Printf("WebDAV sync complete: added=%d updated=%d removed=%d failed=%d", result.Added, result.Updated, result.Removed, result.Failed)
+	return result, nil
+}
+
+// download fetches a file into a temporary file first, so a failed transfer
+// never replaces a good local copy
+func (s *WebDAVService) download(client *webdav.Client, remotePath, localPath string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := client.Download(remotePath, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), localPath)
+}
+
+func (s *WebDAVService) deleteItem(item model.Image) {
+	if err := s.trash.Remove([]model.Image{item}); err != nil {
+		log.Printf("WebDAV: failed to remove %s: %v", item.WebDAVPath, err)
+	}
+}
+
+// ClearPhotos deletes all WebDAV photos and their local copies
+func (s *WebDAVService) ClearPhotos() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	var items []model.Image
+	if err := s.db.Unscoped().Where("source = ?", "webdav").Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		s.deleteItem(item)
+	}
+	log.Printf("Cleared %d WebDAV photos", len(items))
+	return nil
+}
+
+// GetPhotoCount returns the number of WebDAV photos in the database
+func (s *WebDAVService) GetPhotoCount() (int64, error) {
+	var count int64
+	if err := s.db.Model(&model.Image{}).Where("source = ?", "webdav").Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// inSkippedDir reports whether any folder of a remote path is hidden or NAS metadata
+func inSkippedDir(remotePath string) bool {
+	for _, dir := range strings.Split(path.Dir(remotePath), "/") {
+		if dir != "" && skipLocalDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// webdavFilename derives a stable, flat local file name from the remote path
+func webdavFilename(remotePath string) string {
+	sum := sha1.Sum([]byte(remotePath))
+	return hex.EncodeToString(sum[:8]) + strings.ToLower(path.Ext(remotePath))
+}
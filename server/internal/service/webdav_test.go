@@ -0,0 +1,99 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestWebDAVService_Sync(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	trash := NewTrashService(db, settings, NewLibraryService(db), NewHistoryService(db, nil), NewCacheService(db, settings, dataDir), dataDir)
+	svc := NewWebDAVService(db, settings, trash, dataDir)
+
+	ctx := context.Background()
+	fs := webdav.NewMemFS()
+	require.NoError(t, fs.Mkdir(ctx, "/Photos", 0755))
+	require.NoError(t, fs.Mkdir(ctx, "/Photos/Trip", 0755))
+	writePNG := func(name string, w, h int) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+		f, err := fs.OpenFile(ctx, name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		require.NoError(t, err)
+		_, err = f.Write(buf.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	writePNG("/Photos/a.png", 120, 80)
+	writePNG("/Photos/Trip/b.png", 80, 120)
+
+	var failing atomic.Bool
+	handler := &webdav.Handler{FileSystem: fs, LockSystem: webdav.NewMemLS()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	require.NoError(t, settings.Set("webdav_url", server.URL))
+	require.NoError(t, settings.Set("webdav_path", "Photos"))
+
+	result, err := svc.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, WebDAVSyncResult{Added: 2}, *result)
+	var a model.Image
+	require.NoError(t, db.Where("webdav_path = ?", "/Photos/a.png").First(&a).Error)
+	assert.FileExists(t, a.FilePath)
+	assert.Equal(t, "landscape", a.Orientation)
+	assert.NotEmpty(t, a.WebDAVETag)
+
+	// Unchanged files are not downloaded again
+	result, err = svc.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, WebDAVSyncResult{}, *result)
+
+	// A changed file (new ETag) is downloaded and read again
+	writePNG("/Photos/a.png", 60, 100)
+	result, err = svc.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, WebDAVSyncResult{Updated: 1}, *result)
+	var updated model.Image
+	require.NoError(t, db.First(&updated, a.ID).Error)
+	assert.Equal(t, "portrait", updated.Orientation)
+	assert.NotEqual(t, a.WebDAVETag, updated.WebDAVETag)
+
+	// A failed listing leaves the photos alone
+	require.NoError(t, fs.RemoveAll(ctx, "/Photos/Trip"))
+	failing.Store(true)
+	_, err = svc.Sync()
+	assert.Error(t, err)
+	var count int64
+	db.Model(&model.Image{}).Where("source = ?", "webdav").Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	// Files deleted on the server are removed
+	failing.Store(false)
+	var b model.Image
+	require.NoError(t, db.Where("webdav_path = ?", "/Photos/Trip/b.png").First(&b).Error)
+	result, err = svc.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, WebDAVSyncResult{Removed: 1}, *result)
+	db.Unscoped().Model(&model.Image{}).Where("source = ?", "webdav").Count(&count)
+	assert.Equal(t, int64(1), count)
+	assert.NoFileExists(t, b.FilePath)
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// WebhookJob is the job type delivering an event to a webhook
+	WebhookJob = "webhook_delivery"
+
+	// EventWebhookTest is sent by the "send test" endpoint
+	EventWebhookTest = "webhook.test"
+
+	webhookTimeout    = 10 * time.Second
+	deliveryRetention = 30 * 24 * time.Hour // Deliveries are logged for a month
+)
+
+// WebhookEvents are the event types webhooks can subscribe to. Frequent
+// events like job progress and image requests are left out.
+var WebhookEvents = []string{
+	EventPhotoImported,
+	EventPhotoDisplayed,
+	EventPushStarted,
+	EventPushSucceeded,
+	EventPushFailed,
+	EventJobFinished,
+	EventDeviceStale,
+	EventDeviceUpdated,
+	EventDeviceDeleted,
+	EventSettingsChanged,
+}
+
+// webhookPayload is the JSON body posted to webhooks
+type webhookPayload struct {
+	ID    uint        `json:"id"` // Delivery ID, the same for all attempts
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+type deliveryPayload struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// WebhookService posts events from the EventBus to configured URLs. Each
+// delivery runs as a background job, so failed deliveries are retried with
+// backoff and pending ones survive a restart.
+type WebhookService struct {
+	db     *gorm.DB
+	jobs   *JobService
+	events *EventBus
+	client *http.Client
+
+	mu     sync.Mutex      // Guards wanted
+	wanted map[string]bool // Event types any enabled webhook is subscribed to
+}
+
+func NewWebhookService(db *gorm.DB, jobs *JobService, events *EventBus) *WebhookService {
+	s := &WebhookService{
+		db:     db,
+		jobs:   jobs,
+		events: events,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+	// 5 attempts over about 15 minutes; sending an event twice is better than not at all
+	jobs.Register(WebhookJob, JobType{Run: s.runDelivery, MaxAttempts: 5, Backoff: time.Minute, Resume: true})
+	return s
+}
+
+// List returns all webhooks
+func (s *WebhookService) List() ([]model.Webhook, error) {
+	webhooks := []model.Webhook{}
+	err := s.db.Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Get returns a webhook
+func (s *WebhookService) Get(id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := s.db.First(&webhook, id).Error; err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	return &webhook, nil
+}
+
+// Save validates and creates or updates a webhook. A secret is generated if
+// none is set.
+func (s *WebhookService) Save(webhook *model.Webhook) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http or https URL")
+	}
+
+	var events []string
+	for _, event := range strings.Split(webhook.Events, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		known := false
+		for _, e := range WebhookEvents {
+			known = known || e == event
+		}
+		if !known {
+			return fmt.Errorf("unknown event %q", event)
+		}
+		events = append(events, event)
+	}
+	webhook.Events = strings.Join(events, ",")
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	if err := s.db.Save(webhook).Error; err != nil {
+		return err
+	}
+	s.refresh()
+	return nil
+}
+
+// Delete removes a webhook and its delivery log
+func (s *WebhookService) Delete(id uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Webhook{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.refresh()
+	return nil
+}
+
+// Deliveries returns the most recent deliveries of a webhook
+func (s *WebhookService) Deliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+	err := s.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Start delivers events from the bus to the webhooks subscribed to them.
+// Events are queued without limit, so a burst is never dropped.
+func (s *WebhookService) Start() {
+	s.refresh()
+	events, _ := s.events.Queue(s.wants)
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case event := <-events:
+				s.dispatch(event)
+			case <-ticker.C:
+				s.db.Where("created_at < ?", time.Now().Add(-deliveryRetention)).Delete(&model.WebhookDelivery{})
+			}
+		}
+	}()
+}
+
+// refresh reloads the event types the enabled webhooks are subscribed to
+func (s *WebhookService) refresh() {
+	var webhooks []model.Webhook
+	if err := s.db.Where("enabled = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("Webhooks: failed to load webhooks: %v", err)
+		return
+	}
+	wanted := make(map[string]bool)
+	for _, webhook := range webhooks {
+		for _, e := range WebhookEvents {
+			if webhookWants(webhook, e) {
+				wanted[e] = true
+			}
+		}
+	}
+	s.mu.Lock()
+	s.wanted = wanted
+	s.mu.Unlock()
+}
+
+// wants filters the events queued for dispatch, without touching the database
+func (s *WebhookService) wants(event Event) bool {
+	// Deliveries are jobs themselves, reporting them would never end
+	if job, ok := event.Data.(model.Job); ok && job.Type == WebhookJob {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wanted[event.Type]
+}
+
+// dispatch queues a delivery of an event to every webhook subscribed to it
+func (s *WebhookService) dispatch(event Event) {
+	var webhooks []model.Webhook
+	if err := s.db.Where("enabled = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("Webhooks: failed to load webhooks: %v", err)
+		return
+	}
+	for _, webhook := range webhooks {
+		if !webhookWants(webhook, event.Type) {
+			continue
+		}
+		if _, err := s.enqueue(webhook, event); err != nil {
+			log.Printf("Webhooks: failed to queue %s for webhook %d: %v", event.Type, webhook.ID, err)
+		}
+	}
+}
+
+// webhookWants reports whether a webhook is subscribed to an event type
+func webhookWants(webhook model.Webhook, eventType string) bool {
+	if webhook.Events == "" {
+		for _, e := range WebhookEvents {
+			if e == eventType {
+				return true
+			}
+		}
+		return false
+	}
+	for _, e := range strings.Split(webhook.Events, ",") {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// newDelivery logs a delivery with its final JSON body
+func (s *WebhookService) newDelivery(webhook model.Webhook, event Event) (*model.WebhookDelivery, error) {
+	delivery := model.WebhookDelivery{WebhookID: webhook.ID, Event: event.Type, Status: model.DeliveryPending}
+	if err := s.db.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(webhookPayload{ID: delivery.ID, Event: event.Type, Time: event.Time, Data: event.Data})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(body)
+	if err := s.db.Model(&delivery).UpdateColumn("payload", delivery.Payload).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s *WebhookService) enqueue(webhook model.Webhook, event Event) (*model.WebhookDelivery, error) {
+	delivery, err := s.newDelivery(webhook, event)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.jobs.Enqueue(WebhookJob, "", deliveryPayload{DeliveryID: delivery.ID}); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// SendTest sends a webhook.test event right away, without retries, and
+// returns the logged delivery
+func (s *WebhookService) SendTest(id uint) (*model.WebhookDelivery, error) {
+	webhook, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := s.newDelivery(*webhook, Event{
+		Type: EventWebhookTest,
+		Data: map[string]interface{}{"webhook_id": webhook.ID, "name": webhook.Name},
+		Time: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.deliver(context.Background(), *webhook, delivery, true)
+	return delivery, nil
+}
+
+func (s *WebhookService) runDelivery(ctx context.Context, run *JobRun) (interface{}, error) {
+	var payload deliveryPayload
+	if err := run.Payload(&payload); err != nil {
+		return nil, NoRetry(err)
+	}
+	var delivery model.WebhookDelivery
+	if err := s.db.First(&delivery, payload.DeliveryID).Error; err != nil {
+		return nil, NoRetry(errors.New("delivery not found"))
+	}
+	webhook, err := s.Get(delivery.WebhookID)
+	if err != nil {
+		return nil, NoRetry(err)
+	}
+
+	if err := s.deliver(ctx, *webhook, &delivery, run.LastAttempt()); err != nil {
+		return nil, err
+	}
+	return map[string]int{"response_code": delivery.ResponseCode}, nil
+}
+
+// deliver posts a delivery once and logs the attempt. Unless last, a failed
+// attempt stays pending for a retry.
+func (s *WebhookService) deliver(ctx context.Context, webhook model.Webhook, delivery *model.WebhookDelivery, last bool) error {
+	err := s.post(ctx, webhook, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.Error = ""
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliverySucceeded
+		delivery.DeliveredAt = &now
+	case last || errors.As(err, &noRetryError{}):
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Error = err.Error()
+	}
+	if saveErr := s.db.Model(delivery).UpdateColumns(map[string]interface{}{
+		"status":        delivery.Status,
+		"attempts":      delivery.Attempts,
+		"response_code": delivery.ResponseCode,
+		"error":         delivery.Error,
+		"delivered_at":  delivery.DeliveredAt,
+	}).Error; saveErr != nil {
+		log.Printf("Webhooks: failed to log delivery %d: %v", delivery.ID, saveErr)
+	}
+	return err
+}
+
+// post sends the payload signed with the webhook's secret. Client errors
+// other than timeouts and rate limits are not retried.
+func (s *WebhookService) post(ctx context.Context, webhook model.Webhook, delivery *model.WebhookDelivery) error {
+	delivery.ResponseCode = 0
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return NoRetry(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "photoframe-server")
+	req.Header.Set("X-Photoframe-Event", delivery.Event)
+	req.Header.Set("X-Photoframe-Delivery", fmt.Sprint(delivery.ID))
+	req.Header.Set("X-Photoframe-Signature", SignWebhook(webhook.Secret, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	delivery.ResponseCode = resp.StatusCode
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	err = fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return NoRetry(err)
+	}
+	return err
+}
+
+// SignWebhook returns the X-Photoframe-Signature header of a body:
+// "sha256=" and the hex HMAC-SHA256 of the body keyed with the secret
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
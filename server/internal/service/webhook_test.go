@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookService(t *testing.T) {
+	db := setupTestDB(t)
+	bus := NewEventBus()
+	jobs := NewJobService(db, bus)
+	svc := NewWebhookService(db, jobs, bus)
+	// Retry right away
+	jobs.Register(WebhookJob, JobType{Run: svc.runDelivery, MaxAttempts: 3, Backoff: 10 * time.Millisecond, Resume: true})
+
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	var mu sync.Mutex
+	var requests []request
+	status := []int{http.StatusInternalServerError, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, request{r.Header, body})
+		code := http.StatusOK
+		if len(status) > 0 {
+			code, status = status[0], status[1:]
+		}
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	assert.Error(t, svc.Save(&model.Webhook{URL: "ftp://example.com"}))
+	assert.Error(t, svc.Save(&model.Webhook{URL: server.URL, Events: "push.failed,nope"}))
+
+	webhook := model.Webhook{Name: "test", URL: server.URL, Events: " push.failed , device.stale", Enabled: true}
+	require.NoError(t, svc.Save(&webhook))
+	assert.Equal(t, "push.failed,device.stale", webhook.Events)
+	assert.Len(t, webhook.Secret, 64)
+
+	jobs.Start(1)
+	svc.Start()
+
+	// Not subscribed, then failing once before it is delivered
+	bus.Publish(EventPushSucceeded, map[string]interface{}{"device_id": 1})
+	bus.Publish(EventPushFailed, map[string]interface{}{"device_id": 1, "error": "timeout"})
+
+	var deliveries []model.WebhookDelivery
+	var err error
+	require.Eventually(t, func() bool {
+		deliveries, err = svc.Deliveries(webhook.ID, 10)
+		return err == nil && len(deliveries) == 1 && deliveries[0].Status == model.DeliverySucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+	delivery := deliveries[0]
+	assert.Equal(t, EventPushFailed, delivery.Event)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseCode)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	mu.Lock()
+	require.Len(t, requests, 2)
+	for _, req := range requests {
+		assert.Equal(t, EventPushFailed, req.header.Get("X-Photoframe-Event"))
+		assert.Equal(t, SignWebhook(webhook.Secret, req.body), req.header.Get("X-Photoframe-Signature"))
+	}
+	var payload struct {
+		ID    uint                   `json:"id"`
+		Event string                 `json:"event"`
+		Data  map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(requests[1].body, &payload))
+	mu.Unlock()
+	assert.Equal(t, delivery.ID, payload.ID)
+	assert.Equal(t, EventPushFailed, payload.Event)
+	assert.Equal(t, "timeout", payload.Data["error"])
+
+	// Client errors are not retried
+	mu.Lock()
+	status = []int{http.StatusGone}
+	mu.Unlock()
+	bus.Publish(EventDeviceStale, map[string]interface{}{"device_id": 1})
+	require.Eventually(t, func() bool {
+		deliveries, err = svc.Deliveries(webhook.ID, 10)
+		return err == nil && len(deliveries) == 2 && deliveries[0].Status == model.DeliveryFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusGone, deliveries[0].ResponseCode)
+
+	// Test deliveries are sent right away, even if not subscribed
+	test, err := svc.SendTest(webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, EventWebhookTest, test.Event)
+	assert.Equal(t, model.DeliverySucceeded, test.Status)
+
+	require.NoError(t, svc.Delete(webhook.ID))
+	deliveries, err = svc.Deliveries(webhook.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestWebhookService_Burst(t *testing.T) {
+	db := setupTestDB(t)
+	bus := NewEventBus()
+	jobs := NewJobService(db, bus)
+	svc := NewWebhookService(db, jobs, bus)
+	require.NoError(t, svc.Save(&model.Webhook{URL: "http://127.0.0.1:1", Events: "push.failed", Enabled: true}))
+	svc.Start()
+
+	// Far more events than the bus buffers, mixed with ones nobody wants
+	const events = 300
+	for i := 0; i < events; i++ {
+		bus.Publish(EventPushFailed, map[string]interface{}{"device_id": i})
+		bus.Publish(EventPushSucceeded, map[string]interface{}{"device_id": i})
+		bus.Publish(EventJobUpdated, model.Job{Type: WebhookJob})
+	}
+
+	var count int64
+	require.Eventually(t, func() bool {
+		db.Model(&model.WebhookDelivery{}).Count(&count)
+		return count == events
+	}, 10*time.Second, 10*time.Millisecond)
+	db.Model(&model.WebhookDelivery{}).Where("event <> ?", EventPushFailed).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestSignWebhook(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13", SignWebhook("secret", []byte("{}")))
+}
+
+func TestCheckStale(t *testing.T) {
+	db := setupTestDB(t)
+	bus := NewEventBus()
+	events, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	devices := NewDeviceService(db, NewSettingsService(db), nil, nil, nil, bus)
+
+	now := time.Now()
+	seen := now.Add(-25 * time.Hour)
+	require.NoError(t, db.Create(&model.Device{Name: "Hallway", Host: "hallway.local", LastSeenAt: &seen}).Error)
+	require.NoError(t, db.Create(&model.Device{Name: "New", Host: "new.local"}).Error)
+
+	// Reported once until seen again
+	devices.checkStale(now)
+	devices.checkStale(now)
+	require.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, EventDeviceStale, event.Type)
+	assert.Equal(t, "Hallway", event.Data.(map[string]interface{})["device"])
+
+	var device model.Device
+	require.NoError(t, db.First(&device, "name = ?", "Hallway").Error)
+	devices.Seen(&device)
+	<-events
+	devices.checkStale(now)
+	devices.checkStale(now.Add(25 * time.Hour))
+	assert.Len(t, events, 1)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"gorm.io/gorm"
+)
+
+// DefaultFavoriteWeight is how many times more often favorites are shown,
+// unless the favorite_weight setting says otherwise
+const DefaultFavoriteWeight = 3.0
+
+// Visible is a query scope that leaves out hidden photos
+func Visible(db *gorm.DB) *gorm.DB {
+	return db.Where("images.hidden = ?", false)
+}
+
+// FavoriteWeight returns the weight multiplier of favorite photos
+func (s *SettingsService) FavoriteWeight() float64 {
+	val, _ := s.Get("favorite_weight")
+	if w, err := strconv.ParseFloat(val, 64); err == nil && w > 0 {
+		return w
+	}
+	return DefaultFavoriteWeight
+}
+
+// PickWeighted picks up to n distinct visible images matching the query, at
+// random with probability proportional to their weight (times favoriteWeight
+// for favorites). Photos with a weight of 0 are never picked.
+func PickWeighted(query *gorm.DB, n int, favoriteWeight float64) ([]uint, error) {
+	var candidates []struct {
+		ID       uint
+		Weight   float64
+		Favorite bool
+	}
+	err := query.Session(&gorm.Session{}).Model(&model.Image{}).Scopes(Visible).
+		Select("images.id, images.weight, images.favorite").
+		Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Weighted sampling without replacement (Efraimidis-Spirakis): every
+	// candidate draws u^(1/w), the n largest keys win
+	type keyed struct {
+		id  uint
+		key float64
+	}
+	keys := make([]keyed, 0, len(candidates))
+	for _, c := range candidates {
+		w := c.Weight
+		if c.Favorite {
+			w *= favoriteWeight
+		}
+		if w <= 0 {
+			continue
+		}
+		keys = append(keys, keyed{id: c.ID, key: math.Pow(rand.Float64(), 1/w)})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	ids := make([]uint, len(keys))
+	for i, k := range keys {
+		ids[i] = k.id
+	}
+	return ids, nil
+}
+
+// PickWeightedImage picks a single image with PickWeighted
+func PickWeightedImage(query *gorm.DB, favoriteWeight float64) (*model.Image, error) {
+	ids, err := PickWeighted(query, 1, favoriteWeight)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var item model.Image
+	if err := query.Session(&gorm.Session{NewDB: true}).First(&item, ids[0]).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPickWeighted(t *testing.T) {
+	db := setupTestDB(t)
+
+	images := []model.Image{
+		{Caption: "normal", Source: "local"},
+		{Caption: "favorite", Source: "local", Favorite: true},
+		{Caption: "hidden", Source: "local", Hidden: true, Favorite: true},
+		{Caption: "heavy", Source: "upload", Weight: 5},
+	}
+	require.NoError(t, db.Create(&images).Error)
+	// Weight 0 can't be set on create, the column default applies
+	require.NoError(t, db.Create(&model.Image{Caption: "never", Source: "local"}).Error)
+	require.NoError(t, db.Model(&model.Image{}).Where("caption = ?", "never").UpdateColumn("weight", 0).Error)
+
+	local := db.Where("source = ?", "local")
+	picks := map[uint]int{}
+	for i := 0; i < 4000; i++ {
+		ids, err := PickWeighted(local, 1, DefaultFavoriteWeight)
+		require.NoError(t, err)
+		require.Len(t, ids, 1)
+		picks[ids[0]]++
+	}
+
+	// Hidden and zero weight photos are never picked, favorites 3 times as often
+	assert.Len(t, picks, 2)
+	assert.InDelta(t, 0.75, float64(picks[images[1].ID])/4000, 0.05)
+
+	// Without replacement, limited to the candidates
+	ids, err := PickWeighted(db, 10, DefaultFavoriteWeight)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{images[0].ID, images[1].ID, images[3].ID}, ids)
+
+	item, err := PickWeightedImage(db.Where("source = ?", "upload"), DefaultFavoriteWeight)
+	require.NoError(t, err)
+	assert.Equal(t, "heavy", item.Caption)
+
+	_, err = PickWeightedImage(db.Where("source = ?", "email"), DefaultFavoriteWeight)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestRandomCached(t *testing.T) {
+	dataDir := t.TempDir()
+	db := setupTestDB(t)
+	settings := NewSettingsService(db)
+	cache := NewCacheService(db, settings, dataDir)
+	remote := NewRemotePhotoService(db, settings, cache, nil, nil)
+
+	images := []model.Image{
+		{Caption: "cached", Source: "immich", ImmichAssetID: "a"},
+		{Caption: "never", Source: "immich", ImmichAssetID: "b"},
+		{Caption: "uncached", Source: "immich", ImmichAssetID: "c"},
+	}
+	require.NoError(t, db.Create(&images).Error)
+	require.NoError(t, db.Model(&images[1]).UpdateColumn("weight", 0).Error)
+	for _, item := range images[:2] {
+		require.NoError(t, cache.Put(cacheKey(item, VariantDisplay), item.ID, VariantDisplay, []byte(item.Caption)))
+	}
+
+	// Only cached photos with a weight are served
+	for i := 0; i < 20; i++ {
+		item, data, err := remote.RandomCached("immich")
+		require.NoError(t, err)
+		assert.Equal(t, "cached", item.Caption)
+		assert.Equal(t, []byte("cached"), data)
+	}
+	_, _, err := remote.RandomCached("synology")
+	assert.Error(t, err)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/aitjcize/photoframe-server/server/internal/service"
 	"github.com/aitjcize/photoframe-server/server/pkg/googlephotos"
 	"github.com/aitjcize/photoframe-server/server/pkg/photoframe"
+	"github.com/aitjcize/photoframe-server/server/pkg/telegram"
 	"github.com/aitjcize/photoframe-server/server/pkg/weather"
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
@@ -69,10 +70,14 @@ func main() {
 	// Initialize Overlay
 	weatherClient := weather.NewClient()
 	overlayService := service.NewOverlayService(weatherClient, settingsService)
-	// Initialize Synology Photos Service
-	synologyService := service.NewSynologyService(database, settingsService)
+	// Initialize Dashboard renderer (info screens)
+	dashboardService := service.NewDashboardService(weatherClient, settingsService)
+	// Initialize Event Bus (live updates for the web UI, streamed at /api/events)
+	eventBus := service.NewEventBus()
+
+	// Initialize Background Jobs (job types are registered by the services below)
+	jobService := service.NewJobService(database, eventBus)
 
-	// Initialize Picker Service
 	dataDir := os.Getenv("DATA_DIR")
 	if dataDir == "" {
 		dataDir = "esp32-photoframe/data"
@@ -84,32 +89,97 @@ func main() {
 
 	cleanupTempThumbnails(dataDir)
 
-	pickerService := service.NewPickerService(googleClient, database, dataDir)
+	// Initialize Remote Photo Cache (local mirror of Synology/Immich photos)
+	cacheService := service.NewCacheService(database, settingsService, dataDir)
+
+	// Initialize Trash (also removes photos that syncs find gone from their source)
+	libraryService := service.NewLibraryService(database)
+	historyService := service.NewHistoryService(database, eventBus)
+	trashService := service.NewTrashService(database, settingsService, libraryService, historyService, cacheService, dataDir)
+
+	// Initialize Synology Photos Service
+	synologyService := service.NewSynologyService(database, settingsService, jobService, trashService)
+	synologyService.Restart()
+	// Initialize Immich Service
+	immichService := service.NewImmichService(database, settingsService, trashService)
+
+	remoteService := service.NewRemotePhotoService(database, settingsService, cacheService, synologyService, immichService)
+
+	// Initialize Duplicate Detection (hashes photos on import, backfills the rest)
+	duplicateService := service.NewDuplicateService(database, settingsService, remoteService)
+	duplicateService.Start()
+
+	pickerService := service.NewPickerService(googleClient, database, dataDir, duplicateService, jobService, eventBus)
+
+	// Initialize Google album sync (periodic import of selected albums)
+	googleAlbumService := service.NewGoogleAlbumService(googleClient, database, settingsService, trashService, dataDir)
+	googleAlbumService.Restart()
+
+	// Initialize Local Folder Source (scans and watches configured directories)
+	localService := service.NewLocalSourceService(database, settingsService, trashService, dataDir)
+	localService.Restart()
+
+	// Initialize WebDAV Source (periodic sync of a remote folder, e.g. Nextcloud)
+	webdavService := service.NewWebDAVService(database, settingsService, trashService, dataDir)
+	webdavService.Restart()
+
+	// Initialize Placeholder (shown when no photo can be served)
+	placeholderService := service.NewPlaceholderService(dataDir)
 
 	// Initialize PhotoFrame Client
 	photoframeClient := photoframe.NewClient()
 
 	// Initialize Device Service
-	deviceService := service.NewDeviceService(database, settingsService, processorService, overlayService, photoframeClient)
-	deviceHandler := handler.NewDeviceHandler(deviceService, synologyService, database)
+	deviceService := service.NewDeviceService(database, settingsService, processorService, overlayService, photoframeClient, eventBus)
+	deviceService.Start()
+	deviceHandler := handler.NewDeviceHandler(deviceService, remoteService, placeholderService, historyService, database)
 
 	// Initialize Telegram Service
-	// Pass deviceService as Pusher
-	telegramService := service.NewTelegramService(database, dataDir, settingsService, deviceService)
+	// Pass deviceService for pushing photos and the bot commands
+	telegramService := service.NewTelegramService(database, dataDir, settingsService, deviceService, duplicateService, eventBus, trashService)
 	telegramToken, _ := settingsService.Get("telegram_bot_token")
 	if telegramToken != "" {
 		telegramService.Restart(telegramToken)
 	}
 
+	// Initialize Email Source (polls an IMAP inbox, pushes with deviceService like the bot)
+	emailService := service.NewEmailService(database, settingsService, dataDir, deviceService, duplicateService, eventBus)
+	emailService.Restart()
+
+	// Initialize MQTT (Home Assistant discovery and commands for each device)
+	mqttService := service.NewMQTTService(database, settingsService, deviceService, remoteService, historyService, eventBus, dataDir)
+	mqttService.Restart()
+
 	// Initialize Handlers
-	h := handler.NewHandler(settingsService, telegramService, googleClient)
-	googleHandler := handler.NewGoogleHandler(googleClient, pickerService, database, dataDir)
+	h := handler.NewHandler(settingsService, telegramService, localService, webdavService, emailService, googleAlbumService, synologyService, mqttService, googleClient, eventBus)
+	googleHandler := handler.NewGoogleHandler(googleClient, pickerService, googleAlbumService, database, dataDir)
 	sh := handler.NewSynologyHandler(synologyService)
+	imh := handler.NewImmichHandler(immichService)
 	// Reuse 'gh' variable name for GalleryHandler because I used 'gh' in routes above.
 	// Wait, 'gh' was GoogleHandler before. I should rename GoogleHandler to 'googleHandler' and 'gh' to GalleryHandler to match my routes change.
-	gh := handler.NewGalleryHandler(database, synologyService, dataDir)
-	ih := handler.NewImageHandler(settingsService, overlayService, processorService, googleClient, synologyService, database, dataDir)
+	collectionService := service.NewCollectionService(database)
+	trashService.Start()
+	batchService := service.NewBatchService(database, libraryService, trashService, deviceService, remoteService, historyService, jobService, dataDir)
+	// Initialize Webhooks (registers its job type, so before the jobs start)
+	webhookService := service.NewWebhookService(database, jobService, eventBus)
+	webhookService.Start()
+	jobService.Start(service.DefaultJobWorkers)
+	gh := handler.NewGalleryHandler(database, remoteService, deviceService, libraryService, collectionService, historyService, duplicateService, trashService, eventBus, dataDir)
+	libh := handler.NewLibraryHandler(libraryService, collectionService)
+	duph := handler.NewDuplicateHandler(duplicateService)
+	trh := handler.NewTrashHandler(trashService)
+	bh := handler.NewBatchHandler(batchService)
+	jh := handler.NewJobHandler(jobService)
+	evh := handler.NewEventHandler(eventBus)
+	whh := handler.NewWebhookHandler(webhookService)
+	ih := handler.NewImageHandler(settingsService, overlayService, dashboardService, processorService, googleClient, remoteService, placeholderService, collectionService, historyService, deviceService, database, dataDir)
 	ah := handler.NewAuthHandler(authService)
+	dh := handler.NewDashboardHandler(dashboardService)
+	lh := handler.NewLocalHandler(localService)
+	wh := handler.NewWebDAVHandler(webdavService)
+	emh := handler.NewEmailHandler(emailService)
+	ch := handler.NewCacheHandler(cacheService)
+	th := handler.NewTelegramHandler(telegramService)
 
 	// Echo instance
 	e := echo.New()
@@ -136,6 +206,8 @@ func main() {
 
 	// Public Health Check
 	e.GET("/api/status", h.HealthCheck)
+	// Telegram webhook, authenticated by its secret token
+	e.POST(telegram.WebhookPath, th.Webhook)
 	// Public Serve Thumbnail/Image (Actually Request says image endpoint SHOULD be protected)
 	// The user requested /image/:source to be protected.
 	// We need to support ?token= or Authorization header.
@@ -144,6 +216,9 @@ func main() {
 	e.GET("/image/:source", ih.ServeImage, authMiddleware)
 	// Telegram image after specific update ID (for fetching new images)
 	e.GET("/image/telegram/after/:updateID", ih.ServeTelegramImageAfter, authMiddleware)
+	// Albums and smart collections, across all sources
+	e.GET("/image/album/:id", ih.ServeAlbumImage, authMiddleware)
+	e.GET("/image/collection/:id", ih.ServeCollectionImage, authMiddleware)
 	// Thumbnail likely needs protection too, or obscure IDs. For now, keep public as they are temporary?
 	// User said "access the /image/<source>/ endpoint. This one... people can't just access".
 	// Let's protect main image endpoint.
@@ -162,6 +237,9 @@ func main() {
 	protectedApi.PUT("/devices/:id", deviceHandler.UpdateDevice)
 	protectedApi.DELETE("/devices/:id", deviceHandler.DeleteDevice)
 	protectedApi.POST("/devices/:id/push", deviceHandler.PushToDevice)
+	protectedApi.GET("/devices/:id/fallback", deviceHandler.GetFallback)
+	protectedApi.PUT("/devices/:id/fallback", deviceHandler.UploadFallback)
+	protectedApi.DELETE("/devices/:id/fallback", deviceHandler.DeleteFallback)
 
 	// Device Tokens (Protected)
 	protectedApi.POST("/auth/tokens", ah.GenerateDeviceToken)
@@ -173,22 +251,106 @@ func main() {
 	protectedApi.GET("/gallery/photos", gh.ListPhotos)
 	protectedApi.GET("/gallery/thumbnail/:id", gh.GetThumbnail)
 	protectedApi.DELETE("/gallery/photos/:id", gh.DeletePhoto)
+	protectedApi.GET("/gallery/photos/:id/history", gh.GetPhotoHistory)
 	protectedApi.DELETE("/gallery/photos", gh.DeletePhotos)
+	protectedApi.PATCH("/gallery/photos", gh.UpdatePhotos)
+	protectedApi.POST("/gallery/batch", bh.Batch)
+	protectedApi.POST("/gallery/upload", gh.Upload, echoMiddleware.BodyLimit("200M"))
+	protectedApi.GET("/gallery/duplicates", duph.ListDuplicates)
+	protectedApi.POST("/gallery/duplicates/scan", duph.Scan)
+	protectedApi.GET("/gallery/trash", trh.ListTrash)
+	protectedApi.POST("/gallery/trash/restore", trh.RestorePhotos)
+	protectedApi.DELETE("/gallery/trash", trh.EmptyTrash)
+
+	// Albums, Tags and Smart Collections (Protected)
+	protectedApi.GET("/gallery/albums", libh.ListAlbums)
+	protectedApi.POST("/gallery/albums", libh.CreateAlbum)
+	protectedApi.PUT("/gallery/albums/:id", libh.UpdateAlbum)
+	protectedApi.DELETE("/gallery/albums/:id", libh.DeleteAlbum)
+	protectedApi.POST("/gallery/albums/:id/photos", libh.AddAlbumPhotos)
+	protectedApi.DELETE("/gallery/albums/:id/photos", libh.RemoveAlbumPhotos)
+	protectedApi.GET("/gallery/tags", libh.ListTags)
+	protectedApi.PUT("/gallery/tags/:id", libh.UpdateTag)
+	protectedApi.DELETE("/gallery/tags/:id", libh.DeleteTag)
+	protectedApi.POST("/gallery/photos/tags", libh.TagPhotos)
+	protectedApi.DELETE("/gallery/photos/tags", libh.UntagPhotos)
+	protectedApi.GET("/gallery/collections", libh.ListCollections)
+	protectedApi.POST("/gallery/collections", libh.CreateCollection)
+	protectedApi.PUT("/gallery/collections/:id", libh.UpdateCollection)
+	protectedApi.DELETE("/gallery/collections/:id", libh.DeleteCollection)
+
+	// Live Events (Protected, Server-Sent Events)
+	protectedApi.GET("/events", evh.Stream)
+
+	// Webhooks (Protected)
+	protectedApi.GET("/webhooks", whh.ListWebhooks)
+	protectedApi.POST("/webhooks", whh.CreateWebhook)
+	protectedApi.PUT("/webhooks/:id", whh.UpdateWebhook)
+	protectedApi.DELETE("/webhooks/:id", whh.DeleteWebhook)
+	protectedApi.POST("/webhooks/:id/test", whh.TestWebhook)
+	protectedApi.GET("/webhooks/:id/deliveries", whh.ListDeliveries)
+
+	// Background Jobs (Protected)
+	protectedApi.GET("/jobs", jh.ListJobs)
+	protectedApi.GET("/jobs/:id", jh.GetJob)
+	protectedApi.POST("/jobs/:id/cancel", jh.CancelJob)
+
+	// Dashboard (Protected)
+	protectedApi.GET("/dashboard/template", dh.GetTemplate)
+	protectedApi.PUT("/dashboard/template", dh.UpdateTemplate)
+	protectedApi.GET("/dashboard/preview", dh.Preview)
 
 	// Google Picker (Protected)
 	protectedApi.GET("/google/picker/session", googleHandler.CreatePickerSession)
 	protectedApi.GET("/google/picker/poll/:id", googleHandler.PollPickerSession)
 	protectedApi.GET("/google/picker/progress/:id", googleHandler.PollPickerProgress)
 	protectedApi.POST("/google/picker/process/:id", googleHandler.ProcessPickerSession)
+	protectedApi.GET("/google/albums", googleHandler.ListAlbums)
+	protectedApi.POST("/google/albums/sync", googleHandler.SyncAlbums)
 
 	// Synology (Protected)
 	protectedApi.POST("/synology/test", sh.TestConnection)
 	protectedApi.POST("/synology/sync", sh.Sync)
+	protectedApi.GET("/synology/sync/status", sh.GetSyncStatus)
 	protectedApi.POST("/synology/clear", sh.Clear)
 	protectedApi.GET("/synology/albums", sh.ListAlbums)
+	protectedApi.GET("/synology/people", sh.ListPeople)
+	protectedApi.GET("/synology/tags", sh.ListTags)
 	protectedApi.GET("/synology/count", sh.GetPhotoCount)
 	protectedApi.POST("/synology/logout", sh.Logout)
 
+	// Immich (Protected)
+	protectedApi.POST("/immich/test", imh.TestConnection)
+	protectedApi.POST("/immich/sync", imh.Sync)
+	protectedApi.POST("/immich/clear", imh.Clear)
+	protectedApi.GET("/immich/albums", imh.ListAlbums)
+	protectedApi.GET("/immich/count", imh.GetPhotoCount)
+
+	// Local Folders (Protected)
+	protectedApi.POST("/local/scan", lh.Scan)
+	protectedApi.GET("/local/count", lh.GetPhotoCount)
+
+	// WebDAV (Protected)
+	protectedApi.POST("/webdav/test", wh.TestConnection)
+	protectedApi.POST("/webdav/sync", wh.Sync)
+	protectedApi.POST("/webdav/clear", wh.Clear)
+	protectedApi.GET("/webdav/count", wh.GetPhotoCount)
+
+	// Email inbox (Protected)
+	protectedApi.POST("/email/test", emh.TestConnection)
+	protectedApi.POST("/email/poll", emh.Poll)
+	protectedApi.GET("/email/count", emh.GetPhotoCount)
+
+	// Telegram access control (Protected)
+	protectedApi.POST("/telegram/pairing", th.CreatePairing)
+	protectedApi.GET("/telegram/chats", th.ListChats)
+	protectedApi.PUT("/telegram/chats/:id", th.UpdateChat)
+	protectedApi.DELETE("/telegram/chats/:id", th.DeleteChat)
+
+	// Remote Photo Cache (Protected)
+	protectedApi.GET("/cache", ch.GetUsage)
+	protectedApi.DELETE("/cache", ch.Clear)
+
 	// Google Auth: Login (Protected - User initiates), Callback (Public - Google calls)
 	protectedApi.GET("/auth/google/login", googleHandler.Login)
 	protectedApi.POST("/auth/google/logout", googleHandler.Logout)
@@ -57,6 +57,7 @@ func (c *Client) getOAuthConfig() (*oauth2.Config, error) {
 		RedirectURL:  redirectURL,
 		Scopes: []string{
 			"https://www.googleapis.com/auth/photospicker.mediaitems.readonly",
+			"https://www.googleapis.com/auth/photoslibrary.readonly.appcreateddata",
 			"https://www.googleapis.com/auth/userinfo.email",
 			"https://www.googleapis.com/auth/userinfo.profile",
 		},
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 )
@@ -18,11 +19,18 @@ type Album struct {
 }
 
 type MediaItem struct {
-	ID         string `json:"id"`
-	ProductUrl string `json:"productUrl"`
-	BaseUrl    string `json:"baseUrl"`
-	MimeType   string `json:"mimeType"`
-	Filename   string `json:"filename"`
+	ID            string        `json:"id"`
+	ProductUrl    string        `json:"productUrl"`
+	BaseUrl       string        `json:"baseUrl"`
+	MimeType      string        `json:"mimeType"`
+	Filename      string        `json:"filename"`
+	MediaMetadata MediaMetadata `json:"mediaMetadata"`
+}
+
+type MediaMetadata struct {
+	CreationTime string `json:"creationTime"`
+	Width        string `json:"width"`
+	Height       string `json:"height"`
 }
 
 type albumsResponse struct {
@@ -35,67 +43,97 @@ type mediaItemsResponse struct {
 	NextPageToken string      `json:"nextPageToken"`
 }
 
+// ListAlbums returns all albums visible to the app. Since the 2025 Library API
+// changes this is limited to albums created by the app itself.
 func (c *Client) ListAlbums(pageSize int) ([]Album, error) {
 	client, err := c.GetClient()
 	if err != nil {
 		return nil, err
 	}
 
-	endpoint := "https://photoslibrary.googleapis.com/v1/albums"
-	u, _ := url.Parse(endpoint)
-	q := u.Query()
-	q.Set("pageSize", fmt.Sprintf("%d", pageSize))
-	u.RawQuery = q.Encode()
-
-	resp, err := client.Get(u.String())
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		buf := new(bytes.Buffer)
-		buf.ReadFrom(resp.Body)
-		return nil, fmt.Errorf("google photos api returned status: %d, body: %s", resp.StatusCode, buf.String())
-	}
-
-	var result albumsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	var albums []Album
+	pageToken := ""
+	for {
+		u, _ := url.Parse("https://photoslibrary.googleapis.com/v1/albums")
+		q := u.Query()
+		q.Set("pageSize", fmt.Sprintf("%d", pageSize))
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		u.RawQuery = q.Encode()
+
+		var result albumsResponse
+		if err := doJSON(client, "GET", u.String(), nil, &result); err != nil {
+			return nil, err
+		}
+
+		albums = append(albums, result.Albums...)
+		pageToken = result.NextPageToken
+		if pageToken == "" {
+			break
+		}
 	}
-
-	return result.Albums, nil
+	return albums, nil
 }
 
+// ListMediaItems returns all media items of an album, following pagination
 func (c *Client) ListMediaItems(albumID string, pageSize int) ([]MediaItem, error) {
 	client, err := c.GetClient()
 	if err != nil {
 		return nil, err
 	}
 
-	endpoint := "https://photoslibrary.googleapis.com/v1/mediaItems:search"
+	var items []MediaItem
+	pageToken := ""
+	for {
+		body := map[string]interface{}{
+			"albumId":  albumID,
+			"pageSize": pageSize,
+		}
+		if pageToken != "" {
+			body["pageToken"] = pageToken
+		}
+
+		var result mediaItemsResponse
+		if err := doJSON(client, "POST", "https://photoslibrary.googleapis.com/v1/mediaItems:search", body, &result); err != nil {
+			return nil, err
+		}
+
+		items = append(items, result.MediaItems...)
+		pageToken = result.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	return items, nil
+}
 
-	body := map[string]interface{}{
-		"albumId":  albumID,
-		"pageSize": pageSize,
+func doJSON(client *http.Client, method, endpoint string, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		reqBody = bytes.NewBuffer(jsonBody)
 	}
 
-	jsonBody, _ := json.Marshal(body)
+	req, err := http.NewRequest(method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	resp, err := client.Post(endpoint, "application/json", bytes.NewBuffer(jsonBody))
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("google photos api returned status: %d", resp.StatusCode)
-	}
-
-	var result mediaItemsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(resp.Body)
+		return fmt.Errorf("google photos api returned status: %d, body: %s", resp.StatusCode, buf.String())
 	}
 
-	return result.MediaItems, nil
+	return json.NewDecoder(resp.Body).Decode(out)
 }
@@ -0,0 +1,75 @@
+package homeassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	BaseURL    string
+	Token      string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+type State struct {
+	EntityID    string                 `json:"entity_id"`
+	State       string                 `json:"state"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	LastChanged time.Time              `json:"last_changed"`
+}
+
+// FriendlyName returns the entity's friendly name, falling back to the entity ID
+func (s *State) FriendlyName() string {
+	if name, ok := s.Attributes["friendly_name"].(string); ok && name != "" {
+		return name
+	}
+	return s.EntityID
+}
+
+// Unit returns the unit of measurement of a sensor, if any
+func (s *State) Unit() string {
+	unit, _ := s.Attributes["unit_of_measurement"].(string)
+	return unit
+}
+
+// GetState fetches the current state of an entity via the REST API
+func (c *Client) GetState(entityID string) (*State, error) {
+	endpoint := fmt.Sprintf("%s/api/states/%s", c.BaseURL, url.PathEscape(entityID))
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("home assistant returned status: %d", resp.StatusCode)
+	}
+
+	var state State
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
@@ -0,0 +1,80 @@
+package imageops
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"os"
+	"strconv"
+)
+
+// hashSamples is the number of points averaged per hash cell in each
+// direction, which keeps hashing fast for large photos
+const hashSamples = 8
+
+// DHash computes a 64-bit difference hash: the image is reduced to 9x8 gray
+// cells and each bit tells whether a cell is brighter than its right
+// neighbour. Resized, recompressed or slightly edited copies of a photo have
+// hashes that differ in only a few bits.
+func DHash(img image.Image) uint64 {
+	b := img.Bounds()
+	var cells [8][9]float64
+	for cy := 0; cy < 8; cy++ {
+		for cx := 0; cx < 9; cx++ {
+			var sum float64
+			for sy := 0; sy < hashSamples; sy++ {
+				y := b.Min.Y + (cy*hashSamples+sy)*b.Dy()/(8*hashSamples)
+				for sx := 0; sx < hashSamples; sx++ {
+					x := b.Min.X + (cx*hashSamples+sx)*b.Dx()/(9*hashSamples)
+					r, g, bl, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+				}
+			}
+			cells[cy][cx] = sum
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// FormatHash encodes a hash as 16 hex digits, as stored in images.phash
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// HashFile decodes an image file upright and returns its formatted DHash. The
+// hash is not rotation-invariant, so a camera original stored sideways must be
+// turned to match copies whose pixels were rotated (Telegram, NAS thumbnails).
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, _, err := Decode(f)
+	if err != nil {
+		return "", err
+	}
+	return FormatHash(DHash(img)), nil
+}
+
+// HashDistance returns the number of differing bits of two formatted hashes.
+// ok is false if either is not a valid hash.
+func HashDistance(a, b string) (distance int, ok bool) {
+	ha, errA := strconv.ParseUint(a, 16, 64)
+	hb, errB := strconv.ParseUint(b, 16, 64)
+	if errA != nil || errB != nil || len(a) != 16 || len(b) != 16 {
+		return 0, false
+	}
+	return bits.OnesCount64(ha ^ hb), true
+}
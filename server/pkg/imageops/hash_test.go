@@ -0,0 +1,80 @@
+package imageops
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	xdraw "golang.org/x/image/draw"
+)
+
+// testScene draws a few soft blobs, a stand-in for a photo
+func testScene(w, h int, flip bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			if flip {
+				fx = 1 - fx
+			}
+			v := 0.5 + 0.25*math.Sin(fx*7+fy*3) + 0.25*math.Cos(fx*fy*11)
+			img.Set(x, y, color.RGBA{uint8(255 * v), uint8(200 * fy), uint8(180 * fx), 255})
+		}
+	}
+	return img
+}
+
+func TestDHash(t *testing.T) {
+	original := testScene(1200, 800, false)
+	hash := FormatHash(DHash(original))
+	assert.Len(t, hash, 16)
+
+	// A downscaled, recompressed copy is a near-duplicate
+	small := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	xdraw.CatmullRom.Scale(small, small.Bounds(), original, original.Bounds(), xdraw.Src, nil)
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, small, &jpeg.Options{Quality: 60}))
+	copied, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+
+	d, ok := HashDistance(hash, FormatHash(DHash(copied)))
+	require.True(t, ok)
+	assert.LessOrEqual(t, d, 4)
+
+	// A different picture is not
+	d, ok = HashDistance(hash, FormatHash(DHash(testScene(1200, 800, true))))
+	require.True(t, ok)
+	assert.Greater(t, d, 16)
+}
+
+func TestHashDistance(t *testing.T) {
+	d, ok := HashDistance("00000000000000ff", "000000000000000f")
+	assert.True(t, ok)
+	assert.Equal(t, 4, d)
+
+	_, ok = HashDistance("", "000000000000000f")
+	assert.False(t, ok)
+	_, ok = HashDistance("-", "000000000000000f")
+	assert.False(t, ok)
+}
+
+func TestHashFile(t *testing.T) {
+	// A camera original stored sideways and a copy with rotated pixels, like
+	// the same photo received over Telegram
+	scene := testScene(1200, 800, false)
+	original := writeJPEG(t, Rotate(scene, 270), 6)
+	rotated := writeJPEG(t, scene, 1)
+
+	a, err := HashFile(original)
+	require.NoError(t, err)
+	b, err := HashFile(rotated)
+	require.NoError(t, err)
+	d, ok := HashDistance(a, b)
+	require.True(t, ok)
+	assert.LessOrEqual(t, d, 4)
+}
@@ -0,0 +1,128 @@
+package imageops
+
+import (
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/gen2brain/heic" // Register HEIC decoder (pure Go, no libheif needed)
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// SupportedExtensions lists the photo file extensions that can be decoded
+var SupportedExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".heic": true,
+	".heif": true,
+}
+
+// IsSupportedFile reports whether the file extension is a decodable photo format
+func IsSupportedFile(path string) bool {
+	return SupportedExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// ReadInfo returns the displayed dimensions and orientation ("landscape" or
+// "portrait") of an image file without decoding the whole image.
+func ReadInfo(path string) (width, height int, orientation string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, "landscape", err
+	}
+	defer f.Close()
+
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, "landscape", err
+	}
+	width, height = cfg.Width, cfg.Height
+	if format == "jpeg" {
+		if _, err := f.Seek(0, io.SeekStart); err == nil && readOrientation(f) >= 5 {
+			width, height = height, width // Stored sideways
+		}
+	}
+
+	orientation = "landscape"
+	if height > width {
+		orientation = "portrait"
+	}
+	return width, height, orientation, nil
+}
+
+// Decode decodes an image upright, like image.Decode but turning JPEG pixels
+// according to their EXIF orientation. HEIC images are already turned by the
+// decoder.
+func Decode(r io.ReadSeeker) (image.Image, string, error) {
+	img, format, err := image.Decode(r)
+	if err != nil || format != "jpeg" {
+		return img, format, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return img, format, nil
+	}
+	return Orient(img, readOrientation(r)), format, nil
+}
+
+// readOrientation returns the EXIF orientation (1-8) of a JPEG, 1 if it has none
+func readOrientation(r io.Reader) int {
+	x, err := exif.Decode(r)
+	if err != nil {
+		return 1
+	}
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+	o, err := tag.Int(0)
+	if err != nil || o < 1 || o > 8 {
+		return 1
+	}
+	return o
+}
+
+// Orient turns pixels stored with an EXIF orientation upright
+func Orient(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return Flip(img)
+	case 3:
+		return Rotate(img, 180)
+	case 4:
+		return Rotate(Flip(img), 180)
+	case 5:
+		return Rotate(Flip(img), 270)
+	case 6:
+		return Rotate(img, 90)
+	case 7:
+		return Rotate(Flip(img), 90)
+	case 8:
+		return Rotate(img, 270)
+	}
+	return img
+}
+
+// ReadCaptureTime returns when a photo was taken according to its EXIF data,
+// or nil if the file has none (PNG, screenshots, stripped metadata, ...).
+func ReadCaptureTime(path string) *time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	x, err := exif.Decode(f)
+	if err != nil {
+		return nil
+	}
+	t, err := x.DateTime()
+	if err != nil || t.IsZero() {
+		return nil
+	}
+	return &t
+}
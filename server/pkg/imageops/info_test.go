@@ -0,0 +1,79 @@
+package imageops
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeJPEG saves an image as a JPEG with an EXIF orientation tag, like a
+// camera does when the photo was taken with the camera turned
+func writeJPEG(t *testing.T, img image.Image, orientation uint16) string {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	data := buf.Bytes()
+
+	// APP1 segment: Exif header, little endian TIFF header and an IFD with
+	// only the orientation tag (SHORT)
+	var exif bytes.Buffer
+	exif.WriteString("Exif\x00\x00II*\x00")
+	for _, v := range []interface{}{
+		uint32(8), uint16(1), uint16(0x0112), uint16(3), uint32(1), orientation, uint16(0), uint32(0),
+	} {
+		require.NoError(t, binary.Write(&exif, binary.LittleEndian, v))
+	}
+	segment := append([]byte{0xFF, 0xE1, 0, 0}, exif.Bytes()...)
+	binary.BigEndian.PutUint16(segment[2:], uint16(exif.Len()+2))
+
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	out := append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+	require.NoError(t, os.WriteFile(path, out, 0644))
+	return path
+}
+
+func TestReadInfo(t *testing.T) {
+	// Landscape pixels shown as a portrait photo
+	path := writeJPEG(t, testScene(120, 80, false), 6)
+	width, height, orientation, err := ReadInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 80, width)
+	assert.Equal(t, 120, height)
+	assert.Equal(t, "portrait", orientation)
+
+	path = writeJPEG(t, testScene(120, 80, false), 1)
+	width, height, orientation, err = ReadInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 120, width)
+	assert.Equal(t, 80, height)
+	assert.Equal(t, "landscape", orientation)
+}
+
+func TestDecode(t *testing.T) {
+	scene := testScene(120, 80, false)
+	for orientation, stored := range map[uint16]image.Image{
+		3: Rotate(scene, 180),
+		6: Rotate(scene, 270),
+		8: Rotate(scene, 90),
+		2: Flip(scene),
+		5: Rotate(Flip(scene), 270),
+	} {
+		f, err := os.Open(writeJPEG(t, stored, orientation))
+		require.NoError(t, err)
+		img, format, err := Decode(f)
+		f.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, scene.Bounds(), img.Bounds(), "orientation %d", orientation)
+
+		d, ok := HashDistance(FormatHash(DHash(scene)), FormatHash(DHash(img)))
+		require.True(t, ok)
+		assert.LessOrEqual(t, d, 4, "orientation %d", orientation)
+	}
+}
@@ -74,3 +74,48 @@ func DrawCover(dst draw.Image, r image.Rectangle, src image.Image) {
 		}
 	}
 }
+
+// Rotate rotates an image clockwise by 90, 180 or 270 degrees. Other angles
+// return the image unchanged.
+func Rotate(src image.Image, degrees int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	var dst *image.RGBA
+	switch degrees {
+	case 90, 270:
+		dst = image.NewRGBA(image.Rect(0, 0, h, w))
+	case 180:
+		dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	default:
+		return src
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := src.At(b.Min.X+x, b.Min.Y+y)
+			switch degrees {
+			case 90:
+				dst.Set(h-1-y, x, c)
+			case 180:
+				dst.Set(w-1-x, h-1-y, c)
+			case 270:
+				dst.Set(y, w-1-x, c)
+			}
+		}
+	}
+	return dst
+}
+
+// Flip mirrors an image horizontally
+func Flip(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(w-1-x, y, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
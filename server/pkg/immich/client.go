@@ -0,0 +1,144 @@
+package immich
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Thumbnail sizes supported by the Immich asset thumbnail endpoint
+const (
+	SizeThumbnail = "thumbnail" // ~250px, usually WebP
+	SizePreview   = "preview"   // ~1440px JPEG
+)
+
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL, apiKey string, insecure bool) *Client {
+	transport := &http.Transport{}
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	// Accept both "https://immich.local" and "https://immich.local/api"
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api")
+
+	return &Client{
+		BaseURL: baseURL,
+		APIKey:  apiKey,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+	}
+}
+
+func (c *Client) get(path string, query url.Values) (*http.Response, error) {
+	endpoint := c.BaseURL + "/api" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", c.APIKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("immich rejected the API key (status %d)", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("immich api %s returned status: %d", path, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (c *Client) getJSON(path string, query url.Values, out interface{}) error {
+	resp, err := c.get(path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Ping checks that the server is reachable (no authentication required)
+func (c *Client) Ping() error {
+	var result PingResponse
+	if err := c.getJSON("/server/ping", nil, &result); err != nil {
+		return err
+	}
+	if result.Res != "pong" {
+		return fmt.Errorf("unexpected ping response: %q", result.Res)
+	}
+	return nil
+}
+
+// GetMe returns the user owning the API key
+func (c *Client) GetMe() (*User, error) {
+	var user User
+	if err := c.getJSON("/users/me", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListAlbums returns owned and shared albums (without assets)
+func (c *Client) ListAlbums() ([]Album, error) {
+	var owned []Album
+	if err := c.getJSON("/albums", nil, &owned); err != nil {
+		return nil, err
+	}
+
+	var shared []Album
+	if err := c.getJSON("/albums", url.Values{"shared": {"true"}}, &shared); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	albums := make([]Album, 0, len(owned)+len(shared))
+	for _, a := range append(owned, shared...) {
+		if seen[a.ID] {
+			continue
+		}
+		seen[a.ID] = true
+		albums = append(albums, a)
+	}
+	return albums, nil
+}
+
+// ListAlbumAssets returns all assets in an album
+func (c *Client) ListAlbumAssets(albumID string) ([]Asset, error) {
+	var album Album
+	if err := c.getJSON("/albums/"+url.PathEscape(albumID), nil, &album); err != nil {
+		return nil, err
+	}
+	return album.Assets, nil
+}
+
+// GetThumbnail fetches a resized version of an asset.
+// size: SizeThumbnail or SizePreview
+func (c *Client) GetThumbnail(assetID, size string) ([]byte, error) {
+	resp, err := c.get("/assets/"+url.PathEscape(assetID)+"/thumbnail", url.Values{"size": {size}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,122 @@
+package immich
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAPIKey = "test-key"
+
+// newTestServer mimics the subset of the Immich API used by the client
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/server/ping", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PingResponse{Res: "pong"})
+	})
+
+	authed := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("x-api-key") != testAPIKey {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+
+	mux.HandleFunc("/api/users/me", authed(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(User{ID: "u1", Email: "me@example.com", Name: "Me"})
+	}))
+	mux.HandleFunc("/api/albums", authed(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("shared") == "true" {
+			json.NewEncoder(w).Encode([]Album{
+				{ID: "a2", AlbumName: "Family", AssetCount: 5},
+				{ID: "a1", AlbumName: "Holiday", AssetCount: 2},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode([]Album{{ID: "a1", AlbumName: "Holiday", AssetCount: 2}})
+	}))
+	mux.HandleFunc("/api/albums/a1", authed(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Album{
+			ID:        "a1",
+			AlbumName: "Holiday",
+			Assets: []Asset{
+				{ID: "p1", Type: "IMAGE", ExifInfo: &ExifInfo{ExifImageWidth: 4000, ExifImageHeight: 3000, Orientation: "6"}},
+				{ID: "v1", Type: "VIDEO"},
+			},
+		})
+	}))
+	mux.HandleFunc("/api/assets/p1/thumbnail", authed(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("jpeg-" + r.URL.Query().Get("size")))
+	}))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_PingAndMe(t *testing.T) {
+	srv := newTestServer(t)
+
+	c := NewClient(srv.URL+"/api/", testAPIKey, false)
+	assert.Equal(t, srv.URL, c.BaseURL)
+	assert.NoError(t, c.Ping())
+
+	user, err := c.GetMe()
+	assert.NoError(t, err)
+	assert.Equal(t, "me@example.com", user.Email)
+}
+
+func TestClient_InvalidKey(t *testing.T) {
+	srv := newTestServer(t)
+
+	c := NewClient(srv.URL, "wrong", false)
+	assert.NoError(t, c.Ping())
+
+	_, err := c.GetMe()
+	assert.ErrorContains(t, err, "API key")
+}
+
+func TestClient_ListAlbums(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(srv.URL, testAPIKey, false)
+
+	albums, err := c.ListAlbums()
+	assert.NoError(t, err)
+	assert.Len(t, albums, 2)
+	assert.Equal(t, "Holiday", albums[0].AlbumName)
+	assert.Equal(t, "Family", albums[1].AlbumName)
+}
+
+func TestClient_ListAlbumAssets(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(srv.URL, testAPIKey, false)
+
+	assets, err := c.ListAlbumAssets("a1")
+	assert.NoError(t, err)
+	assert.Len(t, assets, 2)
+
+	// EXIF orientation 6 is rotated 90°, so the photo is displayed as portrait
+	w, h := assets[0].Dimensions()
+	assert.Equal(t, 3000, w)
+	assert.Equal(t, 4000, h)
+
+	_, err = c.ListAlbumAssets("missing")
+	assert.Error(t, err)
+}
+
+func TestClient_GetThumbnail(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(srv.URL, testAPIKey, false)
+
+	data, err := c.GetThumbnail("p1", SizePreview)
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg-preview", string(data))
+}
@@ -0,0 +1,66 @@
+package immich
+
+import "time"
+
+type PingResponse struct {
+	Res string `json:"res"`
+}
+
+type User struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type Album struct {
+	ID         string  `json:"id"`
+	AlbumName  string  `json:"albumName"`
+	AssetCount int     `json:"assetCount"`
+	Assets     []Asset `json:"assets,omitempty"`
+}
+
+type Asset struct {
+	ID               string    `json:"id"`
+	Type             string    `json:"type"` // "IMAGE", "VIDEO", "AUDIO" or "OTHER"
+	OriginalFileName string    `json:"originalFileName"`
+	FileCreatedAt    string    `json:"fileCreatedAt"`
+	IsTrashed        bool      `json:"isTrashed"`
+	ExifInfo         *ExifInfo `json:"exifInfo,omitempty"`
+}
+
+type ExifInfo struct {
+	ExifImageWidth   int    `json:"exifImageWidth"`
+	ExifImageHeight  int    `json:"exifImageHeight"`
+	Orientation      string `json:"orientation"` // EXIF orientation, e.g. "1" or "6"
+	Description      string `json:"description"`
+	DateTimeOriginal string `json:"dateTimeOriginal"`
+}
+
+// CapturedAt returns when the photo was taken, falling back to the file
+// creation time, or nil if neither is known
+func (a Asset) CapturedAt() *time.Time {
+	candidates := []string{a.FileCreatedAt}
+	if a.ExifInfo != nil {
+		candidates = append([]string{a.ExifInfo.DateTimeOriginal}, candidates...)
+	}
+	for _, v := range candidates {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			t = t.Local() // Stored like the other timestamps, so they compare in SQL
+			return &t
+		}
+	}
+	return nil
+}
+
+// Dimensions returns the displayed width and height, taking EXIF rotation into account
+func (a Asset) Dimensions() (width, height int) {
+	if a.ExifInfo == nil {
+		return 0, 0
+	}
+	width, height = a.ExifInfo.ExifImageWidth, a.ExifInfo.ExifImageHeight
+	switch a.ExifInfo.Orientation {
+	case "5", "6", "7", "8":
+		width, height = height, width
+	}
+	return width, height
+}
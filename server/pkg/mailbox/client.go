@@ -0,0 +1,283 @@
+package mailbox
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset" // Decode non UTF-8 subjects and file names
+	"github.com/emersion/go-message/mail"
+)
+
+// Connection security modes
+const (
+	SecurityTLS      = "tls"      // Implicit TLS, usually port 993
+	SecuritySTARTTLS = "starttls" // Upgrade a plain connection, usually port 143
+	SecurityNone     = "none"     // Plain text, only for local servers
+)
+
+// Attachments larger than this are skipped
+const maxAttachmentSize = 50 * 1024 * 1024
+
+// Config describes how to reach the mailbox
+type Config struct {
+	Host           string
+	Port           int // 0 = default port of the security mode
+	Security       string
+	Username       string
+	Password       string
+	SkipCertVerify bool
+}
+
+// Message is an email with the photos attached to it
+type Message struct {
+	UID       uint32
+	MessageID string
+	From      string   // Sender address, lower case
+	To        []string // To and Cc addresses, lower case
+	Subject   string
+	Date      time.Time
+	Photos    []Attachment
+}
+
+// Attachment is an image attached to (or inlined in) an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Client reads photo emails from an IMAP mailbox
+type Client struct {
+	c *client.Client
+}
+
+// Dial connects and logs in to the IMAP server
+func Dial(cfg Config) (*Client, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("imap host not configured")
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 993
+		if cfg.Security == SecuritySTARTTLS || cfg.Security == SecurityNone {
+			port = 143
+		}
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host, InsecureSkipVerify: cfg.SkipCertVerify}
+
+	var c *client.Client
+	var err error
+	switch cfg.Security {
+	case SecurityNone, SecuritySTARTTLS:
+		c, err = client.Dial(addr)
+	default:
+		c, err = client.DialTLS(addr, tlsConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	c.Timeout = 2 * time.Minute
+
+	if cfg.Security == SecuritySTARTTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Logout()
+			return nil, fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+
+	if err := c.Login(cfg.Username, cfg.Password); err != nil {
+		c.Logout()
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	return &Client{c: c}, nil
+}
+
+// Close logs out and closes the connection
+func (c *Client) Close() error {
+	return c.c.Logout()
+}
+
+// Select opens a folder, e.g. INBOX
+func (c *Client) Select(folder string) error {
+	_, err := c.c.Select(folder, false)
+	return err
+}
+
+// Unseen returns the UIDs of the unread messages in the selected folder
+func (c *Client) Unseen() ([]uint32, error) {
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	return c.c.UidSearch(criteria)
+}
+
+// Fetch downloads a message and extracts its photos. The message is not
+// marked as read.
+func (c *Client) Fetch(uid uint32) (*Message, error) {
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uid)
+
+	section := &imap.BodySectionName{Peek: true}
+	messages := make(chan *imap.Message, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.c.UidFetch(seqSet, []imap.FetchItem{section.FetchItem()}, messages)
+	}()
+
+	// The channel must be drained for the fetch to complete
+	var raw []byte
+	var readErr error
+	for msg := range messages {
+		if body := msg.GetBody(section); body != nil {
+			raw, readErr = io.ReadAll(body)
+		}
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("message %d not found", uid)
+	}
+
+	msg, err := Parse(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	msg.UID = uid
+	return msg, nil
+}
+
+// MarkSeen flags messages as read
+func (c *Client) MarkSeen(uids ...uint32) error {
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uids...)
+	return c.c.UidStore(seqSet, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.SeenFlag}, nil)
+}
+
+// Move moves messages to another folder
+func (c *Client) Move(folder string, uids ...uint32) error {
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uids...)
+	return c.c.UidMove(seqSet, folder)
+}
+
+// EnsureFolder creates a folder if it doesn't exist yet
+func (c *Client) EnsureFolder(name string) error {
+	mailboxes := make(chan *imap.MailboxInfo, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.c.List("", name, mailboxes)
+	}()
+
+	exists := false
+	for range mailboxes {
+		exists = true
+	}
+	if err := <-done; err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return c.c.Create(name)
+}
+
+// Parse reads an RFC 5322 message and extracts its image attachments
+func Parse(r io.Reader) (*Message, error) {
+	mr, err := mail.CreateReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	defer mr.Close()
+
+	msg := &Message{}
+	msg.MessageID, _ = mr.Header.MessageID()
+	msg.Subject, _ = mr.Header.Subject()
+	msg.Date, _ = mr.Header.Date()
+	if from, err := mr.Header.AddressList("From"); err == nil && len(from) > 0 {
+		msg.From = strings.ToLower(from[0].Address)
+	}
+	for _, key := range []string{"To", "Cc"} {
+		list, _ := mr.Header.AddressList(key)
+		for _, addr := range list {
+			msg.To = append(msg.To, strings.ToLower(addr.Address))
+		}
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message part: %w", err)
+		}
+
+		// Photos are usually attachments, but some mail apps send them inline
+		var contentType, filename string
+		switch h := part.Header.(type) {
+		case *mail.AttachmentHeader:
+			contentType, _, _ = h.ContentType()
+			filename, _ = h.Filename()
+		case *mail.InlineHeader:
+			contentType, _, _ = h.ContentType()
+			if _, params, err := h.ContentDisposition(); err == nil {
+				filename = params["filename"]
+			}
+		}
+		if !isPhoto(contentType, filename) {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part.Body, maxAttachmentSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment %s: %w", filename, err)
+		}
+		if len(data) > maxAttachmentSize {
+			continue
+		}
+		msg.Photos = append(msg.Photos, Attachment{Filename: filename, ContentType: contentType, Data: data})
+	}
+	return msg, nil
+}
+
+// isPhoto reports whether a part is an image in a supported format
+func isPhoto(contentType, filename string) bool {
+	if filename != "" && imageops.IsSupportedFile(filename) {
+		return true
+	}
+	switch strings.ToLower(contentType) {
+	case "image/jpeg", "image/png", "image/heic", "image/heif":
+		return true
+	}
+	return false
+}
+
+// Extension returns the file extension to store an attachment with
+func (a Attachment) Extension() string {
+	if ext := strings.ToLower(filepath.Ext(a.Filename)); imageops.SupportedExtensions[ext] {
+		return ext
+	}
+	switch strings.ToLower(a.ContentType) {
+	case "image/png":
+		return ".png"
+	case "image/heic", "image/heif":
+		return ".heic"
+	}
+	return ".jpg"
+}
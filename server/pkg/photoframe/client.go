@@ -148,6 +148,15 @@ func (c *Client) checkReachability(ip string) error {
 	return nil
 }
 
+// Ping checks that the device accepts connections, without waking the display
+func (c *Client) Ping(host string) error {
+	ip, err := c.resolveHost(host)
+	if err != nil {
+		return err
+	}
+	return c.checkReachability(ip)
+}
+
 type SystemInfo struct {
 	DeviceName string `json:"device_name"`
 	Width      int    `json:"width"`
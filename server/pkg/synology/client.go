@@ -175,52 +175,121 @@ func (c *Client) ListAlbums(offset, limit int) ([]Album, error) {
 	return result.Data.List, nil
 }
 
-func (c *Client) ListPhotos(offset, limit int, albumID int, space string) ([]Item, error) {
-	endpoint := fmt.Sprintf("%s/webapi/entry.cgi", c.BaseURL)
-	params := url.Values{}
-	params.Set("api", "SYNO.Foto.Browse.Item")
-	params.Set("version", "1")
-	params.Set("method", "list")
-	params.Set("type", "photo")
-	params.Set("offset", fmt.Sprintf("%d", offset))
-	params.Set("limit", fmt.Sprintf("%d", limit))
-	params.Set("additional", `["thumbnail"]`)
+// ItemFilter selects which photos ListItems returns. Zero fields are ignored;
+// an empty filter lists all photos in the space.
+type ItemFilter struct {
+	AlbumID  int
+	PersonID int
+	TagID    int
+}
 
-	if albumID != 0 {
-		params.Set("album_id", fmt.Sprintf("%d", albumID))
+// browseAPI returns the API name for the given space. The shared space is
+// served by the SYNO.FotoTeam.* variant of each SYNO.Foto.* API.
+func browseAPI(api, space string) string {
+	if space == "shared" {
+		return strings.Replace(api, "SYNO.Foto.", "SYNO.FotoTeam.", 1)
 	}
+	return api
+}
+
+// get calls an entry.cgi API and decodes the response into result
+func (c *Client) get(params url.Values, result interface{}) error {
+	endpoint := fmt.Sprintf("%s/webapi/entry.cgi", c.BaseURL)
 	if c.SynoToken != "" {
 		params.Set("SynoToken", c.SynoToken)
 	}
 
-	// Note: Space selection (personal/shared) is determined by the session login
-	// The passphrase parameter is not used for space selection in Synology Photos API
-	// Keeping the space parameter for future use but not sending it to API
-
 	req, err := http.NewRequest("GET", endpoint+"?"+params.Encode(), nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("api returned status: %d", resp.StatusCode)
+		return fmt.Errorf("api returned status: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (c *Client) ListPhotos(offset, limit int, albumID int, space string) ([]Item, error) {
+	return c.ListItems(offset, limit, space, ItemFilter{AlbumID: albumID})
+}
+
+// ListItems lists photos of the personal or shared space, optionally filtered
+// by album, person (face recognition) or general tag
+func (c *Client) ListItems(offset, limit int, space string, filter ItemFilter) ([]Item, error) {
+	params := url.Values{}
+	params.Set("api", browseAPI("SYNO.Foto.Browse.Item", space))
+	params.Set("version", "1")
+	params.Set("method", "list")
+	params.Set("type", "photo")
+	params.Set("offset", fmt.Sprintf("%d", offset))
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	params.Set("additional", `["thumbnail"]`)
+
+	if filter.AlbumID != 0 {
+		params.Set("album_id", fmt.Sprintf("%d", filter.AlbumID))
+	}
+	if filter.PersonID != 0 {
+		params.Set("person_id", fmt.Sprintf("%d", filter.PersonID))
+	}
+	if filter.TagID != 0 {
+		params.Set("general_tag_id", fmt.Sprintf("%d", filter.TagID))
 	}
 
 	var result BrowseItemResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.get(params, &result); err != nil {
 		return nil, err
 	}
+	if !result.Success {
+		return nil, fmt.Errorf("api call failed with code: %d", result.Error.Code)
+	}
+	return result.Data.List, nil
+}
+
+// ListPeople lists the people recognized by face grouping
+func (c *Client) ListPeople(offset, limit int, space string) ([]Person, error) {
+	params := url.Values{}
+	params.Set("api", browseAPI("SYNO.Foto.Browse.Person", space))
+	params.Set("version", "1")
+	params.Set("method", "list")
+	params.Set("offset", fmt.Sprintf("%d", offset))
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	params.Set("show_more", "true")
+	params.Set("show_hidden", "false")
 
+	var result BrowsePersonResponse
+	if err := c.get(params, &result); err != nil {
+		return nil, err
+	}
 	if !result.Success {
 		return nil, fmt.Errorf("api call failed with code: %d", result.Error.Code)
 	}
+	return result.Data.List, nil
+}
+
+// ListTags lists the general (user) tags
+func (c *Client) ListTags(offset, limit int, space string) ([]Tag, error) {
+	params := url.Values{}
+	params.Set("api", browseAPI("SYNO.Foto.Browse.GeneralTag", space))
+	params.Set("version", "1")
+	params.Set("method", "list")
+	params.Set("offset", fmt.Sprintf("%d", offset))
+	params.Set("limit", fmt.Sprintf("%d", limit))
 
+	var result BrowseTagResponse
+	if err := c.get(params, &result); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("api call failed with code: %d", result.Error.Code)
+	}
 	return result.Data.List, nil
 }
 
@@ -0,0 +1,120 @@
+package synology
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer mimics the subset of the Synology Photos API used by the
+// client. Photos are listed per space, album, person and tag.
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webapi/auth.cgi", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("account") != "me" || q.Get("passwd") != "secret" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": map[string]int{"code": 400}})
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "did", Value: "device"})
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": map[string]string{"sid": "sid1", "synotoken": "token1"}})
+	})
+	mux.HandleFunc("/webapi/entry.cgi", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("SynoToken") != "token1" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": map[string]int{"code": 119}})
+			return
+		}
+
+		var list interface{}
+		switch q.Get("api") {
+		case "SYNO.Foto.Browse.Item":
+			list = []Item{{ID: 1, Filename: "personal.jpg"}}
+		case "SYNO.FotoTeam.Browse.Item":
+			switch {
+			case q.Get("person_id") == "7":
+				list = []Item{{ID: 10, Filename: "grandma.jpg"}, {ID: 11, Filename: "family.jpg"}}
+			case q.Get("general_tag_id") == "3":
+				list = []Item{{ID: 11, Filename: "family.jpg"}, {ID: 12, Filename: "beach.jpg"}}
+			case q.Get("album_id") == "5":
+				list = []Item{{ID: 13, Filename: "album.jpg"}}
+			default:
+				list = []Item{{ID: 10}, {ID: 11}, {ID: 12}, {ID: 13}}
+			}
+		case "SYNO.FotoTeam.Browse.Person":
+			list = []Person{{ID: 7, Name: "Grandma", ItemCount: 2}, {ID: 8, ItemCount: 1}}
+		case "SYNO.Foto.Browse.Person":
+			list = []Person{{ID: 2, Name: "Me", ItemCount: 1}}
+		case "SYNO.FotoTeam.Browse.GeneralTag":
+			list = []Tag{{ID: 3, Name: "beach", ItemCount: 2}}
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": map[string]int{"code": 102}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": map[string]interface{}{"list": list}})
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T) *Client {
+	client, err := NewClient(newTestServer(t).URL+"/", "me", "secret", false)
+	require.NoError(t, err)
+	require.NoError(t, client.Login(""))
+	return client
+}
+
+func TestLogin(t *testing.T) {
+	client := newTestClient(t)
+	assert.Equal(t, "sid1", client.SID)
+	assert.Equal(t, "device", client.DID)
+	assert.Equal(t, "token1", client.SynoToken)
+
+	client.Password = "wrong"
+	assert.EqualError(t, client.Login(""), "login failed with code: 400")
+}
+
+func TestListItems(t *testing.T) {
+	client := newTestClient(t)
+	ids := func(items []Item, err error) []int {
+		require.NoError(t, err)
+		var ids []int
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	// The shared space is served by the SYNO.FotoTeam APIs
+	assert.Equal(t, []int{1}, ids(client.ListItems(0, 100, "personal", ItemFilter{})))
+	assert.Equal(t, []int{10, 11, 12, 13}, ids(client.ListItems(0, 100, "shared", ItemFilter{})))
+
+	assert.Equal(t, []int{10, 11}, ids(client.ListItems(0, 100, "shared", ItemFilter{PersonID: 7})))
+	assert.Equal(t, []int{11, 12}, ids(client.ListItems(0, 100, "shared", ItemFilter{TagID: 3})))
+	assert.Equal(t, []int{13}, ids(client.ListPhotos(0, 100, 5, "shared")))
+}
+
+func TestListPeopleAndTags(t *testing.T) {
+	client := newTestClient(t)
+
+	people, err := client.ListPeople(0, 100, "shared")
+	require.NoError(t, err)
+	assert.Equal(t, []Person{{ID: 7, Name: "Grandma", ItemCount: 2}, {ID: 8, ItemCount: 1}}, people)
+	people, err = client.ListPeople(0, 100, "personal")
+	require.NoError(t, err)
+	assert.Equal(t, []Person{{ID: 2, Name: "Me", ItemCount: 1}}, people)
+
+	tags, err := client.ListTags(0, 100, "shared")
+	require.NoError(t, err)
+	assert.Equal(t, []Tag{{ID: 3, Name: "beach", ItemCount: 2}}, tags)
+
+	// Tags of the personal space are not served by the fake
+	_, err = client.ListTags(0, 100, "personal")
+	assert.EqualError(t, err, "api call failed with code: 102")
+}
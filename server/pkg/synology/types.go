@@ -43,3 +43,35 @@ type Album struct {
 	Name string `json:"name"`
 	Type string `json:"type"` // "folder" or "album"
 }
+
+type BrowsePersonResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		List []Person `json:"list"`
+	} `json:"data"`
+	Error struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+type Person struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"` // Empty for unnamed face groups
+	ItemCount int    `json:"item_count"`
+}
+
+type BrowseTagResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		List []Tag `json:"list"`
+	} `json:"data"`
+	Error struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+type Tag struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	ItemCount int    `json:"item_count"`
+}
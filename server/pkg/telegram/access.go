@@ -0,0 +1,182 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings used for access control and routing
+const (
+	// Comma separated Telegram user and chat IDs allowed to use the bot
+	SettingAllowedIDs = "telegram_allowed_ids"
+	// JSON object mapping a chat ID to the device its photos are pushed to
+	SettingChatDevices = "telegram_chat_devices"
+	// Pending pairing code (JSON Pairing), generated in the web UI
+	SettingPairing = "telegram_pairing"
+)
+
+const (
+	pairingTTL         = 10 * time.Minute
+	maxPairingAttempts = 5
+)
+
+// Pairing is a one-time code that authorizes the chat it is sent from
+type Pairing struct {
+	Code      string    `json:"code"`
+	DeviceID  uint      `json:"device_id,omitempty"` // Optional route for the paired chat
+	ExpiresAt time.Time `json:"expires_at"`
+	Attempts  int       `json:"attempts,omitempty"` // Failed attempts, the code is dropped after maxPairingAttempts
+}
+
+// AllowedIDs returns the user and chat IDs allowed to use the bot
+func AllowedIDs(s SettingsProvider) []int64 {
+	raw, _ := s.Get(SettingAllowedIDs)
+
+	var ids []int64
+	for _, part := range strings.Split(raw, ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func setAllowedIDs(s SettingsProvider, ids []int64) error {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return s.Set(SettingAllowedIDs, strings.Join(parts, ","))
+}
+
+// IsAllowed reports whether any of the given user/chat IDs is on the allow-list
+func IsAllowed(s SettingsProvider, ids ...int64) bool {
+	for _, allowed := range AllowedIDs(s) {
+		for _, id := range ids {
+			if id != 0 && id == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Allow adds an ID to the allow-list
+func Allow(s SettingsProvider, id int64) error {
+	ids := AllowedIDs(s)
+	for _, existing := range ids {
+		if existing == id {
+			return nil
+		}
+	}
+	return setAllowedIDs(s, append(ids, id))
+}
+
+// Revoke removes an ID from the allow-list, together with its device route
+func Revoke(s SettingsProvider, id int64) error {
+	ids := AllowedIDs(s)
+	kept := ids[:0]
+	for _, existing := range ids {
+		if existing != id {
+			kept = append(kept, existing)
+		}
+	}
+	if err := setAllowedIDs(s, kept); err != nil {
+		return err
+	}
+	return SetChatDevice(s, id, 0)
+}
+
+// ChatDevices returns the device each chat's photos are pushed to
+func ChatDevices(s SettingsProvider) map[int64]uint {
+	raw, _ := s.Get(SettingChatDevices)
+
+	routes := make(map[string]uint)
+	if raw != "" {
+		json.Unmarshal([]byte(raw), &routes)
+	}
+
+	result := make(map[int64]uint, len(routes))
+	for chat, device := range routes {
+		if id, err := strconv.ParseInt(chat, 10, 64); err == nil && device != 0 {
+			result[id] = device
+		}
+	}
+	return result
+}
+
+// SetChatDevice routes a chat to a device. Device 0 removes the route.
+func SetChatDevice(s SettingsProvider, chatID int64, deviceID uint) error {
+	routes := make(map[string]uint)
+	for chat, device := range ChatDevices(s) {
+		routes[strconv.FormatInt(chat, 10)] = device
+	}
+
+	key := strconv.FormatInt(chatID, 10)
+	if deviceID == 0 {
+		delete(routes, key)
+	} else {
+		routes[key] = deviceID
+	}
+
+	data, err := json.Marshal(routes)
+	if err != nil {
+		return err
+	}
+	return s.Set(SettingChatDevices, string(data))
+}
+
+// NewPairing creates a new pairing code, replacing any pending one
+func NewPairing(s SettingsProvider, deviceID uint) (*Pairing, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pairing{
+		Code:      fmt.Sprintf("%06d", n.Int64()),
+		DeviceID:  deviceID,
+		ExpiresAt: time.Now().Add(pairingTTL),
+	}
+	data, _ := json.Marshal(p)
+	if err := s.Set(SettingPairing, string(data)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// redeemPairing checks a code sent to the bot. A valid code can be used once.
+func redeemPairing(s SettingsProvider, code string) (*Pairing, error) {
+	raw, _ := s.Get(SettingPairing)
+	if raw == "" {
+		return nil, errors.New("no pairing code pending")
+	}
+
+	var p Pairing
+	if err := json.Unmarshal([]byte(raw), &p); err != nil || time.Now().After(p.ExpiresAt) {
+		s.Set(SettingPairing, "")
+		return nil, errors.New("pairing code expired")
+	}
+
+	if strings.TrimSpace(code) != p.Code {
+		p.Attempts++
+		if p.Attempts >= maxPairingAttempts {
+			s.Set(SettingPairing, "")
+			return nil, errors.New("too many wrong codes, generate a new one")
+		}
+		data, _ := json.Marshal(p)
+		s.Set(SettingPairing, string(data))
+		return nil, errors.New("wrong pairing code")
+	}
+
+	s.Set(SettingPairing, "")
+	return &p, nil
+}
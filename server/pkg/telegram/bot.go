@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/aitjcize/photoframe-server/server/internal/model"
@@ -20,26 +21,80 @@ import (
 
 type SettingsProvider interface {
 	Get(key string) (string, error)
+	Set(key string, value string) error
 }
 
 type Pusher interface {
 	PushToHost(device *model.Device, imagePath string, extraOpts map[string]string) error
 }
 
+// Devices gives bot commands access to the configured frames.
+// It is implemented by service.DeviceService.
+type Devices interface {
+	Pusher
+	ListDevices() ([]model.Device, error)
+	SetCollage(id uint, enabled bool) (*model.Device, error)
+	IsOnline(device *model.Device) bool
+}
+
+// DuplicateChecker hashes incoming photos and finds near-duplicates already in
+// the gallery. It is implemented by service.DuplicateService.
+type DuplicateChecker interface {
+	Check(path string) (hash string, duplicate *model.Image)
+}
+
+// PhotoPicker picks a random photo matching a query, honoring favorites and
+// weights. It is implemented by service.TelegramService.
+type PhotoPicker interface {
+	PickImage(query *gorm.DB) (*model.Image, error)
+}
+
+// Trasher moves deleted photos to the restorable trash. It is implemented by
+// service.TrashService.
+type Trasher interface {
+	Trash(items []model.Image) error
+}
+
+// ImportNotifier is told about imported photos, for live UI updates. It is
+// implemented by service.EventBus.
+type ImportNotifier interface {
+	PhotoImported(img *model.Image)
+}
+
 type Bot struct {
 	b        *tele.Bot
 	db       *gorm.DB
 	dataDir  string
 	settings SettingsProvider
-	pusher   Pusher
+	devices  Devices
+	dups     DuplicateChecker // nil to skip hashing
+	events   ImportNotifier   // nil to skip notifications
+	picker   PhotoPicker
+	trash    Trasher
+	albums   mediaGroups
+	webhook  *tele.Webhook // nil when long polling
 }
 
-func NewBot(token string, db *gorm.DB, dataDir string, settings SettingsProvider, pusher Pusher) (*Bot, error) {
+// NewBot creates the bot. It receives updates through a webhook when
+// telegram_webhook_url is set, and by long polling otherwise.
+func NewBot(token string, db *gorm.DB, dataDir string, settings SettingsProvider, devices Devices, dups DuplicateChecker, events ImportNotifier, picker PhotoPicker, trash Trasher) (*Bot, error) {
 	pref := tele.Settings{
 		Token:  token,
 		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
 	}
 
+	webhook, err := newWebhook(settings)
+	if err != nil {
+		return nil, err
+	}
+	if webhook != nil {
+		pref.Poller = webhook
+	}
+
+	return newBot(pref, db, dataDir, settings, devices, dups, events, picker, trash)
+}
+
+func newBot(pref tele.Settings, db *gorm.DB, dataDir string, settings SettingsProvider, devices Devices, dups DuplicateChecker, events ImportNotifier, picker PhotoPicker, trash Trasher) (*Bot, error) {
 	b, err := tele.NewBot(pref)
 	if err != nil {
 		return nil, err
@@ -50,7 +105,15 @@ func NewBot(token string, db *gorm.DB, dataDir string, settings SettingsProvider
 		db:       db,
 		dataDir:  dataDir,
 		settings: settings,
-		pusher:   pusher,
+		devices:  devices,
+		dups:     dups,
+		events:   events,
+		picker:   picker,
+		trash:    trash,
+		albums:   mediaGroups{groups: make(map[string]*mediaGroup)},
+	}
+	if webhook, ok := pref.Poller.(*webhookPoller); ok {
+		bot.webhook = webhook.hook
 	}
 	bot.registerHandlers()
 
@@ -58,7 +121,18 @@ func NewBot(token string, db *gorm.DB, dataDir string, settings SettingsProvider
 }
 
 func (bot *Bot) Start() {
-	log.Println("Telegram bot started")
+	if bot.webhook != nil {
+		log.Printf("Telegram bot started (webhook %s)", bot.webhook.Endpoint.PublicURL)
+	} else {
+		// Telegram refuses getUpdates while a webhook is set
+		if err := bot.b.RemoveWebhook(); err != nil {
+			log.Printf("Failed to remove Telegram webhook: %v", err)
+		}
+		log.Println("Telegram bot started (long polling)")
+	}
+	if err := bot.b.SetCommands(botCommands); err != nil {
+		log.Printf("Failed to register Telegram bot commands: %v", err)
+	}
 	go bot.b.Start()
 }
 
@@ -68,97 +142,77 @@ func (bot *Bot) Stop() {
 
 func (bot *Bot) registerHandlers() {
 	bot.b.Handle("/start", func(c tele.Context) error {
-		return c.Send("Hello! Send me a photo to display on your frame.")
+		return c.Send("Hello! Send me a photo to display on your frame.\n\n" + commandHelp())
 	})
 
-	bot.b.Handle(tele.OnPhoto, bot.handlePhoto)
-}
-
-func (bot *Bot) handlePhoto(c tele.Context) error {
-	// Download photo
-	photo := c.Message().Photo
+	bot.b.Handle("/pair", bot.handlePair)
 
-	// Get the Telegram update ID for deduplication
-	telegramUpdateID := int64(c.Update().ID)
+	// Everything else is only available to allowed users and chats
+	g := bot.b.Group()
+	g.Use(bot.authorize)
+	g.Handle(tele.OnPhoto, bot.handleMedia)
+	g.Handle(tele.OnDocument, bot.handleMedia)
+	bot.registerCommands(g)
+}
 
-	// Create directory if not exists
-	photosDir := filepath.Join(bot.dataDir, "photos")
-	if err := os.MkdirAll(photosDir, 0755); err != nil {
-		return c.Send("Failed to create photos directory.")
+// routedDevice returns the device photos from a chat are pushed to. Chats
+// without a route use the global telegram_target_device_id, if set.
+func (bot *Bot) routedDevice(chatID int64) uint {
+	if deviceID, ok := ChatDevices(bot.settings)[chatID]; ok {
+		return deviceID
+	}
+	legacy, _ := bot.settings.Get("telegram_target_device_id")
+	if id, err := strconv.ParseUint(legacy, 10, 64); err == nil {
+		return uint(id)
 	}
+	return 0
+}
 
-	// Target file path
-	destPath := filepath.Join(photosDir, "telegram_last.jpg")
+// authorize only lets updates from allow-listed users and chats through
+func (bot *Bot) authorize(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		var userID, chatID int64
+		if c.Sender() != nil {
+			userID = c.Sender().ID
+		}
+		if c.Chat() != nil {
+			chatID = c.Chat().ID
+		}
+		if IsAllowed(bot.settings, userID, chatID) {
+			return next(c)
+		}
 
-	// Download
-	if err := bot.b.Download(&photo.File, destPath); err != nil {
-		return c.Send("Failed to download photo: " + err.Error())
+		log.Printf("Telegram: rejected update from user %d in chat %d", userID, chatID)
+		if c.Callback() != nil {
+			return c.Respond(&tele.CallbackResponse{Text: "Not authorized"})
+		}
+		return c.Send(fmt.Sprintf("This chat is not authorized (chat ID %d). Generate a pairing code in the PhotoFrame web UI and send /pair <code>.", chatID))
 	}
+}
 
-	// Also save as unique file for collage support
-	timestamp := time.Now().UnixNano()
-	uniquePath := filepath.Join(photosDir, fmt.Sprintf("telegram_%d.jpg", timestamp))
-	if err := copyFile(destPath, uniquePath); err != nil {
-		log.Printf("Failed to create unique copy for collage: %v", err)
+func (bot *Bot) handlePair(c tele.Context) error {
+	chatID := c.Chat().ID
+	if IsAllowed(bot.settings, chatID) {
+		return c.Send("This chat is already paired.")
 	}
 
-	// Create DB entry for smart collage support
-	imageEntry := model.Image{
-		FilePath:         uniquePath,
-		Source:           "telegram",
-		Orientation:      getImageOrientation(uniquePath),
-		CreatedAt:        time.Now(),
-		TelegramUpdateID: telegramUpdateID,
-	}
-	if err := bot.db.Create(&imageEntry).Error; err != nil {
-		log.Printf("Failed to create DB entry for Telegram photo: %v", err)
+	pairing, err := redeemPairing(bot.settings, c.Message().Payload)
+	if err != nil {
+		log.Printf("Telegram: failed pairing attempt from chat %d: %v", chatID, err)
+		return c.Send("Pairing failed: " + err.Error())
 	}
 
-	// Update Caption Setting
-	caption := c.Message().Caption
-	var setting model.Setting
-	setting.Key = "telegram_caption"
-	setting.Value = caption
-	bot.db.Save(&setting)
-
-	// Check if Push to Device is enabled
-	pushEnabled, _ := bot.settings.Get("telegram_push_enabled")
-	targetDeviceIDStr, _ := bot.settings.Get("telegram_target_device_id")
-
-	if pushEnabled == "true" && targetDeviceIDStr != "" {
-		// Send initial status
-		statusMsg, err := bot.b.Send(c.Recipient(), "Connecting to device...")
-		if err != nil {
-			log.Printf("Failed to send status message: %v", err)
-			return err
-		}
-
-		// Look up device
-		var device model.Device
-		if err := bot.db.First(&device, targetDeviceIDStr).Error; err != nil {
-			log.Printf("Failed to find target device (ID: %s): %v", targetDeviceIDStr, err)
-			bot.b.Edit(statusMsg, "Error: Configured target device not found.")
-			return nil
-		}
-
-		err = bot.pusher.PushToHost(&device, destPath, nil)
-		if err != nil {
-			log.Printf("Failed to push to device: %v", err)
-			_, editErr := bot.b.Edit(statusMsg, "Photo updated! Device is offline/unreachable, so it will show up next time the device awakes.")
-			if editErr != nil {
-				return c.Send("Photo updated! Device is offline/unreachable, so it will show up next time the device awakes.")
-			}
-			return nil
-		}
-
-		_, editErr := bot.b.Edit(statusMsg, "Photo updated and displayed on device!")
-		if editErr != nil {
-			return c.Send("Photo updated and displayed on device!")
+	if err := Allow(bot.settings, chatID); err != nil {
+		return c.Send("Pairing failed: " + err.Error())
+	}
+	if pairing.DeviceID != 0 {
+		if err := SetChatDevice(bot.settings, chatID, pairing.DeviceID); err != nil {
+			log.Printf("Telegram: failed to route chat %d: %v", chatID, err)
 		}
-		return nil
 	}
 
-	return c.Send("Photo updated! It will show up next time the device awakes.")
+	log.Printf("Telegram: paired chat %d", chatID)
+	return c.Send("Paired! Send me a photo to display it on your frame.\n\n" + commandHelp())
 }
 
 // copyFile copies a file from src to dst
@@ -179,25 +233,6 @@ func copyFile(src, dst string) error {
 	return err
 }
 
-// getImageOrientation determines if an image is portrait or landscape
-func getImageOrientation(path string) string {
-	f, err := os.Open(path)
-	if err != nil {
-		return "landscape"
-	}
-	defer f.Close()
-
-	img, _, err := image.DecodeConfig(f)
-	if err != nil {
-		return "landscape"
-	}
-
-	if img.Height > img.Width {
-		return "portrait"
-	}
-	return "landscape"
-}
-
 // createVerticalCollage creates a vertical collage (portrait: 480x800)
 func createVerticalCollage(img1, img2 image.Image) image.Image {
 	width := 480
@@ -308,7 +343,7 @@ func loadImageForCollage(path string) (image.Image, error) {
 		return nil, err
 	}
 	defer f.Close()
-	img, _, err := image.Decode(f)
+	img, _, err := imageops.Decode(f)
 	return img, err
 }
 
@@ -0,0 +1,365 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	tele "gopkg.in/telebot.v3"
+)
+
+// Unique IDs of the inline keyboard buttons
+const (
+	btnPush    = "push"
+	btnNext    = "next"
+	btnCollage = "collage"
+	btnDelete  = "delete"
+	btnCancel  = "cancel"
+)
+
+var botCommands = []tele.Command{
+	{Text: "devices", Description: "List frames and their status"},
+	{Text: "push", Description: "Push the latest photo to a frame"},
+	{Text: "next", Description: "Push a random photo to a frame"},
+	{Text: "collage", Description: "Turn the collage on or off: /collage on|off"},
+	{Text: "delete", Description: "Move the last photo to the trash"},
+	{Text: "caption", Description: "Set the caption of the last photo"},
+}
+
+// fileSources are the sources whose photos are files on this server and can be pushed directly
+var fileSources = []string{"telegram", "upload", "local", "google", "webdav", "email"}
+
+func commandHelp() string {
+	lines := make([]string, 0, len(botCommands))
+	for _, cmd := range botCommands {
+		lines = append(lines, fmt.Sprintf("/%s - %s", cmd.Text, cmd.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (bot *Bot) registerCommands(g *tele.Group) {
+	g.Handle("/help", func(c tele.Context) error {
+		return c.Send(commandHelp())
+	})
+	g.Handle("/devices", bot.handleDevices)
+	g.Handle("/push", bot.handlePush)
+	g.Handle("/next", bot.handleNext)
+	g.Handle("/collage", bot.handleCollage)
+	g.Handle("/delete", bot.handleDelete)
+	g.Handle("/caption", bot.handleCaption)
+
+	g.Handle(&tele.Btn{Unique: btnPush}, bot.onDeviceButton(bot.pushLatest))
+	g.Handle(&tele.Btn{Unique: btnNext}, bot.onDeviceButton(bot.pushRandom))
+	g.Handle(&tele.Btn{Unique: btnCollage}, bot.onCollageButton)
+	g.Handle(&tele.Btn{Unique: btnDelete}, bot.onDeleteButton)
+	g.Handle(&tele.Btn{Unique: btnCancel}, func(c tele.Context) error {
+		c.Respond()
+		return c.Edit("Cancelled.")
+	})
+}
+
+func deviceName(d *model.Device) string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Host
+}
+
+// matchDevice finds a device by ID, name or host (case insensitive)
+func matchDevice(devices []model.Device, arg string) *model.Device {
+	if arg == "" {
+		return nil
+	}
+	for i, d := range devices {
+		if strconv.FormatUint(uint64(d.ID), 10) == arg ||
+			strings.EqualFold(d.Name, arg) || strings.EqualFold(d.Host, arg) {
+			return &devices[i]
+		}
+	}
+	return nil
+}
+
+// withDevice runs action on the device named by arg. Without an argument the
+// chat's routed device (or the only configured device) is used directly,
+// otherwise an inline keyboard asks which device to use. The buttons are
+// created by button.
+func (bot *Bot) withDevice(c tele.Context, arg, prompt string, button func(markup *tele.ReplyMarkup, d model.Device) tele.Btn, action func(c tele.Context, d *model.Device) error) error {
+	devices, err := bot.devices.ListDevices()
+	if err != nil {
+		return c.Send("Failed to list devices: " + err.Error())
+	}
+	if len(devices) == 0 {
+		return c.Send("No devices configured. Add a frame in the web UI first.")
+	}
+
+	if d := matchDevice(devices, arg); d != nil {
+		return action(c, d)
+	}
+	if arg == "" {
+		routed := strconv.FormatUint(uint64(bot.routedDevice(c.Chat().ID)), 10)
+		if d := matchDevice(devices, routed); d != nil {
+			return action(c, d)
+		}
+	}
+	if arg == "" && len(devices) == 1 {
+		return action(c, &devices[0])
+	}
+	if arg != "" {
+		prompt = fmt.Sprintf("Unknown device %q. %s", arg, prompt)
+	}
+
+	markup := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, len(devices))
+	for _, d := range devices {
+		rows = append(rows, markup.Row(button(markup, d)))
+	}
+	markup.Inline(rows...)
+	return c.Send(prompt, markup)
+}
+
+// findDevice looks up the device referenced by a button
+func (bot *Bot) findDevice(id string) (*model.Device, error) {
+	devices, err := bot.devices.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+	for i, d := range devices {
+		if strconv.FormatUint(uint64(d.ID), 10) == id {
+			return &devices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("device not found")
+}
+
+// onDeviceButton adapts a device action to an inline keyboard callback
+func (bot *Bot) onDeviceButton(action func(c tele.Context, d *model.Device) error) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		c.Respond()
+		device, err := bot.findDevice(c.Data())
+		if err != nil {
+			return c.Send("Error: " + err.Error())
+		}
+		return action(c, device)
+	}
+}
+
+func (bot *Bot) handleDevices(c tele.Context) error {
+	devices, err := bot.devices.ListDevices()
+	if err != nil {
+		return c.Send("Failed to list devices: " + err.Error())
+	}
+	if len(devices) == 0 {
+		return c.Send("No devices configured. Add a frame in the web UI first.")
+	}
+
+	// Reachability checks time out after a few seconds, run them in parallel
+	online := make([]bool, len(devices))
+	var wg sync.WaitGroup
+	for i := range devices {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			online[i] = bot.devices.IsOnline(&devices[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var sb strings.Builder
+	markup := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, len(devices))
+	for i, d := range devices {
+		status := "offline"
+		if online[i] {
+			status = "online"
+		}
+		collage := "off"
+		if d.EnableCollage {
+			collage = "on"
+		}
+		fmt.Fprintf(&sb, "%d. %s (%s) - %dx%d, collage %s, %s\n", d.ID, deviceName(&d), d.Host, d.Width, d.Height, collage, status)
+
+		id := strconv.FormatUint(uint64(d.ID), 10)
+		rows = append(rows, markup.Row(
+			markup.Data("Latest → "+deviceName(&d), btnPush, id),
+			markup.Data("Random → "+deviceName(&d), btnNext, id),
+		))
+	}
+	markup.Inline(rows...)
+	return c.Send(sb.String(), markup)
+}
+
+func (bot *Bot) handlePush(c tele.Context) error {
+	return bot.withDevice(c, c.Message().Payload, "Push the latest photo to which frame?",
+		func(m *tele.ReplyMarkup, d model.Device) tele.Btn {
+			return m.Data(deviceName(&d), btnPush, strconv.FormatUint(uint64(d.ID), 10))
+		}, bot.pushLatest)
+}
+
+func (bot *Bot) handleNext(c tele.Context) error {
+	return bot.withDevice(c, c.Message().Payload, "Push a random photo to which frame?",
+		func(m *tele.ReplyMarkup, d model.Device) tele.Btn {
+			return m.Data(deviceName(&d), btnNext, strconv.FormatUint(uint64(d.ID), 10))
+		}, bot.pushRandom)
+}
+
+// latestPhoto returns the newest photo received by the bot
+func (bot *Bot) latestPhoto() (*model.Image, error) {
+	var img model.Image
+	err := bot.db.Where("source = ? AND orientation <> ?", "telegram", "collage").
+		Order("telegram_update_id DESC, created_at DESC").
+		First(&img).Error
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (bot *Bot) pushLatest(c tele.Context, device *model.Device) error {
+	path := filepath.Join(bot.dataDir, "photos", "telegram_last.jpg")
+	if img, err := bot.latestPhoto(); err == nil {
+		path = img.FilePath
+	}
+	if _, err := os.Stat(path); err != nil {
+		return c.Send("No photo received yet. Send me one first.")
+	}
+	return bot.pushAndReport(c, device, path)
+}
+
+func (bot *Bot) pushRandom(c tele.Context, device *model.Device) error {
+	img, err := bot.picker.PickImage(bot.db.Where("source IN ? AND file_path <> '' AND orientation <> ?", fileSources, "collage"))
+	if err != nil {
+		return c.Send("No photos available to push.")
+	}
+	return bot.pushAndReport(c, device, img.FilePath)
+}
+
+// pushAndReport pushes a photo, keeping the user informed through a single status message
+func (bot *Bot) pushAndReport(c tele.Context, device *model.Device, path string) error {
+	name := deviceName(device)
+	statusMsg, err := bot.b.Send(c.Recipient(), fmt.Sprintf("Pushing to %s...", name))
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("Photo displayed on %s!", name)
+	if err := bot.devices.PushToHost(device, path, nil); err != nil {
+		log.Printf("Failed to push to device %s: %v", name, err)
+		text = fmt.Sprintf("%s is offline/unreachable: %v", name, err)
+	}
+
+	if _, err := bot.b.Edit(statusMsg, text); err != nil {
+		return c.Send(text)
+	}
+	return nil
+}
+
+func (bot *Bot) handleCollage(c tele.Context) error {
+	args := c.Args()
+	if len(args) == 0 || (args[0] != "on" && args[0] != "off") {
+		return c.Send("Usage: /collage on|off [device]")
+	}
+	enabled := args[0] == "on"
+	arg := strings.Join(args[1:], " ")
+
+	return bot.withDevice(c, arg, fmt.Sprintf("Turn the collage %s for which frame?", args[0]),
+		func(m *tele.ReplyMarkup, d model.Device) tele.Btn {
+			return m.Data(deviceName(&d), btnCollage, strconv.FormatUint(uint64(d.ID), 10), args[0])
+		},
+		func(c tele.Context, d *model.Device) error {
+			return bot.setCollage(c, d, enabled)
+		})
+}
+
+func (bot *Bot) onCollageButton(c tele.Context) error {
+	c.Respond()
+	args := c.Args() // device ID, on|off
+	if len(args) != 2 {
+		return nil
+	}
+	device, err := bot.findDevice(args[0])
+	if err != nil {
+		return c.Send("Error: " + err.Error())
+	}
+	return bot.setCollage(c, device, args[1] == "on")
+}
+
+func (bot *Bot) setCollage(c tele.Context, device *model.Device, enabled bool) error {
+	if _, err := bot.devices.SetCollage(device.ID, enabled); err != nil {
+		return c.Send("Failed to update device: " + err.Error())
+	}
+	state := "off"
+	if enabled {
+		state = "on"
+	}
+	return c.Send(fmt.Sprintf("Collage turned %s for %s.", state, deviceName(device)))
+}
+
+func (bot *Bot) handleDelete(c tele.Context) error {
+	img, err := bot.latestPhoto()
+	if err != nil {
+		return c.Send("There is no photo to delete.")
+	}
+
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("Delete", btnDelete, strconv.FormatUint(uint64(img.ID), 10)),
+		markup.Data("Cancel", btnCancel),
+	))
+
+	text := "Delete the last photo?"
+	if img.Caption != "" {
+		text = fmt.Sprintf("Delete the last photo (%q)?", img.Caption)
+	}
+	return c.Send(text, markup)
+}
+
+func (bot *Bot) onDeleteButton(c tele.Context) error {
+	c.Respond()
+
+	var img model.Image
+	if err := bot.db.Where("source = ?", "telegram").First(&img, c.Data()).Error; err != nil {
+		return c.Edit("That photo was already deleted.")
+	}
+
+	if err := bot.trash.Trash([]model.Image{img}); err != nil {
+		return c.Edit("Failed to delete photo: " + err.Error())
+	}
+
+	// telegram_last.jpg is served when the DB has no photos, keep it in line
+	lastPath := filepath.Join(bot.dataDir, "photos", "telegram_last.jpg")
+	if next, err := bot.latestPhoto(); err == nil {
+		if err := copyFile(next.FilePath, lastPath); err != nil {
+			log.Printf("Failed to restore telegram_last.jpg: %v", err)
+		}
+	} else {
+		os.Remove(lastPath)
+	}
+
+	return c.Edit("Photo moved to the trash. It can be restored from the web UI.")
+}
+
+func (bot *Bot) handleCaption(c tele.Context) error {
+	img, err := bot.latestPhoto()
+	if err != nil {
+		return c.Send("There is no photo to caption.")
+	}
+
+	caption := strings.TrimSpace(c.Message().Payload)
+	if caption == "" {
+		if img.Caption == "" {
+			return c.Send("The last photo has no caption. Usage: /caption <text>")
+		}
+		return c.Send(fmt.Sprintf("Current caption: %s", img.Caption))
+	}
+
+	if err := bot.db.Model(img).Update("caption", caption).Error; err != nil {
+		return c.Send("Failed to update caption: " + err.Error())
+	}
+	bot.db.Save(&model.Setting{Key: "telegram_caption", Value: caption})
+	return c.Send("Caption updated.")
+}
@@ -0,0 +1,331 @@
+package telegram
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/aitjcize/photoframe-server/server/pkg/imageops"
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	// Telegram delivers each photo of an album as a separate update. Photos of
+	// a media group are collected until none arrived for this long.
+	mediaGroupDelay = 2 * time.Second
+
+	// Bot API limit for file downloads
+	maxDownloadSize = 20 * 1024 * 1024
+
+	// Photos of an album beyond this are imported but left out of the collage
+	maxCollagePhotos = 4
+)
+
+// incomingPhoto is a photo or image document waiting to be imported
+type incomingPhoto struct {
+	file     tele.File
+	ext      string
+	name     string
+	caption  string
+	updateID int64
+	err      error // Set for files of an album that are not images
+}
+
+// mediaGroup collects the photos of an album until it is complete
+type mediaGroup struct {
+	chat   *tele.Chat
+	photos []incomingPhoto
+	timer  *time.Timer
+}
+
+// mediaGroups holds the albums that are still arriving, by media group ID
+type mediaGroups struct {
+	mu     sync.Mutex
+	groups map[string]*mediaGroup
+}
+
+// incomingFromMessage extracts the photo of a message. Documents are only
+// accepted when they are a supported image format.
+func incomingFromMessage(msg *tele.Message, updateID int64) (*incomingPhoto, error) {
+	if msg.Photo != nil {
+		return &incomingPhoto{file: msg.Photo.File, ext: ".jpg", caption: msg.Caption, updateID: updateID}, nil
+	}
+
+	doc := msg.Document
+	if doc == nil {
+		return nil, fmt.Errorf("no photo in message")
+	}
+	ext := strings.ToLower(filepath.Ext(doc.FileName))
+	if !imageops.SupportedExtensions[ext] {
+		switch doc.MIME {
+		case "image/jpeg":
+			ext = ".jpg"
+		case "image/png":
+			ext = ".png"
+		case "image/heic", "image/heif":
+			ext = ".heic"
+		default:
+			return nil, fmt.Errorf("%s is not a supported image (JPEG, PNG or HEIC)", documentName(doc))
+		}
+	}
+	if doc.FileSize > maxDownloadSize {
+		return nil, fmt.Errorf("%s is larger than 20 MB", documentName(doc))
+	}
+	return &incomingPhoto{file: doc.File, ext: ext, name: doc.FileName, caption: msg.Caption, updateID: updateID}, nil
+}
+
+func documentName(doc *tele.Document) string {
+	if doc.FileName != "" {
+		return doc.FileName
+	}
+	return "The file"
+}
+
+// handleMedia handles photos and image files. Single photos are imported right
+// away, albums once all of their photos have arrived.
+func (bot *Bot) handleMedia(c tele.Context) error {
+	msg := c.Message()
+	photo, err := incomingFromMessage(msg, int64(c.Update().ID))
+	if err != nil {
+		if msg.AlbumID == "" {
+			return c.Send(err.Error())
+		}
+		// Other files in the album may still be photos, report it with the summary
+		photo = &incomingPhoto{err: err}
+		if msg.Document != nil {
+			photo.name = msg.Document.FileName
+		}
+	}
+
+	if msg.AlbumID == "" {
+		bot.importPhotos(c.Chat(), []incomingPhoto{*photo})
+		return nil
+	}
+
+	bot.albums.mu.Lock()
+	defer bot.albums.mu.Unlock()
+
+	group, ok := bot.albums.groups[msg.AlbumID]
+	if !ok {
+		group = &mediaGroup{chat: c.Chat()}
+		bot.albums.groups[msg.AlbumID] = group
+		albumID := msg.AlbumID
+		group.timer = time.AfterFunc(mediaGroupDelay, func() {
+			bot.albums.mu.Lock()
+			delete(bot.albums.groups, albumID)
+			bot.albums.mu.Unlock()
+			bot.importPhotos(group.chat, group.photos)
+		})
+	} else {
+		group.timer.Reset(mediaGroupDelay)
+	}
+	group.photos = append(group.photos, *photo)
+	return nil
+}
+
+// importPhotos downloads the photos, adds them to the gallery and pushes the
+// result to the chat's device once, then replies with a summary.
+func (bot *Bot) importPhotos(chat *tele.Chat, photos []incomingPhoto) {
+	photosDir := filepath.Join(bot.dataDir, "photos")
+	if err := os.MkdirAll(photosDir, 0755); err != nil {
+		bot.b.Send(chat, "Failed to create photos directory.")
+		return
+	}
+
+	var imported []model.Image
+	var failed []string
+	caption := ""
+	for _, p := range photos {
+		var img *model.Image
+		err := p.err
+		if err == nil {
+			img, err = bot.importPhoto(photosDir, p)
+		}
+		if err != nil {
+			log.Printf("Telegram: failed to import photo: %v", err)
+			name := p.name
+			if name == "" {
+				name = "photo"
+			}
+			failed = append(failed, name)
+			continue
+		}
+		imported = append(imported, *img)
+		if caption == "" {
+			caption = p.caption // Albums carry the caption on one of the photos
+		}
+	}
+
+	if len(imported) == 0 {
+		bot.b.Send(chat, "Failed to download photo.")
+		return
+	}
+
+	bot.db.Save(&model.Setting{Key: "telegram_caption", Value: caption})
+
+	var device *model.Device
+	pushEnabled, _ := bot.settings.Get("telegram_push_enabled")
+	if deviceID := bot.routedDevice(chat.ID); pushEnabled == "true" && deviceID != 0 {
+		var d model.Device
+		if err := bot.db.First(&d, deviceID).Error; err != nil {
+			log.Printf("Failed to find target device (ID: %d): %v", deviceID, err)
+			bot.b.Send(chat, "Error: Configured target device not found.")
+		} else {
+			device = &d
+		}
+	}
+
+	// The frame shows a single photo: the last one, or a collage of the album
+	lastPath := filepath.Join(photosDir, "telegram_last.jpg")
+	shown := "Photo"
+	collage, _ := bot.settings.Get("telegram_album_collage")
+	if len(imported) > 1 && collage == "true" {
+		if err := bot.saveAlbumCollage(imported, device, lastPath); err != nil {
+			log.Printf("Failed to create album collage: %v", err)
+			copyFile(imported[len(imported)-1].FilePath, lastPath)
+		} else {
+			shown = "Collage"
+		}
+	} else if err := copyFile(imported[len(imported)-1].FilePath, lastPath); err != nil {
+		log.Printf("Failed to update telegram_last.jpg: %v", err)
+	}
+
+	summary := "Photo updated!"
+	if len(photos) > 1 {
+		summary = fmt.Sprintf("Imported %d of %d photos.", len(imported), len(photos))
+	}
+	if len(failed) > 0 {
+		summary += fmt.Sprintf(" Failed: %s.", strings.Join(failed, ", "))
+	}
+
+	if device == nil {
+		bot.b.Send(chat, summary+" It will show up next time the device awakes.")
+		return
+	}
+
+	statusMsg, err := bot.b.Send(chat, summary+" Connecting to device...")
+	if err != nil {
+		log.Printf("Failed to send status message: %v", err)
+		return
+	}
+
+	text := fmt.Sprintf("%s %s displayed on %s!", summary, shown, deviceName(device))
+	if err := bot.devices.PushToHost(device, lastPath, nil); err != nil {
+		log.Printf("Failed to push to device: %v", err)
+		text = summary + " Device is offline/unreachable, so it will show up next time the device awakes."
+	}
+	if _, err := bot.b.Edit(statusMsg, text); err != nil {
+		bot.b.Send(chat, text)
+	}
+}
+
+// importPhoto downloads a single photo and creates its gallery entry
+func (bot *Bot) importPhoto(photosDir string, p incomingPhoto) (*model.Image, error) {
+	path := filepath.Join(photosDir, fmt.Sprintf("telegram_%d%s", time.Now().UnixNano(), p.ext))
+	if err := bot.b.Download(&p.file, path); err != nil {
+		return nil, err
+	}
+
+	width, height, orientation, err := imageops.ReadInfo(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("not a valid image: %w", err)
+	}
+
+	var hash string
+	if bot.dups != nil {
+		var duplicate *model.Image
+		hash, duplicate = bot.dups.Check(path)
+		if duplicate != nil {
+			os.Remove(path)
+			return nil, fmt.Errorf("duplicate of photo #%d", duplicate.ID)
+		}
+	}
+
+	img := model.Image{
+		FilePath:         path,
+		Caption:          p.caption,
+		Source:           "telegram",
+		Width:            width,
+		Height:           height,
+		Orientation:      orientation,
+		CreatedAt:        time.Now(),
+		CapturedAt:       imageops.ReadCaptureTime(path),
+		TelegramUpdateID: p.updateID,
+		PHash:            hash,
+	}
+	if err := bot.db.Create(&img).Error; err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to save photo: %w", err)
+	}
+	if bot.events != nil {
+		bot.events.PhotoImported(&img)
+	}
+	return &img, nil
+}
+
+// saveAlbumCollage arranges the album in a grid sized for the device and
+// saves it as a JPEG
+func (bot *Bot) saveAlbumCollage(photos []model.Image, device *model.Device, path string) error {
+	if len(photos) > maxCollagePhotos {
+		photos = photos[:maxCollagePhotos]
+	}
+
+	width, height := 800, 480
+	if device != nil && device.Width > 0 && device.Height > 0 {
+		width, height = device.Width, device.Height
+		if (device.Orientation == "portrait" && width > height) || (device.Orientation == "landscape" && height > width) {
+			width, height = height, width
+		}
+	}
+
+	imgs := make([]image.Image, 0, len(photos))
+	for _, p := range photos {
+		img, err := loadImageForCollage(p.FilePath)
+		if err != nil {
+			return err
+		}
+		imgs = append(imgs, img)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return jpeg.Encode(f, createGridCollage(imgs, width, height), &jpeg.Options{Quality: 95})
+}
+
+// createGridCollage fills width x height with the images in a grid that
+// follows the canvas orientation, e.g. 2 photos side by side on a landscape
+// frame and stacked on a portrait one.
+func createGridCollage(imgs []image.Image, width, height int) image.Image {
+	n := len(imgs)
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	rows := (n + cols - 1) / cols
+	if height > width {
+		cols, rows = rows, cols
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, img := range imgs {
+		row, col := i/cols, i%cols
+		// The last row is stretched when it is not full
+		rowCols := cols
+		if row == rows-1 && n%cols != 0 {
+			rowCols = n % cols
+		}
+		x0, x1 := col*width/rowCols, (col+1)*width/rowCols
+		y0, y1 := row*height/rows, (row+1)*height/rows
+		imageops.DrawCover(dst, image.Rect(x0, y0, x1, y1), img)
+	}
+	return dst
+}
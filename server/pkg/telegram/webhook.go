@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	// Public URL of this server, e.g. https://frame.example.com. Enables webhook mode.
+	SettingWebhookURL = "telegram_webhook_url"
+	// Secret Telegram sends with every webhook request, generated when empty
+	SettingWebhookSecret = "telegram_webhook_secret"
+
+	// WebhookPath is where the webhook is served, relative to the public URL
+	WebhookPath = "/api/telegram/webhook"
+
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+)
+
+// maxUpdateSize bounds the request body of a webhook call
+const maxUpdateSize = 1 << 20
+
+// webhookPoller registers the webhook with Telegram. Updates are delivered
+// through Bot.ServeHTTP on the main server, so unlike tele.Webhook it never
+// listens on its own port. (tele.Webhook without Listen also closes the stop
+// channel a second time, which panics on Bot.Stop.)
+type webhookPoller struct {
+	hook *tele.Webhook
+}
+
+func (p *webhookPoller) Poll(b *tele.Bot, dest chan tele.Update, stop chan struct{}) {
+	if err := b.SetWebhook(p.hook); err != nil {
+		log.Printf("Failed to set Telegram webhook: %v", err)
+	}
+	<-stop
+}
+
+// newWebhook returns the webhook poller for the configured public URL, or nil
+// to use long polling
+func newWebhook(s SettingsProvider) (*webhookPoller, error) {
+	base, _ := s.Get(SettingWebhookURL)
+	base = strings.TrimRight(strings.TrimSpace(base), "/")
+	if base == "" {
+		return nil, nil
+	}
+
+	secret, _ := s.Get(SettingWebhookSecret)
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(buf)
+		if err := s.Set(SettingWebhookSecret, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	url := base
+	if !strings.HasSuffix(url, WebhookPath) {
+		url += WebhookPath
+	}
+
+	return &webhookPoller{hook: &tele.Webhook{
+		SecretToken: secret,
+		Endpoint:    &tele.WebhookEndpoint{PublicURL: url},
+	}}, nil
+}
+
+// UsesWebhook reports whether the bot receives updates through the webhook
+func (bot *Bot) UsesWebhook() bool {
+	return bot.webhook != nil
+}
+
+// ServeHTTP handles an update posted by Telegram to the webhook
+func (bot *Bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if bot.webhook == nil {
+		http.Error(w, "webhook is not enabled", http.StatusNotFound)
+		return
+	}
+
+	token := r.Header.Get(secretTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(bot.webhook.SecretToken)) != 1 {
+		log.Printf("Telegram: rejected webhook request with invalid secret token from %s", r.RemoteAddr)
+		http.Error(w, "invalid secret token", http.StatusUnauthorized)
+		return
+	}
+
+	var update tele.Update
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUpdateSize)).Decode(&update); err != nil {
+		http.Error(w, "invalid update", http.StatusBadRequest)
+		return
+	}
+
+	// Handlers run asynchronously, Telegram only needs to know the update arrived
+	bot.b.ProcessUpdate(update)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,224 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aitjcize/photoframe-server/server/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tele "gopkg.in/telebot.v3"
+)
+
+const testAllowedID = "51234567"
+
+type memSettings map[string]string
+
+func (s memSettings) Get(key string) (string, error) { return s[key], nil }
+func (s memSettings) Set(key, value string) error    { s[key] = value; return nil }
+
+type fakeDevices struct {
+	collage map[uint]bool
+}
+
+func (f *fakeDevices) PushToHost(*model.Device, string, map[string]string) error { return nil }
+func (f *fakeDevices) IsOnline(*model.Device) bool                               { return true }
+
+func (f *fakeDevices) ListDevices() ([]model.Device, error) {
+	return []model.Device{{ID: 1, Name: "Living Room", Host: "frame.local", Width: 800, Height: 480}}, nil
+}
+
+func (f *fakeDevices) SetCollage(id uint, enabled bool) (*model.Device, error) {
+	f.collage[id] = enabled
+	return &model.Device{ID: id}, nil
+}
+
+// apiCall is a request made by the bot to the Bot API
+type apiCall struct {
+	Method string
+	Params map[string]interface{}
+}
+
+// fakeAPI records the Bot API calls and answers them with an empty success
+type fakeAPI struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls []apiCall
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	api := &fakeAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := map[string]interface{}{}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &params)
+
+		api.mu.Lock()
+		api.calls = append(api.calls, apiCall{Method: filepath.Base(r.URL.Path), Params: params})
+		api.mu.Unlock()
+
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"chat":{"id":1}}}`))
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (a *fakeAPI) find(method string) []apiCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var calls []apiCall
+	for _, c := range a.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func newTestBot(t *testing.T, api *fakeAPI, settings memSettings) (*Bot, *fakeDevices) {
+	pref := tele.Settings{URL: api.URL, Token: "test-token", Offline: true, Synchronous: true}
+	webhook, err := newWebhook(settings)
+	require.NoError(t, err)
+	if webhook != nil {
+		pref.Poller = webhook
+	}
+
+	devices := &fakeDevices{collage: map[uint]bool{}}
+	bot, err := newBot(pref, nil, t.TempDir(), settings, devices, nil, nil, nil, nil)
+	require.NoError(t, err)
+	return bot, devices
+}
+
+func webhookSettings() memSettings {
+	return memSettings{
+		SettingWebhookURL:    "https://frame.example.com/",
+		SettingWebhookSecret: "s3cret",
+		SettingAllowedIDs:    testAllowedID,
+	}
+}
+
+// postUpdate posts a recorded update from testdata to the webhook
+func postUpdate(t *testing.T, bot *Bot, fixture, secret string) *httptest.ResponseRecorder {
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, bytes.NewReader(body))
+	req.Header.Set(secretTokenHeader, secret)
+	rec := httptest.NewRecorder()
+	bot.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNewWebhook(t *testing.T) {
+	hook, err := newWebhook(memSettings{})
+	require.NoError(t, err)
+	assert.Nil(t, hook, "long polling without a public URL")
+
+	settings := memSettings{SettingWebhookURL: "https://frame.example.com/"}
+	hook, err = newWebhook(settings)
+	require.NoError(t, err)
+	assert.Equal(t, "https://frame.example.com/api/telegram/webhook", hook.hook.Endpoint.PublicURL)
+	assert.Len(t, settings[SettingWebhookSecret], 64, "secret is generated and stored")
+	assert.Equal(t, settings[SettingWebhookSecret], hook.hook.SecretToken)
+
+	// The full webhook URL is accepted as well
+	settings[SettingWebhookURL] = "https://frame.example.com/api/telegram/webhook"
+	hook, err = newWebhook(settings)
+	require.NoError(t, err)
+	assert.Equal(t, "https://frame.example.com/api/telegram/webhook", hook.hook.Endpoint.PublicURL)
+}
+
+func TestWebhookCommand(t *testing.T) {
+	api := newFakeAPI(t)
+	bot, _ := newTestBot(t, api, webhookSettings())
+
+	rec := postUpdate(t, bot, "command_devices.json", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	sent := api.find("sendMessage")
+	require.Len(t, sent, 1)
+	assert.Equal(t, testAllowedID, sent[0].Params["chat_id"])
+	assert.Contains(t, sent[0].Params["text"], "1. Living Room (frame.local) - 800x480, collage off, online")
+}
+
+func TestWebhookCallback(t *testing.T) {
+	api := newFakeAPI(t)
+	bot, devices := newTestBot(t, api, webhookSettings())
+
+	rec := postUpdate(t, bot, "callback_collage.json", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, map[uint]bool{1: true}, devices.collage)
+	assert.Len(t, api.find("answerCallbackQuery"), 1)
+	sent := api.find("sendMessage")
+	require.Len(t, sent, 1)
+	assert.Equal(t, "Collage turned on for Living Room.", sent[0].Params["text"])
+}
+
+func TestWebhookRejectsUnauthorizedChat(t *testing.T) {
+	api := newFakeAPI(t)
+	bot, _ := newTestBot(t, api, webhookSettings())
+
+	rec := postUpdate(t, bot, "photo_unauthorized.json", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Empty(t, api.find("getFile"), "photo must not be downloaded")
+	sent := api.find("sendMessage")
+	require.Len(t, sent, 1)
+	assert.Equal(t, "99887766", sent[0].Params["chat_id"])
+	assert.Contains(t, sent[0].Params["text"], "not authorized")
+}
+
+func TestWebhookRejectsInvalidRequests(t *testing.T) {
+	api := newFakeAPI(t)
+	bot, _ := newTestBot(t, api, webhookSettings())
+
+	rec := postUpdate(t, bot, "command_devices.json", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = postUpdate(t, bot, "command_devices.json", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader("{not json"))
+	req.Header.Set(secretTokenHeader, "s3cret")
+	rec = httptest.NewRecorder()
+	bot.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	assert.Empty(t, api.find("sendMessage"))
+}
+
+func TestWebhookDisabledWhenPolling(t *testing.T) {
+	api := newFakeAPI(t)
+	settings := webhookSettings()
+	delete(settings, SettingWebhookURL)
+	bot, _ := newTestBot(t, api, settings)
+
+	assert.False(t, bot.UsesWebhook())
+	rec := postUpdate(t, bot, "command_devices.json", "s3cret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, api.find("sendMessage"))
+}
+
+func TestStartRegistersWebhook(t *testing.T) {
+	api := newFakeAPI(t)
+	bot, _ := newTestBot(t, api, webhookSettings())
+
+	bot.Start()
+	require.Eventually(t, func() bool { return len(api.find("setWebhook")) == 1 }, 2*time.Second, 10*time.Millisecond)
+	bot.Stop()
+
+	params := api.find("setWebhook")[0].Params
+	assert.Equal(t, "https://frame.example.com/api/telegram/webhook", params["url"])
+	assert.Equal(t, "s3cret", params["secret_token"])
+	assert.Empty(t, api.find("deleteWebhook"))
+	assert.Len(t, api.find("setMyCommands"), 1)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type Weather struct {
@@ -86,8 +87,87 @@ func (c *Client) GetWeather(lat, lon string) (*CurrentWeather, error) {
 	return &result.Current, nil
 }
 
+type dailyResponse struct {
+	Daily struct {
+		Time             []string  `json:"time"`
+		WeatherCode      []int     `json:"weathercode"`
+		TemperatureMax   []float64 `json:"temperature_2m_max"`
+		TemperatureMin   []float64 `json:"temperature_2m_min"`
+		PrecipitationMax []int     `json:"precipitation_probability_max"`
+	} `json:"daily"`
+}
+
+// DailyForecast is the forecast for a single day
+type DailyForecast struct {
+	Date                     time.Time
+	WeatherCode              int
+	TemperatureMax           float64
+	TemperatureMin           float64
+	PrecipitationProbability int
+}
+
+// GetForecast returns the daily forecast for the next `days` days, starting today
+func (c *Client) GetForecast(lat, lon string, days int) ([]DailyForecast, error) {
+	if days <= 0 {
+		days = 3
+	}
+	url := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%s&longitude=%s&daily=weathercode,temperature_2m_max,temperature_2m_min,precipitation_probability_max&timezone=auto&forecast_days=%d", lat, lon, days)
+
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("weather api returned status: %d", resp.StatusCode)
+	}
+
+	var result dailyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var forecast []DailyForecast
+	for i, t := range result.Daily.Time {
+		date, err := time.Parse("2006-01-02", t)
+		if err != nil {
+			continue
+		}
+		day := DailyForecast{Date: date}
+		if i < len(result.Daily.WeatherCode) {
+			day.WeatherCode = result.Daily.WeatherCode[i]
+		}
+		if i < len(result.Daily.TemperatureMax) {
+			day.TemperatureMax = result.Daily.TemperatureMax[i]
+		}
+		if i < len(result.Daily.TemperatureMin) {
+			day.TemperatureMin = result.Daily.TemperatureMin[i]
+		}
+		if i < len(result.Daily.PrecipitationMax) {
+			day.PrecipitationProbability = result.Daily.PrecipitationMax[i]
+		}
+		forecast = append(forecast, day)
+	}
+
+	return forecast, nil
+}
+
+func (d DailyForecast) Description() string {
+	return descriptionForCode(d.WeatherCode)
+}
+
+// Icon returns a Material Symbols icon code based on the weather code
+func (d DailyForecast) Icon() string {
+	return iconForCode(d.WeatherCode)
+}
+
 func (c CurrentWeather) Description() string {
-	switch c.WeatherCode {
+	return descriptionForCode(c.WeatherCode)
+}
+
+func descriptionForCode(code int) string {
+	switch code {
 	case 0:
 		return "Clear"
 	case 1, 2, 3:
@@ -113,7 +193,11 @@ func (c CurrentWeather) Description() string {
 
 // Icon returns a Material Symbols icon code based on the weather code
 func (c CurrentWeather) Icon() string {
-	switch c.WeatherCode {
+	return iconForCode(c.WeatherCode)
+}
+
+func iconForCode(code int) string {
+	switch code {
 	case 0:
 		return "\uf157" // clear_day
 	case 1, 2:
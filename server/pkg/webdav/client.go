@@ -0,0 +1,245 @@
+package webdav
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Resource is a file or collection returned by PROPFIND
+type Resource struct {
+	Path         string // Path relative to the client base URL, always starting with "/"
+	IsDir        bool
+	ETag         string
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
+
+type Client struct {
+	BaseURL    string
+	Username   string
+	Password   string
+	basePath   string // Unescaped path component of BaseURL
+	httpClient *http.Client
+}
+
+// NewClient creates a client for a WebDAV root, e.g.
+// https://cloud.example.com/remote.php/dav/files/<user> for Nextcloud.
+func NewClient(baseURL, username, password string, insecure bool) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid webdav url: %s", baseURL)
+	}
+
+	transport := &http.Transport{}
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &Client{
+		BaseURL:  u.String(),
+		Username: username,
+		Password: password,
+		basePath: strings.TrimSuffix(u.Path, "/"),
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   60 * time.Second,
+		},
+	}, nil
+}
+
+func (c *Client) resourceURL(p string) string {
+	p = path.Clean("/" + p)
+	escaped := (&url.URL{Path: p}).EscapedPath()
+	if p == "/" {
+		escaped = "/"
+	}
+	return c.BaseURL + escaped
+}
+
+func (c *Client) do(method, p string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.resourceURL(p), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return c.httpClient.Do(req)
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:">
+  <d:prop>
+    <d:resourcetype/>
+    <d:getetag/>
+    <d:getcontenttype/>
+    <d:getcontentlength/>
+    <d:getlastmodified/>
+  </d:prop>
+</d:propfind>`
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"href"`
+		Propstats []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ETag          string `xml:"getetag"`
+				ContentType   string `xml:"getcontenttype"`
+				ContentLength int64  `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// Stat returns the properties of a single resource (PROPFIND Depth: 0)
+func (c *Client) Stat(p string) (*Resource, error) {
+	resources, err := c.propfind(p, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("webdav: %s not found", p)
+	}
+	return &resources[0], nil
+}
+
+// List returns the direct children of a collection (PROPFIND Depth: 1)
+func (c *Client) List(p string) ([]Resource, error) {
+	resources, err := c.propfind(p, "1")
+	if err != nil {
+		return nil, err
+	}
+
+	self := path.Clean("/" + p)
+	children := make([]Resource, 0, len(resources))
+	for _, r := range resources {
+		if r.Path == self {
+			continue
+		}
+		children = append(children, r)
+	}
+	return children, nil
+}
+
+// Walk lists all files below a collection. Depth: infinity is disabled on most
+// servers (including Nextcloud), so collections are listed one level at a time.
+func (c *Client) Walk(root string) ([]Resource, error) {
+	var files []Resource
+	queue := []string{root}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+
+		children, err := c.List(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range children {
+			if r.IsDir {
+				queue = append(queue, r.Path)
+			} else {
+				files = append(files, r)
+			}
+		}
+	}
+	return files, nil
+}
+
+func (c *Client) propfind(p, depth string) ([]Resource, error) {
+	resp, err := c.do("PROPFIND", p, bytes.NewBufferString(propfindBody), map[string]string{
+		"Depth":        depth,
+		"Content-Type": "application/xml; charset=utf-8",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("webdav authentication failed")
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("webdav PROPFIND %s returned status: %d", p, resp.StatusCode)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("webdav: invalid PROPFIND response: %w", err)
+	}
+
+	resources := make([]Resource, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		relPath, err := c.relativePath(r.Href)
+		if err != nil {
+			continue
+		}
+
+		res := Resource{Path: relPath}
+		for _, ps := range r.Propstats {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			res.IsDir = ps.Prop.ResourceType.Collection != nil
+			res.ETag = strings.Trim(ps.Prop.ETag, `"`)
+			res.ContentType = ps.Prop.ContentType
+			res.Size = ps.Prop.ContentLength
+			if t, err := http.ParseTime(ps.Prop.LastModified); err == nil {
+				res.LastModified = t
+			}
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
+
+// relativePath converts an href (absolute URL or absolute path) to a path relative to the base URL
+func (c *Client) relativePath(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	p := path.Clean("/" + u.Path) // u.Path is already unescaped
+	if !strings.HasPrefix(p+"/", c.basePath+"/") {
+		return "", fmt.Errorf("href %s outside of base path", href)
+	}
+	rel := strings.TrimPrefix(p, c.basePath)
+	if rel == "" {
+		rel = "/"
+	}
+	return rel, nil
+}
+
+// Download streams a file into w
+func (c *Client) Download(p string, w io.Writer) error {
+	resp, err := c.do("GET", p, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webdav GET %s returned status: %d", p, resp.StatusCode)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
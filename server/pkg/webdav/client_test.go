@@ -0,0 +1,112 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+const testPrefix = "/remote.php/dav/files/me"
+
+// newTestServer serves an in-memory WebDAV tree below testPrefix, like Nextcloud
+func newTestServer(t *testing.T, files map[string]string) *httptest.Server {
+	fs := webdav.NewMemFS()
+	ctx := context.Background()
+	for name, content := range files {
+		require.NoError(t, mkdirAll(ctx, fs, path.Dir(name)))
+		f, err := fs.OpenFile(ctx, name, os.O_CREATE|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	handler := &webdav.Handler{Prefix: testPrefix, FileSystem: fs, LockSystem: webdav.NewMemLS()}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func mkdirAll(ctx context.Context, fs webdav.FileSystem, dir string) error {
+	current := ""
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		current += "/" + part
+		if err := fs.Mkdir(ctx, current, 0755); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/Photos/a.jpg":             "jpeg",
+		"/Photos/Trip 2024/b.png":   "png!",
+		"/Photos/Trip 2024/c/d.jpg": "deep",
+	})
+	client, err := NewClient(srv.URL+testPrefix+"/", "me", "secret", false)
+	require.NoError(t, err)
+
+	res, err := client.Stat("/Photos")
+	require.NoError(t, err)
+	assert.Equal(t, "/Photos", res.Path)
+	assert.True(t, res.IsDir)
+
+	// Only direct children, without the collection itself
+	children, err := client.List("/Photos")
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+	sort.Slice(children, func(i, j int) bool { return children[i].Path < children[j].Path })
+	assert.Equal(t, "/Photos/Trip 2024", children[0].Path)
+	assert.True(t, children[0].IsDir)
+	assert.Equal(t, "/Photos/a.jpg", children[1].Path)
+	assert.False(t, children[1].IsDir)
+	assert.Equal(t, int64(4), children[1].Size)
+	assert.NotEmpty(t, children[1].ETag)
+	assert.NotContains(t, children[1].ETag, `"`)
+	assert.False(t, children[1].LastModified.IsZero())
+
+	// Walks into nested collections, with escaped names
+	files, err := client.Walk("/Photos")
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	assert.ElementsMatch(t, []string{"/Photos/a.jpg", "/Photos/Trip 2024/b.png", "/Photos/Trip 2024/c/d.jpg"}, paths)
+
+	var buf bytes.Buffer
+	require.NoError(t, client.Download("/Photos/Trip 2024/b.png", &buf))
+	assert.Equal(t, "png!", buf.String())
+	assert.Error(t, client.Download("/Photos/missing.jpg", &buf))
+
+	_, err = client.Walk("/Missing")
+	assert.Error(t, err)
+}
+
+func TestClient_AuthFailure(t *testing.T) {
+	srv := newTestServer(t, nil)
+	client, err := NewClient(srv.URL+testPrefix, "me", "wrong", false)
+	require.NoError(t, err)
+	_, err = client.Stat("/")
+	assert.EqualError(t, err, "webdav authentication failed")
+
+	_, err = NewClient("ftp://example.com", "", "", false)
+	assert.Error(t, err)
+}